@@ -0,0 +1,39 @@
+package json_go
+
+import "unicode/utf8"
+
+// ParseBytes parses UTF-8 input without decoding all of it to runes first, so a mostly ASCII document
+// takes about a quarter of the memory Parse needs. Only strings are decoded, as they are parsed.
+// The value is the same as from Parse, but error positions are byte offsets,
+// and invalid UTF-8 is only reported once the parser reaches it, as a DecodingError
+// that errors.As takes as a ParseError at a byte offset too.
+func ParseBytes(input []byte) (value JsonValue, err error) {
+	return Options{}.ParseBytes(input)
+}
+
+func (opts Options) ParseBytes(input []byte) (value JsonValue, err error) {
+	p := parser[byte]{opts: opts}
+	return p.parseDocument(input)
+}
+
+// the character at cur and its length in input, bytes are decoded as UTF-8
+func decodeChar[T char](input []T, cur int) (ch rune, size int, err error) {
+	if b, ok := any(input).([]byte); ok && b[cur] >= utf8.RuneSelf {
+		var next int
+		ch, next, err = ReadCode(b, cur)
+		if derr, ok := err.(*DecodingError); ok {
+			derr.runes = utf8.RuneCount(b[:derr.start])
+			derr.inBytes = true
+		}
+		return ch, next - cur, err
+	}
+	return rune(input[cur]), 1, nil
+}
+
+// append ch as UTF-8, a byte of the input is already UTF-8
+func appendChar[T char](dst []byte, ch T) []byte {
+	if b, ok := any(ch).(byte); ok {
+		return append(dst, b)
+	}
+	return utf8.AppendRune(dst, rune(ch))
+}
@@ -0,0 +1,90 @@
+package json_go
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBytes(t *testing.T) {
+	for _, input := range []string{
+		`null`, ` -1.5e3 `, `9223372036854775808`, `"é😀\n"`, "\"啊\U0001f600\"",
+		`[[], {}, [1, [2]], {"a": {"b": null}}]`, "{\"é\": [\"啊\", true], \"b\\u00e9\": {}}",
+	} {
+		expect := MustParse(t, input)
+		value, err := ParseBytes([]byte(input))
+		assert.NoError(t, err, input)
+		assert.Equal(t, expect, value, input)
+	}
+
+	opts := Options{AllowBareWords: true, InternKeys: true, DuplicateKeys: Collect}
+	input := "[{\"é\": ok, \"é\": \"啊\"}, {\"é\": NULL}]"
+	expect, err := opts.Parse(input)
+	assert.NoError(t, err)
+	value, err := opts.ParseBytes([]byte(input))
+	assert.NoError(t, err)
+	assert.Equal(t, expect, value)
+
+	// positions are byte offsets
+	_, err = ParseBytes([]byte("[\"啊\", x]"))
+	assert.Equal(t, &ParseError{8, "bad char: 'x' (0x78)"}, err)
+	_, err = ParseBytes([]byte("[\"啊\"] 啊"))
+	assert.Equal(t, &ParseError{8, "junk after the document: '啊' (0x554a)"}, err)
+	_, err = ParseBytes([]byte("[é]"))
+	assert.Equal(t, &ParseError{1, "bad char: 'é' (0xe9)"}, err)
+	_, err = ParseBytes([]byte("\"\\é\""))
+	assert.Equal(t, &ParseError{2, "bad escape char: 'é' (0xe9)"}, err)
+
+	// invalid UTF-8 where the parser gets to it
+	_, err = ParseBytes([]byte("[\"a\xed\xa0\x80\"]"))
+	assert.Equal(t, &DecodingError{3, 0xed, "surrogate code point", 3, 6, 3, true}, err)
+	_, err = ParseBytes([]byte("[1, \xff]"))
+	assert.Equal(t, &DecodingError{4, 0xff, "bad leading char", 4, 5, 4, true}, err)
+	_, err = ParseBytes([]byte("\"\xe5\x95"))
+	assert.Error(t, err)
+	_, err = ParseBytes(nil)
+	assert.Equal(t, ErrEmptyInput, err)
+}
+
+func FuzzParseBytes(f *testing.F) {
+	for _, seed := range []string{
+		`null`, `[1, -2.5e3, "aé"]`, `{"a": {"b": [true]}}`, "\"\xe5\x95\x8a\"", "[\"\xed\xa0\x80\"]", "[\xff]", `[tru`,
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		expect, experr := Parse(input)
+		value, err := ParseBytes([]byte(input))
+		if (experr == nil) != (err == nil) {
+			t.Fatalf("%q: Parse error %v, ParseBytes error %v", input, experr, err)
+		}
+		if err == nil && !assert.Equal(t, expect, value) {
+			t.Fatalf("%q: different values", input)
+		}
+	})
+}
+
+func BenchmarkParseBytes(b *testing.B) {
+	item := `{"id": 12345, "name": "some name \"quoted\"", "tags": ["a", "b", "c"], "score": -1.5e3, "ok": true, "next": null}`
+	input := []byte("[" + strings.Repeat(item+",", 999) + item + "]")
+	b.Run("Parse", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err := Parse(string(input))
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("ParseBytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, err := ParseBytes(input)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
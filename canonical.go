@@ -0,0 +1,57 @@
+package json_go
+
+import (
+	"sort"
+	"unicode/utf16"
+)
+
+// Canonicalize parses input and writes it in the RFC 8785 canonical form: no whitespace,
+// object keys sorted by their UTF-16 code units, and numbers formatted as IEEE doubles
+// the way ECMAScript does, so equal documents always produce the same bytes.
+func Canonicalize(input string) (output string, err error) {
+	var value JsonValue
+	value, err = Parse(input)
+	if err != nil {
+		return
+	}
+	m := marshaler{canonical: true}
+	err = m.marshal(value, "", 0)
+	output = string(m.buf)
+	return
+}
+
+// MarshalCanonicalPretty is a stable, readable form for files under version control: MarshalIndent with
+// two spaces, keys in sorted order for every kind of object, including the members of a []JsonKeyValue,
+// and a trailing newline, so the same tree always gives the same text whatever the order of the input.
+// Numbers are not reformatted as in RFC 8785: parse with UseDecimal to keep their original text.
+func MarshalCanonicalPretty(value JsonValue) (output string, err error) {
+	m := marshaler{indent: "  ", pretty: true, sortPairs: true}
+	err = m.marshal(value, "", 0)
+	if err != nil {
+		return
+	}
+	output = string(append(m.buf, '\n'))
+	return
+}
+
+// pairs sorted by key, repeated keys in their order
+func sortedPairs(pairs []JsonKeyValue) []JsonKeyValue {
+	if sort.SliceIsSorted(pairs, func(i, j int) bool { return pairs[i].key < pairs[j].key }) {
+		return pairs
+	}
+	sorted := append([]JsonKeyValue(nil), pairs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].key < sorted[j].key })
+	return sorted
+}
+
+// differs from byte order for characters outside the BMP against U+E000 to U+FFFF
+func lessUTF16(a, b string) bool {
+	ua := utf16.Encode([]rune(a))
+	ub := utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
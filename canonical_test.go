@@ -0,0 +1,64 @@
+package json_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalCanonicalPretty(t *testing.T) {
+	expect := "{\n  \"a\": [\n    1.50,\n    1e3,\n    2\n  ],\n  \"b\": {\n    \"c\": null,\n    \"d\": {}\n  }\n}\n"
+	for _, input := range []string{
+		`{"b": {"d": {}, "c": null}, "a": [1.50, 1e3, 2]}`,
+		`{"a":[1.50,1e3,2],"b":{"c":null,"d":{}}}`,
+	} {
+		value, err := ParseWith(input, WithUseDecimal())
+		assert.NoError(t, err)
+		output, err := MarshalCanonicalPretty(value)
+		assert.NoError(t, err)
+		assert.Equal(t, expect, output)
+
+		value, err = ParseWith(input, WithUseDecimal(), WithSortedMaps())
+		assert.NoError(t, err)
+		output, err = MarshalCanonicalPretty(value)
+		assert.NoError(t, err)
+		assert.Equal(t, expect, output)
+	}
+
+	pairs := []JsonKeyValue{NewKeyValue("b", int64(1)), NewKeyValue("a", 0.5), NewKeyValue("b", int64(2))}
+	output, err := MarshalCanonicalPretty(JsonArray{pairs})
+	assert.NoError(t, err)
+	assert.Equal(t, "[\n  {\n    \"a\": 0.5,\n    \"b\": 1,\n    \"b\": 2\n  }\n]\n", output)
+	assert.Equal(t, "b", pairs[0].Key())
+
+	output, err = MarshalCanonicalPretty("x")
+	assert.NoError(t, err)
+	assert.Equal(t, "\"x\"\n", output)
+}
+
+func TestCanonicalize(t *testing.T) {
+	canonical := func(input string, expect string) {
+		output, err := Canonicalize(input)
+		if assert.NoError(t, err, input) {
+			assert.Equal(t, expect, output, input)
+		}
+	}
+
+	// the key sorting example of RFC 8785
+	canonical(`{"€": "Euro Sign", "\r": "Carriage Return", "\ufb33": "Hebrew Letter Dalet With Dagesh",
+		"1": "One", "😀": "Emoji: Grinning Face", "\u0080": "Control", "ö": "Latin Small Letter O With Diaeresis"}`,
+		`{"\r":"Carriage Return","1":"One","`+"\u0080"+`":"Control","ö":"Latin Small Letter O With Diaeresis",`+
+			`"€":"Euro Sign","😀":"Emoji: Grinning Face","`+"\ufb33"+`":"Hebrew Letter Dalet With Dagesh"}`)
+
+	canonical(` [ 1.0, -0.0, 1e21, 1E-7, 0.000001, 123456789012345678, 2e0, "\u001f\/" ] `,
+		`[1,0,1e+21,1e-7,0.000001,123456789012345680,2,"\u001f/"]`)
+	canonical(`{"b": {"d": [], "c": {}}, "a": null}`, `{"a":null,"b":{"c":{},"d":[]}}`)
+
+	_, err := Canonicalize(`[1,]`)
+	assert.Error(t, err)
+
+	assert.True(t, lessUTF16("\U0001F600", "\ufb33"))
+	assert.False(t, "\U0001F600" < "\ufb33")
+	assert.True(t, lessUTF16("a", "ab"))
+	assert.False(t, lessUTF16("ab", "ab"))
+}
@@ -0,0 +1,27 @@
+package json_go
+
+import "os"
+
+// ANSI escapes used by MarshalColor
+const (
+	colorReset  = "\x1b[0m"
+	colorKey    = "\x1b[1;34m"
+	colorString = "\x1b[32m"
+	colorNumber = "\x1b[36m"
+	colorBool   = "\x1b[33m"
+	colorNull   = "\x1b[35m"
+	colorPunct  = "\x1b[2m"
+)
+
+// NoColor makes MarshalColor produce the same output as MarshalIndent.
+// It defaults to true when the NO_COLOR environment variable is set.
+var NoColor = os.Getenv("NO_COLOR") != ""
+
+// MarshalColor is MarshalIndent with ANSI colors for terminal output.
+// Colors are put around the already escaped tokens.
+func MarshalColor(value JsonValue, indent string) (output string, err error) {
+	m := marshaler{indent: indent, pretty: true, color: !NoColor}
+	err = m.marshal(value, "", 0)
+	output = string(m.buf)
+	return
+}
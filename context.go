@@ -0,0 +1,67 @@
+package json_go
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// the context is consulted once per this many values
+const contextCheckInterval = 1024
+
+// ContextError is returned when the context of ParseContext is done before the parse finishes.
+// It unwraps to the context's error.
+type ContextError struct {
+	pos   int
+	cause error
+}
+
+func (err *ContextError) Error() string {
+	return fmt.Sprintf("ContextError at %d: %v", err.pos, err.cause)
+}
+
+func (err *ContextError) Unwrap() error {
+	return err.cause
+}
+
+func ParseContext(ctx context.Context, input string) (value JsonValue, err error) {
+	return Options{}.ParseContext(ctx, input)
+}
+
+// ParseContext aborts with a ContextError once ctx is done.
+func (opts Options) ParseContext(ctx context.Context, input string) (value JsonValue, err error) {
+	if err = ctx.Err(); err != nil {
+		return nil, &ContextError{0, err}
+	}
+
+	var decoded []rune
+	decoded, err = DecodeString(input)
+	if err != nil {
+		return
+	}
+	p := parser[rune]{opts: opts, ctx: ctx}
+	return p.parseDocument(decoded)
+}
+
+// ParseTimeout aborts the parse after d, with an error satisfying
+// errors.Is(err, context.DeadlineExceeded).
+// The deadline is only checked every few values, so the parse may run slightly past it.
+func ParseTimeout(input string, d time.Duration) (value JsonValue, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return ParseContext(ctx, input)
+}
+
+func (p *parser[T]) checkContext(pos int) (err error) {
+	if p.ctx == nil {
+		return
+	}
+	p.values++
+	if p.values%contextCheckInterval != 0 {
+		return
+	}
+	if err = p.ctx.Err(); err != nil {
+		err = &ContextError{pos, err}
+	}
+	return
+}
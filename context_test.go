@@ -0,0 +1,44 @@
+package json_go
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseContext(t *testing.T) {
+	got, err := ParseContext(context.Background(), `[1, {"a": 2}]`)
+	if assert.NoError(t, err) {
+		assert.Equal(t, JsonArray{int64(1), JsonMap{"a": int64(2)}}, got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ParseContext(ctx, `[]`)
+	assert.True(t, errors.Is(err, context.Canceled))
+
+	// canceled in the middle of a large document
+	p := parser[rune]{ctx: ctx}
+	_, err = p.parseDocument([]rune("[" + strings.Repeat("1,", 2*contextCheckInterval) + "1]"))
+	var cerr *ContextError
+	if assert.True(t, errors.As(err, &cerr)) {
+		assert.True(t, cerr.pos > 0)
+		assert.True(t, errors.Is(err, context.Canceled))
+	}
+}
+
+func TestParseTimeout(t *testing.T) {
+	got, err := ParseTimeout(`{"a": [true]}`, time.Minute)
+	if assert.NoError(t, err) {
+		assert.Equal(t, JsonMap{"a": JsonArray{true}}, got)
+	}
+
+	input := "[" + strings.Repeat(`{"a": [1, 2, 3]},`, 100000) + "1]"
+	_, err = ParseTimeout(input, time.Nanosecond)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	t.Log(err)
+}
@@ -0,0 +1,86 @@
+package json_go
+
+import (
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type CSVOptions struct {
+	// write an array or object member as its compact JSON in the cell instead of failing
+	NestedAsJSON bool
+}
+
+func ToCSV(arr JsonArray) (string, error) {
+	return CSVOptions{}.ToCSV(arr)
+}
+
+// ToCSV writes an array of flat objects as CSV. The columns are the sorted union of the keys,
+// after a header row. Missing members and nulls are empty cells, a float64 is written by FormatNumber,
+// an int64 with all its digits and a Decimal as its text.
+func (opts CSVOptions) ToCSV(arr JsonArray) (output string, err error) {
+	columns := map[string]bool{}
+	for i, item := range arr {
+		obj, ok := asMap(item)
+		if !ok {
+			return "", &MarshalError{path: pointerIndex("", i), msg: fmt.Sprintf("expect object, got %s", TypeName(item))}
+		}
+		for key := range obj {
+			columns[key] = true
+		}
+	}
+	header := make([]string, 0, len(columns))
+	for key := range columns {
+		header = append(header, key)
+	}
+	sort.Strings(header)
+
+	buf := strings.Builder{}
+	w := csv.NewWriter(&buf)
+	_ = w.Write(header)
+	row := make([]string, len(header))
+	for i, item := range arr {
+		obj, _ := asMap(item)
+		for j, key := range header {
+			row[j], err = opts.csvCell(obj[key], pointerJoin(pointerIndex("", i), key))
+			if err != nil {
+				return
+			}
+		}
+		_ = w.Write(row)
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+func (opts CSVOptions) csvCell(value JsonValue, path string) (cell string, err error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		cell, err = FormatNumber(v)
+		if merr, ok := err.(*MarshalError); ok {
+			merr.path = path
+		}
+		return
+	case Decimal:
+		return v.String(), nil
+	case JsonArray, JsonMap, SortedMap:
+		if !opts.NestedAsJSON {
+			return "", &MarshalError{path: path, msg: fmt.Sprintf("nested %s in CSV cell", TypeName(value))}
+		}
+		m := marshaler{}
+		err = m.marshal(value, path, 0)
+		return string(m.buf), err
+	default:
+		return "", &MarshalError{path: path, msg: fmt.Sprintf("unsupported type: %T", value)}
+	}
+}
@@ -0,0 +1,62 @@
+package json_go
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToCSV(t *testing.T) {
+	arr := MustParse(t, `[
+		{"name": "a, b", "n": 1, "ok": true},
+		{"name": "say \"hi\"", "x": 2.5e-7, "n": null},
+		{"name": "line\nbreak", "ok": false, "n": 1e21}
+	]`).(JsonArray)
+	output, err := ToCSV(arr)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "n,name,ok,x\n"+
+			"1,\"a, b\",true,\n"+
+			",\"say \"\"hi\"\"\",,2.5e-7\n"+
+			"1e+21,\"line\nbreak\",false,\n", output)
+	}
+
+	output, err = ToCSV(JsonArray{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "\n", output)
+	}
+
+	nested := MustParse(t, `[{"a": 1}, {"a": [1, {"b": "c"}]}]`).(JsonArray)
+	_, err = ToCSV(nested)
+	var merr *MarshalError
+	if assert.True(t, errors.As(err, &merr)) {
+		assert.Equal(t, "/1/a", merr.path)
+	}
+	output, err = CSVOptions{NestedAsJSON: true}.ToCSV(nested)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "a\n1\n\"[1,{\"\"b\"\":\"\"c\"\"}]\"\n", output)
+	}
+
+	// IDs beyond 2^53 are kept
+	output, err = ToCSV(MustParse(t, `[{"id": 9007199254740993}, {"id": -9223372036854775808}]`).(JsonArray))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "id\n9007199254740993\n-9223372036854775808\n", output)
+	}
+
+	decimals, err := ParseWith(`[{"price": 1.50, "big": 123456789012345678901234567890}]`, WithUseDecimal())
+	assert.NoError(t, err)
+	output, err = ToCSV(decimals.(JsonArray))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "big,price\n123456789012345678901234567890,1.50\n", output)
+	}
+
+	_, err = ToCSV(JsonArray{JsonMap{}, "x"})
+	if assert.True(t, errors.As(err, &merr)) {
+		assert.Equal(t, "/1", merr.path)
+	}
+	_, err = ToCSV(JsonArray{JsonMap{"f": math.NaN()}})
+	if assert.True(t, errors.As(err, &merr)) {
+		assert.Equal(t, "/0/f", merr.path)
+	}
+}
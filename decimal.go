@@ -0,0 +1,221 @@
+package json_go
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is a number kept as the exact text of the input, for Options.UseDecimal, so that amounts
+// like 0.1 are never rounded to a float64. It marshals back to the same text.
+//
+// Parsing is a bit cheaper than for float64, as the digits are only checked and copied,
+// but arithmetic goes through math/big with Rat, which allocates. There is no dependency
+// on a decimal package: for one, convert with String, like decimal.RequireFromString(d.String()).
+type Decimal struct {
+	text string
+}
+
+// ParseDecimal makes a Decimal from a JSON number.
+func ParseDecimal(text string) (d Decimal, err error) {
+	input := []byte(text)
+	var next int
+	next, _, err = numberEnd(input, 0)
+	if err == nil && next != len(input) {
+		err = &ParseError{next, "not terminated"}
+	}
+	if err == nil {
+		d.text = text
+	}
+	return
+}
+
+func parseDecimal[T char](input []T, cur int) (value JsonValue, next int, err error) {
+	next, _, err = numberEnd(input, cur)
+	if err != nil {
+		return
+	}
+	text := make([]byte, 0, next-cur)
+	for _, ch := range input[cur:next] {
+		text = append(text, byte(ch))
+	}
+	value = Decimal{string(text)}
+	return
+}
+
+// for LazyBigNumber: int64 or float64 when it holds the exact value from the input, Decimal otherwise
+func parseLazyNumber[T char](input []T, cur int) (value JsonValue, next int, err error) {
+	var isfloat bool
+	next, isfloat, err = numberEnd(input, cur)
+	if err != nil {
+		return
+	}
+	var buf [64]byte
+	text := buf[:0]
+	for _, ch := range input[cur:next] {
+		text = append(text, byte(ch))
+	}
+	if !isfloat {
+		if i, perr := strconv.ParseInt(string(text), 10, 64); perr == nil {
+			return i, next, nil
+		}
+	}
+
+	f, perr := strconv.ParseFloat(string(text), 64)
+	if perr == nil {
+		var shortest [32]byte
+		if sameDecimal(text, strconv.AppendFloat(shortest[:0], f, 'e', -1, 64)) {
+			return f, next, nil
+		}
+	}
+	return Decimal{string(text)}, next, nil
+}
+
+// whether two JSON numbers, or a number and a float formatted with 'e', have the same value
+func sameDecimal(a []byte, b []byte) bool {
+	var bufA, bufB [32]byte
+	negA, digitsA, expA, okA := decimalDigits(a, bufA[:0])
+	negB, digitsB, expB, okB := decimalDigits(b, bufB[:0])
+	if !okA || !okB {
+		return false
+	}
+	if len(digitsA) == 0 && len(digitsB) == 0 {
+		return true // -0 is 0
+	}
+	return negA == negB && expA == expB && string(digitsA) == string(digitsB)
+}
+
+// the significant digits of a number without leading or trailing zeros, and the power of ten of the last one,
+// so "1.50e3" and "1500" are "15" and 2. ok is false for an exponent too large to compare.
+func decimalDigits(text []byte, buf []byte) (negative bool, digits []byte, exp int, ok bool) {
+	i := 0
+	if i < len(text) && text[i] == '-' {
+		negative = true
+		i++
+	}
+	digits = buf
+	afterPoint := false
+	for ; i < len(text) && text[i] != 'e' && text[i] != 'E'; i++ {
+		switch ch := text[i]; {
+		case ch == '.':
+			afterPoint = true
+		case ch == '0' && len(digits) == 0: // leading zero
+			if afterPoint {
+				exp--
+			}
+		default:
+			digits = append(digits, ch)
+			if afterPoint {
+				exp--
+			}
+		}
+	}
+
+	if i < len(text) {
+		e, err := strconv.Atoi(strings.TrimPrefix(string(text[i+1:]), "+"))
+		if err != nil || e > 1<<30 || e < -(1<<30) {
+			return
+		}
+		exp += e
+	}
+	for len(digits) > 0 && digits[len(digits)-1] == '0' {
+		digits = digits[:len(digits)-1]
+		exp++
+	}
+	if len(digits) == 0 {
+		negative, exp = false, 0
+	}
+	ok = true
+	return
+}
+
+// the exact value of an int64, float64 or Decimal as in decimalDigits, ok is false for NaN and Inf.
+// A float64 is written with all the digits of its binary value, which takes at most 767.
+func exactDigits(v JsonValue, buf []byte) (negative bool, digits []byte, exp int, ok bool) {
+	var text []byte
+	switch n := v.(type) {
+	case int64:
+		text = strconv.AppendInt(nil, n, 10)
+	case float64:
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return
+		}
+		text = strconv.AppendFloat(nil, n, 'e', 767, 64)
+	case Decimal:
+		text = []byte(n.text)
+	default:
+		return
+	}
+	return decimalDigits(text, buf)
+}
+
+// whether two numbers have the same exact value, without math/big, so huge exponents are cheap
+func exactEqual(a, b JsonValue) bool {
+	var bufA, bufB [32]byte
+	negA, digitsA, expA, okA := exactDigits(a, bufA[:0])
+	negB, digitsB, expB, okB := exactDigits(b, bufB[:0])
+	return okA && okB && negA == negB && expA == expB && string(digitsA) == string(digitsB)
+}
+
+// String is the number as written.
+func (d Decimal) String() string {
+	return d.text
+}
+
+// Parts splits the number into its sign, the digits before and after the point, and the exponent,
+// so "-1.50e3" is true, "1", "50", 3.
+func (d Decimal) Parts() (negative bool, integer string, fraction string, exponent int, err error) {
+	text := d.text
+	if i := strings.IndexAny(text, "eE"); i >= 0 {
+		exponent, err = strconv.Atoi(strings.TrimPrefix(text[i+1:], "+"))
+		if err != nil {
+			err = &ParseError{i + 1, "exponent out of range"}
+			return
+		}
+		text = text[:i]
+	}
+	if strings.HasPrefix(text, "-") {
+		negative = true
+		text = text[1:]
+	}
+	integer, fraction, _ = strings.Cut(text, ".")
+	return
+}
+
+// Rat is the exact value. Its size grows with the exponent, so check that with Parts for untrusted input.
+func (d Decimal) Rat() *big.Rat {
+	r, _ := new(big.Rat).SetString(d.text)
+	return r
+}
+
+// Float64 is the nearest float64, with an error if the magnitude is too large.
+func (d Decimal) Float64() (f float64, err error) {
+	f, _ = strconv.ParseFloat(d.text, 64)
+	if math.IsInf(f, 0) {
+		err = fmt.Errorf("decimal %s out of float64 range", d.text)
+	}
+	return
+}
+
+// Int64 is the value if it is an integer that fits.
+func (d Decimal) Int64() (i int64, ok bool) {
+	var buf [32]byte
+	negative, digits, exp, ok := exactDigits(d, buf[:0])
+	if !ok || exp < 0 || len(digits)+exp > 19 {
+		return 0, false
+	}
+	if len(digits) == 0 {
+		return 0, true
+	}
+	text := digits
+	if negative {
+		text = append([]byte{'-'}, digits...)
+	}
+	for ; exp > 0; exp-- {
+		text = append(text, '0')
+	}
+	i, err := strconv.ParseInt(string(text), 10, 64)
+	return i, err == nil
+}
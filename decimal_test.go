@@ -0,0 +1,148 @@
+package json_go
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUseDecimal(t *testing.T) {
+	opts := Options{UseDecimal: true}
+	input := `{"price": 0.1, "qty": 3, "big": 123456789012345678901234567890.000001, "tiny": -1.50E-400}`
+	value, err := opts.Parse(input)
+	assert.NoError(t, err)
+	obj := value.(JsonMap)
+	assert.Equal(t, Decimal{"0.1"}, obj["price"])
+	assert.Equal(t, Decimal{"3"}, obj["qty"])
+	assert.Equal(t, "123456789012345678901234567890.000001", obj["big"].(Decimal).String())
+
+	// written back exactly
+	output, err := Marshal(value)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"big":123456789012345678901234567890.000001,"price":0.1,"qty":3,"tiny":-1.50E-400}`, output)
+
+	// exact arithmetic
+	sum := new(big.Rat)
+	for i := 0; i < 3; i++ {
+		sum.Add(sum, obj["price"].(Decimal).Rat())
+	}
+	assert.Equal(t, "3/10", sum.String())
+
+	value, err = opts.ParseBytes([]byte(`[1e400]`))
+	assert.NoError(t, err)
+	assert.Equal(t, JsonArray{Decimal{"1e400"}}, value)
+	_, err = opts.Parse(`[1.]`)
+	assert.Equal(t, &ParseError{3, "expect digits"}, err)
+}
+
+func TestDecimal(t *testing.T) {
+	d, err := ParseDecimal("-1.50e+3")
+	assert.NoError(t, err)
+	negative, integer, fraction, exponent, err := d.Parts()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{true, "1", "50", 3}, []interface{}{negative, integer, fraction, exponent})
+
+	f, err := d.Float64()
+	assert.NoError(t, err)
+	assert.Equal(t, -1500.0, f)
+	i, ok := d.Int64()
+	assert.True(t, ok)
+	assert.Equal(t, int64(-1500), i)
+
+	d, _ = ParseDecimal("0.5")
+	_, ok = d.Int64()
+	assert.False(t, ok)
+	d, _ = ParseDecimal("99999999999999999999")
+	_, ok = d.Int64()
+	assert.False(t, ok)
+	d, _ = ParseDecimal("1e400")
+	_, err = d.Float64()
+	assert.Error(t, err)
+
+	for _, bad := range []string{"", "+1", "01", "1.", ".5", "1e", "1 ", "x"} {
+		_, err = ParseDecimal(bad)
+		assert.Error(t, err, bad)
+	}
+
+	// compared by value
+	one, _ := ParseDecimal("1.000")
+	assert.True(t, Equal(one, int64(1)))
+	assert.True(t, Equal(1.0, one))
+	assert.True(t, Equal(one, Decimal{"10e-1"}))
+	assert.False(t, Equal(Decimal{"0.1"}, 0.1))
+	assert.False(t, Equal(one, "1"))
+	assert.True(t, Equal(Decimal{"0.1000000000000000055511151231257827021181583404541015625"}, 0.1))
+	assert.True(t, Equal(Decimal{"-0.0"}, 0.0))
+	assert.True(t, Equal(Decimal{"9007199254740993"}, int64(9007199254740993)))
+	assert.False(t, Equal(Decimal{"9007199254740993"}, float64(9007199254740992)))
+
+	// huge exponents are compared without building the value
+	assert.True(t, Equal(Decimal{"1e10000000"}, Decimal{"1e10000000"}))
+	assert.True(t, Equal(Decimal{"1e10000000"}, Decimal{"10.0e9999999"}))
+	assert.False(t, Equal(Decimal{"1e10000000"}, Decimal{"1e10000001"}))
+	assert.True(t, IsEmpty(Decimal{"0e99999999"}))
+	assert.False(t, IsEmpty(Decimal{"1e-99999999"}))
+	i, ok = Decimal{"1.2e1"}.Int64()
+	assert.True(t, ok)
+	assert.Equal(t, int64(12), i)
+	i, ok = Decimal{"-0.0e5"}.Int64()
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), i)
+	_, ok = Decimal{"1e1000000"}.Int64()
+	assert.False(t, ok)
+	i, ok = Decimal{"-9223372036854775808"}.Int64()
+	assert.True(t, ok)
+	assert.Equal(t, int64(math.MinInt64), i)
+
+	// canonical form is the nearest double
+	m := marshaler{canonical: true}
+	assert.NoError(t, m.marshal(JsonArray{one, Decimal{"1e2"}}, "", 0))
+	assert.Equal(t, `[1,100]`, string(m.buf))
+	m = marshaler{canonical: true}
+	assert.Error(t, m.marshal(JsonArray{Decimal{"1e400"}}, "", 0))
+}
+
+func TestLazyBigNumber(t *testing.T) {
+	for _, c := range []struct {
+		input  string
+		expect JsonValue
+	}{
+		{"1", int64(1)},
+		{"-9223372036854775808", int64(math.MinInt64)},
+		{"9223372036854775808", Decimal{"9223372036854775808"}},
+		{"123456789012345678901", Decimal{"123456789012345678901"}},
+		{"0.1", 0.1},
+		{"1.50", 1.5},
+		{"-0.0", math.Copysign(0, -1)},
+		{"1e22", 1e22},
+		{"1E+2", 100.0},
+		{"0.05e-3", 0.00005},
+		{"1.7976931348623157e308", math.MaxFloat64},
+		{"5e-324", 5e-324},
+		// float64 just loses a digit
+		{"0.30000000000000004", 0.30000000000000004},
+		{"0.300000000000000041", Decimal{"0.300000000000000041"}},
+		{"0.30000000000000001", Decimal{"0.30000000000000001"}},
+		{"9007199254740992.0", 9007199254740992.0},
+		{"9007199254740993.0", Decimal{"9007199254740993.0"}},
+		{"9007199254740993", int64(9007199254740993)},
+		{"1e400", Decimal{"1e400"}},
+		{"1e-400", Decimal{"1e-400"}},
+		{"1e99999999999999999999", Decimal{"1e99999999999999999999"}},
+	} {
+		value, err := ParseWith(c.input, WithLazyBigNumber())
+		assert.NoError(t, err, c.input)
+		assert.Equal(t, c.expect, value, c.input)
+	}
+
+	value, err := ParseWith("[2.0, 0.30000000000000001]", WithLazyBigNumber(), WithCoerceWholeFloatsToInt())
+	assert.NoError(t, err)
+	assert.Equal(t, JsonArray{int64(2), Decimal{"0.30000000000000001"}}, value)
+	value, err = ParseWith("0.1", WithLazyBigNumber(), WithUseDecimal())
+	assert.NoError(t, err)
+	assert.Equal(t, Decimal{"0.1"}, value)
+	_, err = ParseWith("1.", WithLazyBigNumber())
+	assert.Equal(t, &ParseError{2, "expect digits"}, err)
+}
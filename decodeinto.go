@@ -0,0 +1,204 @@
+package json_go
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DecodeInto is Unmarshal without the intermediate JsonValue tree: the tokens of a Decoder
+// are stored into out as they are read. Only values that need the tree anyway,
+// for JsonUnmarshaler, JsonValue and interface{} destinations, are built as a JsonValue.
+// The results and UnmarshalErrors are the same as Unmarshal, syntax errors are reported by the Decoder.
+func DecodeInto(input string, out interface{}) (err error) {
+	return UnmarshalOptions{}.DecodeInto(input, out)
+}
+
+func (opts UnmarshalOptions) DecodeInto(input string, out interface{}) (err error) {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &UnmarshalError{msg: fmt.Sprintf("expect non-nil pointer, got %T", out)}
+	}
+
+	s := streamUnmarshaler{u: unmarshaler{opts: opts}, d: NewDecoder(strings.NewReader(input))}
+	var tok Token
+	tok, err = s.d.token()
+	if err != nil {
+		return
+	}
+	err = s.decode(tok, rv.Elem(), "")
+	if err != nil {
+		return
+	}
+
+	var ch rune
+	ch, err = s.d.skipSpace()
+	if err == nil && ch >= 0 {
+		err = trailingError(s.d.pos, ch)
+	}
+	return
+}
+
+type streamUnmarshaler struct {
+	u unmarshaler
+	d *Decoder
+}
+
+// decode the value starting with tok into rv
+func (s *streamUnmarshaler) decode(tok Token, rv reflect.Value, path string) (err error) {
+	if tok.Kind == Scalar || rv.CanAddr() && rv.Addr().Type().Implements(jsonUnmarshalerType) {
+		return s.fallback(tok, rv, path)
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return s.decode(tok, rv.Elem(), path)
+	case reflect.Slice:
+		if tok.Kind != BeginArray || rv.Type().Elem().Kind() == reflect.Uint8 {
+			return s.fallback(tok, rv, path)
+		}
+		return s.decodeSlice(rv, path)
+	case reflect.Array:
+		if tok.Kind != BeginArray {
+			return s.fallback(tok, rv, path)
+		}
+		return s.decodeArray(rv, path)
+	case reflect.Map:
+		if tok.Kind != BeginObject || !isMapKeyType(rv.Type().Key()) {
+			return s.fallback(tok, rv, path)
+		}
+		return s.decodeMap(rv, path)
+	case reflect.Struct:
+		if tok.Kind != BeginObject || rv.Type() == timeType {
+			return s.fallback(tok, rv, path)
+		}
+		return s.decodeStruct(rv, path)
+	default:
+		return s.fallback(tok, rv, path)
+	}
+}
+
+// build the value as a tree and store it like Unmarshal
+func (s *streamUnmarshaler) fallback(tok Token, rv reflect.Value, path string) (err error) {
+	var value JsonValue
+	value, err = s.d.decodeFrom(tok)
+	if err != nil {
+		return
+	}
+	return s.u.unmarshal(value, rv, path)
+}
+
+// the existing backing array is reused
+func (s *streamUnmarshaler) decodeSlice(rv reflect.Value, path string) (err error) {
+	if rv.IsNil() {
+		rv.Set(reflect.MakeSlice(rv.Type(), 0, 0))
+	}
+	zero := reflect.Zero(rv.Type().Elem())
+	rv.SetLen(0)
+	for i := 0; ; i++ {
+		var tok Token
+		tok, err = s.d.token()
+		if err != nil || tok.Kind == EndArray {
+			return
+		}
+		rv.Set(reflect.Append(rv, zero))
+		err = s.decode(tok, rv.Index(i), pointerIndex(path, i))
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (s *streamUnmarshaler) decodeArray(rv reflect.Value, path string) (err error) {
+	i := 0
+	for ; ; i++ {
+		var tok Token
+		tok, err = s.d.token()
+		if err != nil {
+			return
+		}
+		if tok.Kind == EndArray {
+			break
+		}
+		if i >= rv.Len() {
+			_, err = s.d.decodeFrom(tok)
+		} else {
+			err = s.decode(tok, rv.Index(i), pointerIndex(path, i))
+		}
+		if err != nil {
+			return
+		}
+	}
+	for ; i < rv.Len(); i++ {
+		rv.Index(i).SetZero()
+	}
+	return
+}
+
+func (s *streamUnmarshaler) decodeMap(rv reflect.Value, path string) (err error) {
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMap(rv.Type()))
+	}
+	keyType := rv.Type().Key()
+	for {
+		var tok Token
+		tok, err = s.d.token()
+		if err != nil || tok.Kind == EndObject {
+			return
+		}
+		key := tok.Value.(string)
+
+		var kv reflect.Value
+		kv, err = mapKey(keyType, key, path)
+		if err != nil {
+			return
+		}
+
+		tok, err = s.d.token()
+		if err != nil {
+			return
+		}
+		elem := reflect.New(rv.Type().Elem()).Elem()
+		err = s.decode(tok, elem, pointerJoin(path, key))
+		if err != nil {
+			return
+		}
+		rv.SetMapIndex(kv, elem)
+	}
+}
+
+func (s *streamUnmarshaler) decodeStruct(rv reflect.Value, path string) (err error) {
+	fields := typeFields(rv.Type())
+	for {
+		var tok Token
+		tok, err = s.d.token()
+		if err != nil || tok.Kind == EndObject {
+			return
+		}
+		key := tok.Value.(string)
+
+		field, ok := lookupField(fields, key)
+		if !ok {
+			if s.u.opts.DisallowUnknownFields {
+				return &UnmarshalError{path: pointerJoin(path, key), msg: fmt.Sprintf("unknown field %q", key)}
+			}
+			err = s.d.SkipValue()
+			if err != nil {
+				return
+			}
+			continue
+		}
+
+		tok, err = s.d.token()
+		if err != nil {
+			return
+		}
+		err = s.decode(tok, fieldByIndexAlloc(rv, field.index), pointerJoin(path, key))
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package json_go
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type decodeItem struct {
+	ID    int               `json:"id"`
+	Name  string            `json:"name"`
+	Tags  []string          `json:"tags"`
+	Attrs map[string]string `json:"attrs"`
+	Score *float64          `json:"score"`
+}
+
+type decodeDoc struct {
+	Items   []decodeItem     `json:"items"`
+	Pair    [2]int           `json:"pair"`
+	ByID    map[int]bool     `json:"by_id"`
+	Flex    flexInt          `json:"flex"`
+	Any     interface{}      `json:"any"`
+	Tree    JsonValue        `json:"tree"`
+	When    time.Time        `json:"when"`
+	Wait    time.Duration    `json:"wait"`
+	Data    []byte           `json:"data"`
+	Nested  *decodeDoc       `json:"nested"`
+	Counts  map[string][]int `json:"counts"`
+	Ignored string           `json:"-"`
+}
+
+// DecodeInto must agree with Unmarshal
+func decodeBoth(t *testing.T, input string, newOut func() interface{}) {
+	expect, got := newOut(), newOut()
+	expectErr := Unmarshal(input, expect)
+	err := DecodeInto(input, got)
+	if expectErr != nil {
+		assert.Error(t, err, input)
+		var uerr *UnmarshalError
+		if errors.As(expectErr, &uerr) {
+			assert.Equal(t, expectErr, err, input)
+		}
+		t.Log(input, "\t", err)
+		return
+	}
+	if assert.NoError(t, err, input) {
+		assert.Equal(t, expect, got, input)
+	}
+}
+
+func TestDecodeInto(t *testing.T) {
+	doc := func() interface{} { return &decodeDoc{} }
+	decodeBoth(t, `{
+		"items": [{"id": 1, "name": "a", "tags": ["x", "y"], "attrs": {"k": "v"}, "score": 1.5},
+			{"ID": 2.0, "score": null, "unknown": [1, {"a": []}]}],
+		"pair": [1, 2, 3],
+		"by_id": {"-1": true, "2": false},
+		"flex": "7",
+		"any": {"a": [1, 2.5, null]},
+		"tree": [{"b": true}],
+		"when": "2024-01-02T03:04:05Z",
+		"wait": "1m30s",
+		"data": "AQI=",
+		"nested": {"pair": [5], "items": []},
+		"counts": {"z": [], "y": null},
+		"Ignored": "x"
+	}`, doc)
+	decodeBoth(t, `{}`, doc)
+	decodeBoth(t, `null`, doc)
+	decodeBoth(t, ` {"nested": null} `, doc)
+
+	decodeBoth(t, `[]`, doc)
+	decodeBoth(t, `{"items": {}}`, doc)
+	decodeBoth(t, `{"items": [{"id": "1"}]}`, doc)
+	decodeBoth(t, `{"pair": [1, true]}`, doc)
+	decodeBoth(t, `{"by_id": {"x": true}}`, doc)
+	decodeBoth(t, `{"flex": []}`, doc)
+	decodeBoth(t, `{"when": {}}`, doc)
+	decodeBoth(t, `{"data": [1]}`, doc)
+	decodeBoth(t, `{"nested": {"nested": {"items": [{"tags": [1]}]}}}`, doc)
+
+	// syntax errors
+	decodeBoth(t, ``, doc)
+	decodeBoth(t, `{"items": [}`, doc)
+	decodeBoth(t, `{"unknown": [1, }`, doc)
+	decodeBoth(t, `{"pair": [1, 2]`, doc)
+	decodeBoth(t, `{} {}`, doc)
+	err := DecodeInto(`{} x`, &decodeDoc{})
+	assert.Equal(t, &ParseError{3, "junk after the document: 'x' (0x78)"}, err)
+
+	decodeBoth(t, `[1, 2]`, func() interface{} { return &[]int{} })
+	decodeBoth(t, `{"a": {"b": 1}}`, func() interface{} { return &map[string]map[string]int{} })
+	decodeBoth(t, `{"true": 1}`, func() interface{} { return &map[bool]int{} })
+	decodeBoth(t, `"s"`, func() interface{} { return new(string) })
+	decodeBoth(t, `{"a": 1}`, func() interface{} { return new(interface{}) })
+
+	assert.Error(t, DecodeInto(`{}`, decodeDoc{}))
+	assert.Error(t, DecodeInto(`{}`, (*decodeDoc)(nil)))
+}
+
+func TestDecodeIntoReuse(t *testing.T) {
+	got := decodeDoc{Items: make([]decodeItem, 0, 10), Pair: [2]int{8, 9}, ByID: map[int]bool{5: true}}
+	backing := reflect.ValueOf(got.Items).Pointer()
+	err := DecodeInto(`{"items": [{"id": 1}], "pair": [1], "by_id": {"6": false}}`, &got)
+	if assert.NoError(t, err) {
+		assert.Equal(t, []decodeItem{{ID: 1}}, got.Items)
+		assert.Equal(t, backing, reflect.ValueOf(got.Items).Pointer())
+		assert.Equal(t, [2]int{1, 0}, got.Pair)
+		assert.Equal(t, map[int]bool{5: true, 6: false}, got.ByID)
+	}
+}
+
+func TestDecodeIntoDisallowUnknownFields(t *testing.T) {
+	opts := UnmarshalOptions{DisallowUnknownFields: true}
+	err := opts.DecodeInto(`{"items": [{"id": 1, "extra": 2}]}`, &decodeDoc{})
+	var uerr *UnmarshalError
+	if assert.True(t, errors.As(err, &uerr)) {
+		assert.Equal(t, "/items/0/extra", uerr.path)
+	}
+}
+
+func benchmarkDecodeInput() string {
+	item := `{"id": 12345, "name": "some name", "tags": ["a", "b", "c"], "attrs": {"k1": "v1", "k2": "v2"}, "score": -1.5}`
+	return `{"items": [` + strings.Repeat(item+",", 99) + item + `], "pair": [1, 2]}`
+}
+
+func BenchmarkDecodeInto(b *testing.B) {
+	input := benchmarkDecodeInput()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var doc decodeDoc
+		if err := DecodeInto(input, &doc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	input := benchmarkDecodeInput()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var doc decodeDoc
+		if err := Unmarshal(input, &doc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,495 @@
+package json_go
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+type TokenKind int
+
+const (
+	BeginArray TokenKind = iota + 1
+	EndArray
+	BeginObject
+	EndObject
+	Key    // Value is the key string
+	Scalar // Value is a string, int64, float64, bool or nil
+	Comma  // only reported by Scanner
+	Colon  // only reported by Scanner
+)
+
+type Token struct {
+	Kind  TokenKind
+	Value JsonValue
+}
+
+// what the decoder expects next
+type decodeState int
+
+const (
+	stateTop         decodeState = iota // a value or EOF
+	stateValue                          // a value
+	stateArrayStart                     // a value or ']'
+	stateArrayComma                     // ',' or ']'
+	stateObjectStart                    // a key or '}'
+	stateObjectKey                      // a key
+	stateObjectColon                    // ':'
+	stateObjectComma                    // ',' or '}'
+)
+
+// Decoder reads a stream of JSON values from an io.Reader as tokens,
+// without holding more than the current scalar in memory.
+// Positions in errors are rune offsets from the start of the stream.
+type Decoder struct {
+	r      *bufio.Reader
+	pos    int // runes consumed
+	offset int // bytes consumed
+	state  decodeState
+	stack  []rune // open brackets
+	buf    []rune // reused for each scalar
+	err    error
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &Decoder{r: br}
+}
+
+// peekRune returns -1 on EOF
+func (d *Decoder) peekRune() (ch rune, size int, err error) {
+	buf, err := d.r.Peek(1)
+	if len(buf) == 0 {
+		if err == io.EOF {
+			err = nil
+		}
+		return -1, 0, err
+	}
+	if buf[0] < utf8.RuneSelf {
+		return rune(buf[0]), 1, nil
+	}
+
+	buf, err = d.r.Peek(utf8.UTFMax)
+	if err != nil && err != io.EOF {
+		return
+	}
+	ch, size, err = ReadCode(buf, 0)
+	err = shiftDecodingError(err, d.offset)
+	if derr, ok := err.(*DecodingError); ok {
+		derr.runes = d.pos
+	}
+	return
+}
+
+func (d *Decoder) readRune() (ch rune, err error) {
+	var size int
+	ch, size, err = d.peekRune()
+	if err == nil && ch >= 0 {
+		_, _ = d.r.Discard(size)
+		d.pos++
+		d.offset += size
+	}
+	return
+}
+
+func (d *Decoder) skipSpace() (ch rune, err error) {
+	for {
+		ch, _, err = d.peekRune()
+		if err != nil {
+			return
+		}
+		switch ch {
+		case ' ', '\t', '\n', '\r':
+			_, _ = d.readRune()
+		default:
+			return
+		}
+	}
+}
+
+func (d *Decoder) fail(pos int, msg string) error {
+	d.err = &ParseError{pos, msg}
+	return d.err
+}
+
+// Token returns the next token, or io.EOF after the last top-level value.
+// Commas and colons are consumed silently.
+func (d *Decoder) Token() (tok Token, err error) {
+	if d.err != nil {
+		return tok, d.err
+	}
+
+	for {
+		var ch rune
+		ch, err = d.skipSpace()
+		if err != nil {
+			d.err = err
+			return
+		}
+
+		switch d.state {
+		case stateTop:
+			if ch < 0 {
+				return tok, io.EOF
+			}
+			return d.value(ch)
+		case stateValue:
+			return d.value(ch)
+		case stateArrayStart:
+			if ch == ']' {
+				return d.end()
+			}
+			return d.value(ch)
+		case stateArrayComma:
+			switch ch {
+			case ',':
+				_, _ = d.readRune()
+				d.state = stateValue
+			case ']':
+				return d.end()
+			default:
+				return tok, d.fail(d.pos, "expect ']' or ','")
+			}
+		case stateObjectStart, stateObjectKey:
+			if ch == '}' && d.state == stateObjectStart {
+				return d.end()
+			}
+			if ch != '"' {
+				return tok, d.fail(d.pos, "expect object key")
+			}
+			tok.Kind = Key
+			tok.Value, err = d.readString()
+			d.state = stateObjectColon
+			return
+		case stateObjectColon:
+			if ch != ':' {
+				return tok, d.fail(d.pos, "expect ':'")
+			}
+			_, _ = d.readRune()
+			d.state = stateValue
+		case stateObjectComma:
+			switch ch {
+			case ',':
+				_, _ = d.readRune()
+				d.state = stateObjectKey
+			case '}':
+				return d.end()
+			default:
+				return tok, d.fail(d.pos, "expect '}' or ','")
+			}
+		}
+	}
+}
+
+func (d *Decoder) end() (tok Token, err error) {
+	bracket, _ := d.readRune()
+	d.stack = d.stack[:len(d.stack)-1]
+	if bracket == ']' {
+		tok.Kind = EndArray
+	} else {
+		tok.Kind = EndObject
+	}
+	d.afterValue()
+	return
+}
+
+func (d *Decoder) afterValue() {
+	switch {
+	case len(d.stack) == 0:
+		d.state = stateTop
+	case d.stack[len(d.stack)-1] == '[':
+		d.state = stateArrayComma
+	default:
+		d.state = stateObjectComma
+	}
+}
+
+func (d *Decoder) value(ch rune) (tok Token, err error) {
+	switch {
+	case ch < 0:
+		return tok, d.fail(d.pos, "expect something, got EOS")
+	case ch == '[' || ch == '{':
+		_, _ = d.readRune()
+		d.stack = append(d.stack, ch)
+		if ch == '[' {
+			tok.Kind = BeginArray
+			d.state = stateArrayStart
+		} else {
+			tok.Kind = BeginObject
+			d.state = stateObjectStart
+		}
+		return
+	case ch == '"':
+		tok.Value, err = d.readString()
+	case ch == '-' || IsDigit(ch):
+		tok.Value, err = d.readScalar(isNumberChar, ParseNum)
+	case 'a' <= ch && ch <= 'z':
+		tok.Value, err = d.readScalar(isLetter, ParseBoolNull)
+	default:
+		return tok, d.fail(d.pos, fmt.Sprintf("bad char: '%c' (%#x)", ch, ch))
+	}
+
+	tok.Kind = Scalar
+	d.afterValue()
+	return
+}
+
+func isNumberChar(ch rune) bool {
+	return IsDigit(ch) || ch == '-' || ch == '+' || ch == '.' || ch == 'e' || ch == 'E'
+}
+
+func isLetter(ch rune) bool {
+	return ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z')
+}
+
+// buffer the runes of a number or literal and hand them to the in-memory parser
+func (d *Decoder) readScalar(accept func(rune) bool, parse ParseFunc) (value JsonValue, err error) {
+	start := d.pos
+	buf := d.buf[:0]
+	for {
+		var ch rune
+		ch, _, err = d.peekRune()
+		if err != nil {
+			d.err = err
+			return
+		}
+		if ch < 0 || !accept(ch) {
+			break
+		}
+		_, _ = d.readRune()
+		buf = append(buf, ch)
+	}
+	d.buf = buf
+
+	var next int
+	value, next, err = parse(buf, 0)
+	if err == nil && next != len(buf) {
+		err = &ParseError{next, "not terminated"}
+	}
+	if err != nil {
+		d.err = shiftError(err, start)
+		err = d.err
+	}
+	return
+}
+
+// buffer the runes of a string literal and hand them to ParseString
+func (d *Decoder) readString() (value string, err error) {
+	start := d.pos
+	ch, _ := d.readRune()
+	buf := append(d.buf[:0], ch)
+	escaped := false
+	for {
+		ch, err = d.readRune()
+		if err != nil {
+			d.err = err
+			return
+		}
+		if ch < 0 {
+			break
+		}
+		buf = append(buf, ch)
+		if escaped {
+			escaped = false
+		} else if ch == '\\' {
+			escaped = true
+		} else if ch == '"' {
+			break
+		}
+	}
+	d.buf = buf
+
+	value, _, err = ParseString(buf, 0)
+	if err != nil {
+		d.err = shiftError(err, start)
+		err = d.err
+	}
+	return
+}
+
+func shiftError(err error, offset int) error {
+	if perr, ok := err.(*ParseError); ok {
+		return &ParseError{perr.pos + offset, perr.msg}
+	}
+	return err
+}
+
+func shiftDecodingError(err error, offset int) error {
+	if derr, ok := err.(*DecodingError); ok {
+		return &DecodingError{derr.pos + offset, derr.char, derr.msg, derr.start + offset, derr.end + offset, derr.runes, false}
+	}
+	return err
+}
+
+// More reports whether there is another element in the current array or object.
+func (d *Decoder) More() bool {
+	if d.err != nil {
+		return false
+	}
+	ch, err := d.skipSpace()
+	return err == nil && ch >= 0 && ch != ']' && ch != '}'
+}
+
+// Decode reads the next complete value, or returns io.EOF after the last top-level value.
+func (d *Decoder) Decode() (value JsonValue, err error) {
+	var tok Token
+	tok, err = d.Token()
+	if err != nil {
+		return
+	}
+	return d.decodeFrom(tok)
+}
+
+// ParseOne parses a single value from the start of r and returns a reader positioned right after it,
+// with the bytes already buffered, for JSON embedded in other framing. Leading whitespace is skipped.
+// A number or literal ends at the first byte that can't continue it. io.EOF is returned if r is empty.
+func ParseOne(r io.Reader) (value JsonValue, rest *bufio.Reader, err error) {
+	d := NewDecoder(r)
+	value, err = d.Decode()
+	return value, d.r, err
+}
+
+// TeeParse parses a single document from r while copying every byte read to w unchanged,
+// to forward a body as it came after checking it. w gets the bytes as the parser reads them,
+// so on error it has a prefix of the input that goes at least up to the error, and a little after.
+// An error from w stops the parse and is returned.
+func TeeParse(r io.Reader, w io.Writer) (value JsonValue, err error) {
+	return NewDecoder(io.TeeReader(r, w)).decodeDocument()
+}
+
+// ErrTooLarge is returned by ParseReaderLimit for input longer than the limit.
+var ErrTooLarge = errors.New("input larger than the limit")
+
+// ParseReaderLimit parses a single document from r, and fails with ErrTooLarge as soon as more than
+// maxBytes have been read, like behind an http.MaxBytesReader, without reading the rest of r.
+// The input is read as the parse goes, whitespace after the document counts too.
+func ParseReaderLimit(r io.Reader, maxBytes int64) (value JsonValue, err error) {
+	return NewDecoder(&limitReader{r, maxBytes}).decodeDocument()
+}
+
+type limitReader struct {
+	r         io.Reader
+	remaining int64 // -1 once over the limit
+}
+
+func (l *limitReader) Read(p []byte) (n int, err error) {
+	if l.remaining < 0 {
+		return 0, ErrTooLarge
+	}
+	// one byte more tells if the input goes over, remaining+1 can't overflow here
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining+1]
+	}
+	n, err = l.r.Read(p)
+	if int64(n) <= l.remaining {
+		l.remaining -= int64(n)
+		return
+	}
+	n = int(l.remaining)
+	l.remaining = -1
+	return n, ErrTooLarge
+}
+
+// the whole input as one document, nil on error
+func (d *Decoder) decodeDocument() (value JsonValue, err error) {
+	value, err = d.Decode()
+	if err == io.EOF {
+		err = ErrEmptyInput
+	}
+	if err == nil {
+		// read to the end, for the TeeParse writer and the limit
+		var ch rune
+		ch, err = d.skipSpace()
+		if err == nil && ch >= 0 {
+			err = trailingError(d.pos, ch)
+		}
+	}
+	if err != nil {
+		value = nil
+	}
+	return
+}
+
+func (d *Decoder) decodeFrom(tok Token) (value JsonValue, err error) {
+	switch tok.Kind {
+	case Scalar:
+		value = tok.Value
+	case BeginArray:
+		arr := JsonArray{}
+		for {
+			tok, err = d.token()
+			if err != nil || tok.Kind == EndArray {
+				break
+			}
+			var item JsonValue
+			item, err = d.decodeFrom(tok)
+			if err != nil {
+				break
+			}
+			arr = append(arr, item)
+		}
+		value = arr
+	case BeginObject:
+		obj := JsonMap{}
+		for {
+			tok, err = d.token()
+			if err != nil || tok.Kind == EndObject {
+				break
+			}
+			key := tok.Value.(string)
+			var item JsonValue
+			item, err = d.Decode()
+			if err != nil {
+				break
+			}
+			obj[key] = item
+		}
+		value = obj
+	default:
+		err = d.fail(d.pos, "unexpected end of container")
+	}
+	return
+}
+
+// like Token, but EOF inside a value is an error
+func (d *Decoder) token() (tok Token, err error) {
+	tok, err = d.Token()
+	if err == io.EOF {
+		err = d.fail(d.pos, "expect something, got EOS")
+	}
+	return
+}
+
+// SkipValue consumes the next value without building it.
+func (d *Decoder) SkipValue() (err error) {
+	depth := 0
+	for {
+		var tok Token
+		if depth == 0 {
+			tok, err = d.Token()
+		} else {
+			tok, err = d.token()
+		}
+		if err != nil {
+			return
+		}
+
+		switch tok.Kind {
+		case BeginArray, BeginObject:
+			depth++
+		case EndArray, EndObject:
+			depth--
+			if depth < 0 {
+				return d.fail(d.pos, "unexpected end of container")
+			}
+		}
+		if depth == 0 && tok.Kind != Key {
+			return
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package json_go
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecoderToken(t *testing.T) {
+	d := NewDecoder(strings.NewReader(` {"a": [1, "x\"", true], "b": {}} null`))
+	expect := []Token{
+		{BeginObject, nil},
+		{Key, "a"},
+		{BeginArray, nil},
+		{Scalar, int64(1)},
+		{Scalar, "x\""},
+		{Scalar, true},
+		{EndArray, nil},
+		{Key, "b"},
+		{BeginObject, nil},
+		{EndObject, nil},
+		{EndObject, nil},
+		{Scalar, nil},
+	}
+	for _, tok := range expect {
+		got, err := d.Token()
+		if assert.NoError(t, err) {
+			assert.Equal(t, tok, got)
+		}
+	}
+	_, err := d.Token()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestDecoderDecode(t *testing.T) {
+	inputs := []string{
+		`[]`, `{}`, `123`, `-1.5e3`, `"啊ሴ"`, `[1, [2, {"a": null}]]`,
+		`{"b": 23, "c": "d", "e": [false]}`,
+	}
+	for _, input := range inputs {
+		// one byte at a time to split every multi-byte character
+		d := NewDecoder(iotest.OneByteReader(strings.NewReader(input + " ")))
+		got, err := d.Decode()
+		if assert.NoError(t, err, input) {
+			assert.Equal(t, MustParse(t, input), got, input)
+		}
+		_, err = d.Decode()
+		assert.Equal(t, io.EOF, err, input)
+	}
+}
+
+func TestParseOne(t *testing.T) {
+	one := func(input string, expect JsonValue, rest string) {
+		value, r, err := ParseOne(iotest.OneByteReader(strings.NewReader(input)))
+		if assert.NoError(t, err, input) {
+			assert.Equal(t, expect, value, input)
+			remain, _ := io.ReadAll(r)
+			assert.Equal(t, rest, string(remain), input)
+		}
+	}
+	one(`{"a": [1]}tail`, JsonMap{"a": JsonArray{int64(1)}}, "tail")
+	one(" \n\"啊\"\r\n{}", "啊", "\r\n{}")
+	one(`12 34`, int64(12), " 34")
+	one(`-1.5]`, -1.5, "]")
+	one(`null;`, nil, ";")
+	one(`[] []`, JsonArray{}, " []")
+
+	// the rest includes what was buffered
+	br := bufio.NewReader(strings.NewReader(`{"a": 1}` + "\x00\x01binary"))
+	value, rest, err := ParseOne(br)
+	if assert.NoError(t, err) {
+		assert.Equal(t, JsonMap{"a": int64(1)}, value)
+		assert.Same(t, br, rest)
+		remain, _ := io.ReadAll(rest)
+		assert.Equal(t, "\x00\x01binary", string(remain))
+	}
+
+	_, _, err = ParseOne(strings.NewReader("  "))
+	assert.Equal(t, io.EOF, err)
+	_, _, err = ParseOne(strings.NewReader(`[1, 2`))
+	assert.Error(t, err)
+	_, _, err = ParseOne(strings.NewReader(`truex`))
+	assert.Error(t, err)
+}
+
+func TestDecoderBad(t *testing.T) {
+	bad := func(input string) {
+		d := NewDecoder(strings.NewReader(input))
+		_, err := d.Decode()
+		if assert.Error(t, err, input) {
+			assert.NotEqual(t, io.EOF, err, input)
+		}
+		t.Log(input, "\t", err)
+	}
+
+	bad("[")
+	bad("[,]")
+	bad("[1 2]")
+	bad("[1,]")
+	bad(`{"a" 1}`)
+	bad(`{"a": 1,}`)
+	bad(`{1: 1}`)
+	bad(`"abc`)
+	bad(`"\x"`)
+	bad(`01`)
+	bad(`nul`)
+	bad(`truex`)
+	bad(`@`)
+	bad("\"\xff\"")
+
+	d := NewDecoder(strings.NewReader(`[1, "\q"]`))
+	d.Token()
+	d.Token()
+	_, err := d.Token()
+	assert.Equal(t, &ParseError{6, "bad escape char: 'q' (0x71)"}, err)
+}
+
+func TestDecoderMoreSkip(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`[{"a": [1, {"b": 2}]}, "x", 3]`))
+	tok, _ := d.Token()
+	assert.Equal(t, BeginArray, tok.Kind)
+	assert.True(t, d.More())
+	assert.NoError(t, d.SkipValue())
+	assert.True(t, d.More())
+	assert.NoError(t, d.SkipValue())
+	assert.True(t, d.More())
+	got, err := d.Decode()
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(3), got)
+	}
+	assert.False(t, d.More())
+	assert.Error(t, d.SkipValue())
+}
+
+func TestExtract(t *testing.T) {
+	input := `{"meta": {"results": "no"}, "results": [{"id": 1, "tags": ["a"]}, {"id": 2}, {"name": "x"}]}`
+	good := func(path string, expect ...JsonValue) {
+		got := []JsonValue{}
+		err := Extract(strings.NewReader(input), path, func(v JsonValue) error {
+			got = append(got, v)
+			return nil
+		})
+		if assert.NoError(t, err, path) {
+			if expect == nil {
+				expect = []JsonValue{}
+			}
+			assert.Equal(t, expect, got, path)
+		}
+	}
+
+	good("$.results.*.id", int64(1), int64(2))
+	good("$.results[1]", JsonMap{"id": int64(2)})
+	good("$['results'][0].tags[0]", "a")
+	good("$.*.results", "no")
+	good("$.missing.*")
+	good("$.results.*.id.x")
+
+	stop := io.ErrClosedPipe
+	count := 0
+	err := Extract(strings.NewReader(input), "$.results.*", func(v JsonValue) error {
+		count++
+		return stop
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, count)
+
+	for _, path := range []string{"$..id", "$.results[-1]", "$.results[0:1]", "results"} {
+		assert.Error(t, Extract(strings.NewReader(input), path, func(JsonValue) error { return nil }), path)
+	}
+	noop := func(JsonValue) error { return nil }
+	assert.Error(t, Extract(strings.NewReader(`{"results": [1, 2}`), "$.results.*", noop))
+	assert.Error(t, Extract(strings.NewReader(`{"results": [1]} {}`), "$.results.*", noop))
+}
+
+func TestCountMatching(t *testing.T) {
+	input := `{"records": [{"status": "ok"}, {"status": "error"}, {"status": "error", "n": [1]}, 4], "status": "error"}`
+	isError := func(v JsonValue) bool {
+		status, _ := Get(v, "status")
+		return status == "error"
+	}
+	count, err := CountMatching(strings.NewReader(input), "$.records.*", isError)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	count, err = CountMatching(strings.NewReader(input), "$.records.*", func(JsonValue) bool { return true })
+	assert.NoError(t, err)
+	assert.Equal(t, 4, count)
+	count, err = CountMatching(strings.NewReader(input), "$.missing.*", isError)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	count, err = CountMatching(strings.NewReader(`{"records": [{"status": "error"}, {"status": error}]}`), "$.records.*", isError)
+	assert.Equal(t, &ParseError{45, "expect true|false|null"}, err)
+	assert.Equal(t, 1, count)
+	_, err = CountMatching(strings.NewReader(input), "$..status", isError)
+	assert.Error(t, err)
+}
+
+func TestGetTopLevel(t *testing.T) {
+	input := ` {"meta": {"id": "no"}, "id": [1, {"a": 2}], "rest": [1, 2], "id": 3}`
+	value, found, err := GetTopLevel(input, "id")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, MustParse(t, `[1, {"a": 2}]`), value)
+
+	value, found, err = GetTopLevel(input, "missing")
+	assert.NoError(t, err)
+	assert.False(t, found)
+	assert.Nil(t, value)
+	_, found, err = GetTopLevel(`{}`, "id")
+	assert.NoError(t, err)
+	assert.False(t, found)
+
+	// checked up to the value, not after it
+	value, found, err = GetTopLevel(`{"a": [1, 2], "id": "é", "b": ]`, "id")
+	assert.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "é", value)
+	_, _, err = GetTopLevel(`{"a": [1, 2,], "id": 1}`, "id")
+	assert.Equal(t, &ParseError{12, "bad char: ']' (0x5d)"}, err)
+	_, _, err = GetTopLevel(`{"a": 1, "id": [1`, "id")
+	assert.Equal(t, &ParseError{17, "expect ']' or ','"}, err)
+	_, _, err = GetTopLevel(`{"a": 1`, "id")
+	assert.Equal(t, &ParseError{7, "expect '}' or ','"}, err)
+	_, _, err = GetTopLevel(`  [{"id": 1}]`, "id")
+	assert.Equal(t, &ParseError{2, "expect object"}, err)
+	_, _, err = GetTopLevel(` `, "id")
+	assert.Equal(t, ErrEmptyInput, err)
+}
+
+func BenchmarkGetTopLevel(b *testing.B) {
+	var buf strings.Builder
+	buf.WriteString(`{`)
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&buf, `"skip%d": [%s],`, i, strings.Repeat(`{"k": "some text", "n": 1.5},`, 100)+`null`)
+	}
+	buf.WriteString(`"id": 42}`)
+	input := buf.String()
+
+	b.Run("Parse", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			value, _ := Parse(input)
+			_ = value.(JsonMap)["id"]
+		}
+	})
+	b.Run("GetTopLevel", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _, _ = GetTopLevel(input, "id")
+		}
+	})
+}
+
+func TestParseReaderLimit(t *testing.T) {
+	input := `{"a": [1, 2, 3], "b": "text"}`
+	limit := int64(len(input))
+	value, err := ParseReaderLimit(strings.NewReader(input), limit)
+	assert.NoError(t, err)
+	assert.Equal(t, MustParse(t, input), value)
+	value, err = ParseReaderLimit(iotest.OneByteReader(strings.NewReader(input)), limit)
+	assert.NoError(t, err)
+	assert.Equal(t, MustParse(t, input), value)
+
+	// one byte over, even if it is whitespace
+	for _, over := range []string{input + " ", input[:len(input)-1] + "é}", "[" + input + "]"} {
+		value, err = ParseReaderLimit(strings.NewReader(over), limit)
+		assert.Equal(t, ErrTooLarge, err, over)
+		assert.Nil(t, value)
+		value, err = ParseReaderLimit(iotest.OneByteReader(strings.NewReader(over)), limit)
+		assert.Equal(t, ErrTooLarge, err, over)
+	}
+
+	// stops reading right after the limit
+	r := strings.NewReader(`[` + strings.Repeat(`1,`, 100000) + `1]`)
+	_, err = ParseReaderLimit(r, 100)
+	assert.True(t, errors.Is(err, ErrTooLarge))
+	assert.Equal(t, int64(101), r.Size()-int64(r.Len()))
+
+	// other errors first
+	_, err = ParseReaderLimit(strings.NewReader(`[1,]`), 100)
+	assert.Equal(t, &ParseError{3, "bad char: ']' (0x5d)"}, err)
+	_, err = ParseReaderLimit(strings.NewReader(``), 100)
+	assert.Equal(t, ErrEmptyInput, err)
+	_, err = ParseReaderLimit(strings.NewReader(`1`), 0)
+	assert.Equal(t, ErrTooLarge, err)
+
+	// no limit
+	value, err = ParseReaderLimit(strings.NewReader(input), math.MaxInt64)
+	assert.NoError(t, err)
+	assert.Equal(t, MustParse(t, input), value)
+}
+
+func TestTeeParse(t *testing.T) {
+	input := " {\"a\" : [1.50e3, -0.0, \"\\u00e9\\n\"],\n\t\"b\":{} }\n\n"
+	var out strings.Builder
+	value, err := TeeParse(strings.NewReader(input), &out)
+	assert.NoError(t, err)
+	assert.Equal(t, MustParse(t, input), value)
+	assert.Equal(t, input, out.String())
+
+	for _, input := range []string{`[1, 2, x] and more`, `{"a": 1} {}`, `[1, "\q"]`, `[1, 2`} {
+		var out strings.Builder
+		value, err := TeeParse(iotest.OneByteReader(strings.NewReader(input)), &out)
+		assert.Nil(t, value, input)
+		perr, ok := err.(*ParseError)
+		if assert.True(t, ok, input) {
+			assert.True(t, strings.HasPrefix(input, out.String()), input)
+			assert.GreaterOrEqual(t, len(out.String()), perr.Pos(), input)
+		}
+	}
+
+	_, err = TeeParse(strings.NewReader(" \n"), io.Discard)
+	assert.Equal(t, ErrEmptyInput, err)
+
+	broken := errors.New("broken")
+	_, err = TeeParse(strings.NewReader(`[1]`), failWriter{broken})
+	assert.Equal(t, broken, err)
+}
@@ -0,0 +1,182 @@
+package json_go
+
+// Diff returns a JSON Patch (RFC 6902) that turns a into b with ApplyPatch.
+// Objects are compared member by member and arrays index by index, and a value that changes type
+// is replaced whole. Values are compared exactly, so 1 and 1.0 differ. Paths in the patch are valid
+// at the point each operation applies. See DiffOptions for arrays with insertions or deletions.
+func Diff(a, b JsonValue) JsonArray {
+	return DiffOptions{}.Diff(a, b)
+}
+
+type DiffOptions struct {
+	// match array elements with a longest common subsequence, so an inserted or deleted element
+	// is one add or remove instead of a change of every element after it.
+	// Takes O(n*m) time and memory for the part of two arrays that differs; when that part has
+	// more than 1<<22 pairs of elements (about 2048 by 2048), it is diffed index by index instead.
+	LCSArrays bool
+}
+
+// a table of 32 MB on 64-bit platforms
+const lcsMaxCells = 1 << 22
+
+func (opts DiffOptions) Diff(a, b JsonValue) JsonArray {
+	d := differ{opts: opts, patch: JsonArray{}}
+	d.diff(a, b, "")
+	return d.patch
+}
+
+type differ struct {
+	opts  DiffOptions
+	patch JsonArray
+}
+
+func (d *differ) op(op string, path string, value JsonValue) {
+	item := JsonMap{"op": op, "path": path}
+	if op != "remove" {
+		item["value"] = cloneValue(value)
+	}
+	d.patch = append(d.patch, item)
+}
+
+func (d *differ) diff(a, b JsonValue, path string) {
+	switch av := a.(type) {
+	case JsonMap, SortedMap:
+		am, _ := asMap(a)
+		if bm, ok := asMap(b); ok {
+			d.diffMap(am, bm, path)
+			return
+		}
+	case JsonArray:
+		if bv, ok := b.(JsonArray); ok {
+			if d.opts.LCSArrays {
+				d.diffArrayLCS(av, bv, path)
+			} else {
+				d.diffArray(av, bv, path)
+			}
+			return
+		}
+	}
+	if !identical(a, b) {
+		d.op("replace", path, b)
+	}
+}
+
+func (d *differ) diffMap(a, b JsonMap, path string) {
+	for _, key := range sortedKeys(a) {
+		if bv, ok := b[key]; ok {
+			d.diff(a[key], bv, pointerJoin(path, key))
+		} else {
+			d.op("remove", pointerJoin(path, key), nil)
+		}
+	}
+	for _, key := range sortedKeys(b) {
+		if _, ok := a[key]; !ok {
+			d.op("add", pointerJoin(path, key), b[key])
+		}
+	}
+}
+
+func (d *differ) diffArray(a, b JsonArray, path string) {
+	d.diffArrayAt(a, b, path, 0)
+}
+
+// diff a and b index by index, where they start at index start of the array
+func (d *differ) diffArrayAt(a, b JsonArray, path string, start int) {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		d.diff(a[i], b[i], pointerIndex(path, start+i))
+	}
+	for i := len(a); i < len(b); i++ {
+		d.op("add", pointerIndex(path, start+i), b[i])
+	}
+	for i := len(a) - 1; i >= len(b); i-- {
+		d.op("remove", pointerIndex(path, start+i), nil)
+	}
+}
+
+func (d *differ) diffArrayLCS(a, b JsonArray, path string) {
+	// the common ends don't need the table
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && identical(a[prefix], b[prefix]) {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && identical(a[len(a)-1-suffix], b[len(b)-1-suffix]) {
+		suffix++
+	}
+	x, y := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	if len(x) > 0 && len(y) > lcsMaxCells/len(x) {
+		d.diffArrayAt(x, y, path, prefix)
+		return
+	}
+
+	// lcs[i][j] is the length of the LCS of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			switch {
+			case identical(x[i], y[j]):
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] > lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	// k is the index in the array as patched so far
+	i, j, k := 0, 0, prefix
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && identical(x[i], y[j]):
+			i, j, k = i+1, j+1, k+1
+		case i < len(x) && j < len(y) && lcs[i+1][j+1] == lcs[i][j]:
+			// neither is in the LCS, change one into the other in place
+			d.diff(x[i], y[j], pointerIndex(path, k))
+			i, j, k = i+1, j+1, k+1
+		case j < len(y) && (i == len(x) || lcs[i][j+1] >= lcs[i+1][j]):
+			d.op("add", pointerIndex(path, k), y[j])
+			j, k = j+1, k+1
+		default:
+			d.op("remove", pointerIndex(path, k), nil)
+			i++
+		}
+	}
+}
+
+// Equal without converting numbers, so that a patch reproduces the exact types
+func identical(a, b JsonValue) bool {
+	switch av := a.(type) {
+	case JsonArray:
+		bv, ok := b.(JsonArray)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !identical(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case JsonMap, SortedMap:
+		am, _ := asMap(a)
+		bm, ok := asMap(b)
+		if !ok || len(am) != len(bm) {
+			return false
+		}
+		for k, v := range am {
+			other, ok := bm[k]
+			if !ok || !identical(v, other) {
+				return false
+			}
+		}
+		return true
+	case nil, bool, string, int64, float64, Decimal:
+		return a == b
+	default:
+		return false
+	}
+}
@@ -0,0 +1,97 @@
+package json_go
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	a := MustParse(t, `{"a": 1, "b": [1, 2, 3], "c": {"d": "x"}, "e": null}`)
+	b := MustParse(t, `{"a": 1.0, "b": [1, 5], "c": {"d": "x", "f": true}, "g~/": []}`)
+	patch := Diff(a, b)
+	assert.Equal(t, MustParse(t, `[
+		{"op": "replace", "path": "/a", "value": 1.0},
+		{"op": "replace", "path": "/b/1", "value": 5},
+		{"op": "remove", "path": "/b/2"},
+		{"op": "add", "path": "/c/f", "value": true},
+		{"op": "remove", "path": "/e"},
+		{"op": "add", "path": "/g~0~1", "value": []}
+	]`), patch)
+	result, err := ApplyPatch(a, patch)
+	assert.NoError(t, err)
+	assert.Equal(t, b, result)
+
+	assert.Equal(t, JsonArray{}, Diff(a, MustParse(t, `{"a": 1, "b": [1, 2, 3], "c": {"d": "x"}, "e": null}`)))
+	assert.Equal(t, MustParse(t, `[{"op": "replace", "path": "", "value": [1]}]`), Diff(JsonMap{}, JsonArray{int64(1)}))
+}
+
+func TestDiffLCSArrays(t *testing.T) {
+	lcs := DiffOptions{LCSArrays: true}
+	a := MustParse(t, `[{"id": 1}, {"id": 2}, {"id": 3}, {"id": 4}]`)
+	b := MustParse(t, `[{"id": 0}, {"id": 1}, {"id": 2}, {"id": 4}]`)
+	assert.Equal(t, 3, len(Diff(a, b)))
+	patch := lcs.Diff(a, b)
+	assert.Equal(t, MustParse(t, `[
+		{"op": "add", "path": "/0", "value": {"id": 0}},
+		{"op": "remove", "path": "/3"}
+	]`), patch)
+	result, err := ApplyPatch(a, patch)
+	assert.NoError(t, err)
+	assert.Equal(t, b, result)
+
+	// a changed element is diffed in place
+	patch = lcs.Diff(MustParse(t, `[1, {"a": 1, "b": 2}, 3]`), MustParse(t, `[1, {"a": 1, "b": 3}, 3]`))
+	assert.Equal(t, MustParse(t, `[{"op": "replace", "path": "/1/b", "value": 3}]`), patch)
+
+	// too large for the table, the differing middle is diffed index by index
+	big := func(n, from int) JsonArray {
+		arr := JsonArray{"head"}
+		for i := 0; i < n; i++ {
+			arr = append(arr, int64(from+i))
+		}
+		return append(arr, "tail")
+	}
+	a, b = big(3000, 0), big(2000, 10000)
+	patch = lcs.Diff(a, b)
+	assert.Equal(t, 3000, len(patch))
+	assert.Equal(t, JsonMap{"op": "replace", "path": "/1", "value": int64(10000)}, patch[0])
+	assert.Equal(t, JsonMap{"op": "remove", "path": "/3000"}, patch[2000])
+	result, err = ApplyPatch(a, patch)
+	assert.NoError(t, err)
+	assert.Equal(t, b, result)
+
+	// random edits of small arrays round trip
+	rng := rand.New(rand.NewSource(1))
+	randArray := func() JsonArray {
+		arr := JsonArray{}
+		for i := rng.Intn(8); i > 0; i-- {
+			if rng.Intn(4) == 0 {
+				arr = append(arr, JsonArray{int64(rng.Intn(3))})
+			} else {
+				arr = append(arr, int64(rng.Intn(4)))
+			}
+		}
+		return arr
+	}
+	for n := 0; n < 500; n++ {
+		a, b := randArray(), randArray()
+		for _, opts := range []DiffOptions{{}, lcs} {
+			patch := opts.Diff(a, b)
+			result, err := ApplyPatch(a, patch)
+			msg := fmt.Sprint(a, b, patch)
+			if assert.NoError(t, err, msg) {
+				assert.Equal(t, b, result, msg)
+			}
+		}
+	}
+
+	a = JsonArray{int64(1), int64(2), int64(3)}
+	b = JsonArray{int64(0), int64(1), int64(2), int64(3)}
+	assert.Equal(t, 4, len(Diff(a, b)))
+	assert.Equal(t, MustParse(t, `[{"op": "add", "path": "/0", "value": 0}]`), lcs.Diff(a, b))
+	// elements are matched exactly, so 1.0 is not kept as 1
+	assert.Equal(t, MustParse(t, `[{"op": "replace", "path": "/0", "value": 1}]`), lcs.Diff(JsonArray{1.0}, JsonArray{int64(1)}))
+}
@@ -0,0 +1,41 @@
+package json_go
+
+// IsEmpty reports whether v is empty in the sense of omitempty: null, false, "", the number 0
+// of any type (0, 0.0, -0.0, or a Decimal like 0e5), or an array or object without elements.
+// Nested values are not looked at, so [null] and {"a": ""} are not empty.
+// See EmptyOptions to keep zero numbers.
+func IsEmpty(v JsonValue) bool {
+	return EmptyOptions{}.IsEmpty(v)
+}
+
+type EmptyOptions struct {
+	// 0 is a value like any other number, not empty
+	KeepZeroNumbers bool
+}
+
+func (opts EmptyOptions) IsEmpty(v JsonValue) bool {
+	switch n := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !n
+	case string:
+		return n == ""
+	case int64, float64, Decimal:
+		if opts.KeepZeroNumbers {
+			return false
+		}
+		_, digits, _, ok := exactDigits(v, nil)
+		return ok && len(digits) == 0
+	case JsonArray:
+		return len(n) == 0
+	case JsonMap:
+		return len(n) == 0
+	case SortedMap:
+		return len(n) == 0
+	case []JsonKeyValue:
+		return len(n) == 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,24 @@
+package json_go
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsEmpty(t *testing.T) {
+	for _, v := range []JsonValue{nil, false, "", int64(0), 0.0, math.Copysign(0, -1), Decimal{"-0.00e7"}, JsonArray{}, JsonMap{}, SortedMap{}, []JsonKeyValue{}} {
+		assert.True(t, IsEmpty(v), "%#v", v)
+	}
+	for _, v := range []JsonValue{true, " ", int64(1), 1e-300, math.NaN(), Decimal{"0.01"}, JsonArray{nil}, JsonMap{"a": ""}, SortedMap{{"a", nil}}, 0} {
+		assert.False(t, IsEmpty(v), "%#v", v)
+	}
+
+	keep := EmptyOptions{KeepZeroNumbers: true}
+	assert.False(t, keep.IsEmpty(int64(0)))
+	assert.False(t, keep.IsEmpty(0.0))
+	assert.False(t, keep.IsEmpty(Decimal{"0"}))
+	assert.True(t, keep.IsEmpty(""))
+	assert.True(t, keep.IsEmpty(nil))
+}
@@ -0,0 +1,112 @@
+package json_go
+
+import "io"
+
+// Encoder writes values to an io.Writer, reusing its buffer between values.
+type Encoder struct {
+	w   io.Writer
+	buf []byte
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the compact form of value followed by a newline.
+func (e *Encoder) Encode(value JsonValue) error {
+	return e.encode("", value, "\n")
+}
+
+func (e *Encoder) encode(prefix string, value JsonValue, suffix string) (err error) {
+	e.buf = append(e.buf[:0], prefix...)
+	e.buf, err = AppendMarshal(e.buf, value)
+	if err != nil {
+		return
+	}
+	e.buf = append(e.buf, suffix...)
+	_, err = e.w.Write(e.buf)
+	return
+}
+
+// NDJSONEncoder writes newline-delimited JSON: each value compact on its own line, ending with "\n"
+// and nothing else, since the compact form escapes every newline in strings.
+// A value that fails to marshal writes nothing.
+type NDJSONEncoder struct {
+	e Encoder
+}
+
+// NewNDJSONEncoder flushes w after each record if it has a Flush method, like a *bufio.Writer
+// or an http.ResponseWriter, so a reader gets the records as they are produced.
+func NewNDJSONEncoder(w io.Writer) *NDJSONEncoder {
+	return &NDJSONEncoder{Encoder{w: w}}
+}
+
+func (e *NDJSONEncoder) Encode(value JsonValue) (err error) {
+	err = e.e.Encode(value)
+	if err != nil {
+		return
+	}
+	switch f := e.e.w.(type) {
+	case interface{ Flush() error }:
+		err = f.Flush()
+	case interface{ Flush() }:
+		f.Flush()
+	}
+	return
+}
+
+// StreamTransformArray copies a top-level array from r to w with every element replaced by fn(element).
+// Elements are read with a Decoder and written with an Encoder one at a time,
+// so only the current element is held in memory. An error from fn stops the copy and is returned.
+func StreamTransformArray(r io.Reader, w io.Writer, fn func(JsonValue) (JsonValue, error)) (err error) {
+	d := NewDecoder(r)
+	var tok Token
+	tok, err = d.token()
+	if err != nil {
+		return
+	}
+	if tok.Kind != BeginArray {
+		return &ParseError{d.pos, "expect array"}
+	}
+
+	e := NewEncoder(w)
+	sep := "["
+	for d.More() {
+		var value JsonValue
+		value, err = d.Decode()
+		if err != nil {
+			return
+		}
+		value, err = fn(value)
+		if err != nil {
+			return
+		}
+		err = e.encode(sep, value, "")
+		if err != nil {
+			return
+		}
+		sep = ","
+	}
+	tok, err = d.token()
+	if err != nil {
+		return
+	}
+	if tok.Kind != EndArray {
+		return &ParseError{d.pos, "expect ']'"}
+	}
+	var ch rune
+	ch, err = d.skipSpace()
+	if err != nil {
+		return
+	}
+	if ch >= 0 {
+		return trailingError(d.pos, ch)
+	}
+
+	if sep == "[" {
+		_, err = io.WriteString(w, "[]")
+	} else {
+		_, err = io.WriteString(w, "]")
+	}
+	return
+}
@@ -0,0 +1,104 @@
+package json_go
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoder(t *testing.T) {
+	buf := bytes.Buffer{}
+	e := NewEncoder(&buf)
+	assert.NoError(t, e.Encode(JsonMap{"b": int64(1), "a": JsonArray{}}))
+	assert.NoError(t, e.Encode("x"))
+	assert.Error(t, e.Encode(JsonArray{func() {}}))
+	assert.Equal(t, "{\"a\":[],\"b\":1}\n\"x\"\n", buf.String())
+}
+
+func TestStreamTransformArray(t *testing.T) {
+	addField := func(v JsonValue) (JsonValue, error) {
+		if obj, ok := v.(JsonMap); ok {
+			obj["seen"] = true
+		}
+		return v, nil
+	}
+	transform := func(input string, fn func(JsonValue) (JsonValue, error)) (string, error) {
+		buf := bytes.Buffer{}
+		err := StreamTransformArray(strings.NewReader(input), &buf, fn)
+		return buf.String(), err
+	}
+
+	output, err := transform(` [ {"id": 1}, {"id": 2, "x": [1, {}]}, 3 ] `, addField)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `[{"id":1,"seen":true},{"id":2,"seen":true,"x":[1,{}]},3]`, output)
+	}
+	output, err = transform(`[]`, addField)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `[]`, output)
+	}
+
+	stop := errors.New("stop")
+	output, err = transform(`[1, 2, 3]`, func(v JsonValue) (JsonValue, error) {
+		if v == int64(2) {
+			return nil, stop
+		}
+		return v, nil
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, `[1`, output)
+
+	bad := func(input string) {
+		_, err := transform(input, addField)
+		assert.Error(t, err, input)
+		t.Log(input, "\t", err)
+	}
+	bad(``)
+	bad(`{}`)
+	bad(`[1, 2`)
+	bad(`[1 2]`)
+	bad(`[1,]`)
+	bad(`[1] 2`)
+	_, err = transform(`[1] 2`, addField)
+	assert.Equal(t, &ParseError{4, "extra value after the document, starting with '2'"}, err)
+}
+
+type countingFlusher struct {
+	bytes.Buffer
+	flushes int
+}
+
+func (w *countingFlusher) Flush() {
+	w.flushes++
+}
+
+func TestNDJSONEncoder(t *testing.T) {
+	var out bytes.Buffer
+	bw := bufio.NewWriter(&out)
+	e := NewNDJSONEncoder(bw)
+	assert.NoError(t, e.Encode(MustParse(t, `{"a": "x\ny", "b": [1, 2]}`)))
+	// flushed through the bufio.Writer
+	assert.Equal(t, "{\"a\":\"x\\ny\",\"b\":[1,2]}\n", out.String())
+	assert.NoError(t, e.Encode(" "))
+	assert.Error(t, e.Encode(math.Inf(1)))
+	assert.NoError(t, e.Encode(nil))
+	assert.Equal(t, "{\"a\":\"x\\ny\",\"b\":[1,2]}\n\" \"\nnull\n", out.String())
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	assert.Equal(t, 3, len(lines))
+	for _, line := range lines {
+		_, err := Parse(line)
+		assert.NoError(t, err, line)
+	}
+
+	w := &countingFlusher{}
+	e = NewNDJSONEncoder(w)
+	assert.NoError(t, e.Encode(int64(1)))
+	assert.NoError(t, e.Encode(int64(2)))
+	assert.Equal(t, 2, w.flushes)
+	assert.Equal(t, "1\n2\n", w.String())
+}
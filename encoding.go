@@ -0,0 +1,102 @@
+package json_go
+
+import (
+	"encoding/binary"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// DetectEncoding guesses the Unicode encoding of a JSON document: "utf-8", "utf-16le", "utf-16be",
+// "utf-32le" or "utf-32be". A BOM decides it, and bomLen is its length in bytes. Without a BOM,
+// the pattern of NUL bytes in the first 4 bytes decides it as in RFC 4627, since a document starts
+// with an ASCII character. Anything else is "utf-8" with bomLen 0, which DecodeAuto then validates.
+func DetectEncoding(input []byte) (encoding string, bomLen int) {
+	b := input
+	switch {
+	case len(b) >= 3 && b[0] == 0xef && b[1] == 0xbb && b[2] == 0xbf:
+		return "utf-8", 3
+	case len(b) >= 4 && b[0] == 0xff && b[1] == 0xfe && b[2] == 0 && b[3] == 0:
+		return "utf-32le", 4
+	case len(b) >= 4 && b[0] == 0 && b[1] == 0 && b[2] == 0xfe && b[3] == 0xff:
+		return "utf-32be", 4
+	case len(b) >= 2 && b[0] == 0xff && b[1] == 0xfe:
+		return "utf-16le", 2
+	case len(b) >= 2 && b[0] == 0xfe && b[1] == 0xff:
+		return "utf-16be", 2
+	}
+
+	// the first 2 bytes are enough for UTF-16 when the document is a single digit
+	switch {
+	case len(b) >= 4 && b[0] == 0 && b[1] == 0 && b[2] == 0 && b[3] != 0:
+		return "utf-32be", 0
+	case len(b) >= 4 && b[0] != 0 && b[1] == 0 && b[2] == 0 && b[3] == 0:
+		return "utf-32le", 0
+	case len(b) >= 2 && b[0] == 0 && b[1] != 0:
+		return "utf-16be", 0
+	case len(b) >= 2 && b[0] != 0 && b[1] == 0:
+		return "utf-16le", 0
+	}
+	return "utf-8", 0
+}
+
+// DecodeAuto decodes input to runes in the encoding from DetectEncoding, without the BOM.
+// Errors are DecodingErrors at byte offsets of input, for invalid UTF-8, a length that is not
+// a whole number of code units, a lone UTF-16 surrogate or a UTF-32 code unit that is not a character.
+func DecodeAuto(input []byte) (output []rune, err error) {
+	encoding, bomLen := DetectEncoding(input)
+	switch encoding {
+	case "utf-16le", "utf-16be":
+		return decodeUTF16(input, bomLen, encoding == "utf-16be")
+	case "utf-32le", "utf-32be":
+		return decodeUTF32(input, bomLen, encoding == "utf-32be")
+	}
+	output, err = Decode(input[bomLen:])
+	return output, shiftDecodingError(err, bomLen)
+}
+
+func byteOrder(bigEndian bool) binary.ByteOrder {
+	if bigEndian {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+func decodeUTF16(input []byte, cur int, bigEndian bool) (output []rune, err error) {
+	order := byteOrder(bigEndian)
+	for cur < len(input) {
+		if cur+2 > len(input) {
+			return output, &DecodingError{cur, input[cur], "truncated UTF-16 code unit", cur, len(input), len(output), false}
+		}
+		unit := rune(order.Uint16(input[cur:]))
+		size := 2
+		if utf16.IsSurrogate(unit) {
+			var low rune = -1
+			if unit < 0xdc00 && cur+4 <= len(input) {
+				low = rune(order.Uint16(input[cur+2:]))
+			}
+			unit = utf16.DecodeRune(unit, low)
+			if unit == utf8.RuneError {
+				return output, &DecodingError{cur, input[cur], "lone UTF-16 surrogate", cur, cur + 2, len(output), false}
+			}
+			size = 4
+		}
+		output = append(output, unit)
+		cur += size
+	}
+	return
+}
+
+func decodeUTF32(input []byte, cur int, bigEndian bool) (output []rune, err error) {
+	order := byteOrder(bigEndian)
+	for ; cur < len(input); cur += 4 {
+		if cur+4 > len(input) {
+			return output, &DecodingError{cur, input[cur], "truncated UTF-32 code unit", cur, len(input), len(output), false}
+		}
+		code := order.Uint32(input[cur:])
+		if code > utf8.MaxRune || utf16.IsSurrogate(rune(code)) {
+			return output, &DecodingError{cur, input[cur], "bad UTF-32 code point", cur, cur + 4, len(output), false}
+		}
+		output = append(output, rune(code))
+	}
+	return
+}
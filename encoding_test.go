@@ -0,0 +1,79 @@
+package json_go
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func encodeUTF16(s string, order binary.AppendByteOrder) (b []byte) {
+	for _, unit := range utf16.Encode([]rune(s)) {
+		b = order.AppendUint16(b, unit)
+	}
+	return
+}
+
+func encodeUTF32(s string, order binary.AppendByteOrder) (b []byte) {
+	for _, ch := range s {
+		b = order.AppendUint32(b, uint32(ch))
+	}
+	return
+}
+
+func TestDetectEncoding(t *testing.T) {
+	le, be := binary.LittleEndian, binary.BigEndian
+	for _, c := range []struct {
+		input    []byte
+		encoding string
+		bomLen   int
+	}{
+		{[]byte(`{"a": 1}`), "utf-8", 0},
+		{[]byte("\ufeff[]"), "utf-8", 3},
+		{[]byte("é"), "utf-8", 0},
+		{[]byte("1"), "utf-8", 0},
+		{[]byte{}, "utf-8", 0},
+		{encodeUTF16("\ufeff[]", le), "utf-16le", 2},
+		{encodeUTF16("\ufeff[]", be), "utf-16be", 2},
+		{encodeUTF32("\ufeff[]", le), "utf-32le", 4},
+		{encodeUTF32("\ufeff[]", be), "utf-32be", 4},
+		{encodeUTF16(`{"a": 1}`, le), "utf-16le", 0},
+		{encodeUTF16(`{"a": 1}`, be), "utf-16be", 0},
+		{encodeUTF16("1", le), "utf-16le", 0},
+		{encodeUTF16("1", be), "utf-16be", 0},
+		{encodeUTF32(`[1]`, le), "utf-32le", 0},
+		{encodeUTF32(`[1]`, be), "utf-32be", 0},
+	} {
+		encoding, bomLen := DetectEncoding(c.input)
+		assert.Equal(t, c.encoding, encoding, "%x", c.input)
+		assert.Equal(t, c.bomLen, bomLen, "%x", c.input)
+	}
+}
+
+func TestDecodeAuto(t *testing.T) {
+	doc := `{"k": "é😀"}`
+	for _, input := range [][]byte{
+		[]byte(doc),
+		[]byte("\ufeff" + doc),
+		encodeUTF16(doc, binary.LittleEndian),
+		encodeUTF16("\ufeff"+doc, binary.BigEndian),
+		encodeUTF32(doc, binary.BigEndian),
+		encodeUTF32("\ufeff"+doc, binary.LittleEndian),
+	} {
+		output, err := DecodeAuto(input)
+		assert.NoError(t, err, "%x", input)
+		assert.Equal(t, doc, string(output), "%x", input)
+	}
+
+	_, err := DecodeAuto([]byte("\ufeff[\xff]"))
+	assert.Equal(t, &DecodingError{4, 0xff, "bad leading char", 4, 5, 1, false}, err)
+	_, err = DecodeAuto(append(encodeUTF16("[1]", binary.LittleEndian), '\n'))
+	assert.Equal(t, &DecodingError{6, '\n', "truncated UTF-16 code unit", 6, 7, 3, false}, err)
+	_, err = DecodeAuto(append(encodeUTF16("[1", binary.BigEndian), 0xd8, 0, 0, ']'))
+	assert.Equal(t, &DecodingError{4, 0xd8, "lone UTF-16 surrogate", 4, 6, 2, false}, err)
+	_, err = DecodeAuto(append(encodeUTF16("[1", binary.LittleEndian), 0, 0xdc))
+	assert.Equal(t, &DecodingError{4, 0, "lone UTF-16 surrogate", 4, 6, 2, false}, err)
+	_, err = DecodeAuto(append(encodeUTF32("[", binary.LittleEndian), 0, 0, 0x11, 0))
+	assert.Equal(t, &DecodingError{4, 0, "bad UTF-32 code point", 4, 8, 1, false}, err)
+}
@@ -0,0 +1,278 @@
+package json_go
+
+import (
+	"math"
+	"strings"
+)
+
+// numbers are compared by value, so int64(2) equals float64(2.0)
+func Equal(a, b JsonValue) bool {
+	return equaler{}.equal(a, b, "")
+}
+
+// the traversal of Equal and EqualUnordered, paths are only built when there are unordered paths
+type equaler struct {
+	unorderedPaths []string
+}
+
+func (e equaler) equal(a, b JsonValue, path string) bool {
+	switch av := a.(type) {
+	case nil:
+		return b == nil
+	case bool:
+		bv, ok := b.(bool)
+		return ok && av == bv
+	case string:
+		bv, ok := b.(string)
+		return ok && av == bv
+	case int64, float64, Decimal:
+		return numberEqual(a, b)
+	case JsonArray:
+		bv, ok := b.(JsonArray)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		if e.unordered(path) {
+			return multisetEqual(av, bv)
+		}
+		for i := range av {
+			if !e.equal(av[i], bv[i], e.index(path, i)) {
+				return false
+			}
+		}
+		return true
+	case SortedMap:
+		bv, ok := b.(SortedMap)
+		if !ok {
+			break
+		}
+		if len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if av[i].key != bv[i].key || !e.equal(av[i].value, bv[i].value, e.join(path, av[i].key)) {
+				return false
+			}
+		}
+		return true
+	case JsonMap:
+	default:
+		return false
+	}
+
+	am, _ := asMap(a)
+	bm, ok := asMap(b)
+	if !ok || len(am) != len(bm) {
+		return false
+	}
+	for k, v := range am {
+		other, ok := bm[k]
+		if !ok || !e.equal(v, other, e.join(path, k)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e equaler) unordered(path string) bool {
+	for _, pattern := range e.unorderedPaths {
+		if pattern == path || (strings.Count(pattern, "/") == strings.Count(path, "/") && pointerMatch(pattern, path)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e equaler) index(path string, i int) string {
+	if len(e.unorderedPaths) == 0 {
+		return ""
+	}
+	return pointerIndex(path, i)
+}
+
+func (e equaler) join(path string, key string) string {
+	if len(e.unorderedPaths) == 0 {
+		return ""
+	}
+	return pointerJoin(path, key)
+}
+
+// FirstDiff is Equal that also tells where the trees differ: the JSON Pointer of the first difference
+// in Walk order, with the two values there. An element or member missing on one side is reported at its path
+// with nil on that side. Numbers are compared by value like in Equal.
+func FirstDiff(a, b JsonValue) (path string, aVal, bVal JsonValue, equal bool) {
+	path, aVal, bVal, equal = firstDiff(a, b, "")
+	return
+}
+
+func firstDiff(a, b JsonValue, path string) (string, JsonValue, JsonValue, bool) {
+	switch av := a.(type) {
+	case JsonArray:
+		bv, ok := b.(JsonArray)
+		if !ok {
+			break
+		}
+		for i := 0; i < len(av) || i < len(bv); i++ {
+			if i >= len(av) {
+				return pointerIndex(path, i), nil, bv[i], false
+			}
+			if i >= len(bv) {
+				return pointerIndex(path, i), av[i], nil, false
+			}
+			if diffPath, x, y, equal := firstDiff(av[i], bv[i], pointerIndex(path, i)); !equal {
+				return diffPath, x, y, false
+			}
+		}
+		return "", nil, nil, true
+	case JsonMap, SortedMap:
+		am, _ := asMap(a)
+		bm, ok := asMap(b)
+		if !ok {
+			break
+		}
+		union := JsonMap{}
+		for k := range am {
+			union[k] = nil
+		}
+		for k := range bm {
+			union[k] = nil
+		}
+		for _, k := range sortedKeys(union) {
+			x, inA := am[k]
+			y, inB := bm[k]
+			if !inA || !inB {
+				return pointerJoin(path, k), x, y, false
+			}
+			if diffPath, x, y, equal := firstDiff(x, y, pointerJoin(path, k)); !equal {
+				return diffPath, x, y, false
+			}
+		}
+		return "", nil, nil, true
+	}
+	if Equal(a, b) {
+		return "", nil, nil, true
+	}
+	return path, a, b, false
+}
+
+// EqualUnordered is Equal, except that the arrays at unorderedPaths are compared as multisets:
+// each element of one must match an element of the other with Equal, counting duplicates,
+// so [1, 2, 2] equals [2, 1, 2] but not [1, 1, 2]. Paths are JSON Pointers where a `*` token
+// matches any key or index, like "/items/*/tags". Below an unordered array, values are compared
+// with Equal, so their arrays are ordered whatever the paths. All other arrays are ordered.
+func EqualUnordered(a, b JsonValue, unorderedPaths []string) bool {
+	return equaler{unorderedPaths}.equal(a, b, "")
+}
+
+// a and b have the same length, Equal is transitive so a greedy matching is enough
+func multisetEqual(a, b JsonArray) bool {
+	used := make([]bool, len(b))
+	for _, x := range a {
+		match := -1
+		for j, y := range b {
+			if !used[j] && Equal(x, y) {
+				match = j
+				break
+			}
+		}
+		if match < 0 {
+			return false
+		}
+		used[match] = true
+	}
+	return true
+}
+
+func numberEqual(a, b JsonValue) bool {
+	_, aDec := a.(Decimal)
+	_, bDec := b.(Decimal)
+	if aDec || bDec {
+		return exactEqual(a, b)
+	}
+
+	switch av := a.(type) {
+	case int64:
+		switch bv := b.(type) {
+		case int64:
+			return av == bv
+		case float64:
+			return floatIntEqual(bv, av)
+		}
+	case float64:
+		switch bv := b.(type) {
+		case int64:
+			return floatIntEqual(av, bv)
+		case float64:
+			return av == bv
+		}
+	}
+	return false
+}
+
+func floatIntEqual(f float64, i int64) bool {
+	if f != math.Trunc(f) || f < -(1<<63) || f >= 1<<63 {
+		return false
+	}
+	return int64(f) == i
+}
+
+// numeric value as float64, for int64 or float64
+func toFloat(v JsonValue) (f float64, ok bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case Decimal:
+		var err error
+		f, err = n.Float64()
+		return f, err == nil
+	}
+	return
+}
+
+// EqualIgnoring is Equal, except that the values at ignorePaths are not compared,
+// including whether they are present at all. Paths are JSON Pointers where
+// a `*` token matches any key or index, like "/items/*/createdAt".
+func EqualIgnoring(a, b JsonValue, ignorePaths []string) bool {
+	fa := flattenIgnoring(a, ignorePaths)
+	fb := flattenIgnoring(b, ignorePaths)
+	if len(fa) != len(fb) {
+		return false
+	}
+	for path, va := range fa {
+		vb, ok := fb[path]
+		if !ok || !shallowEqual(va, vb) {
+			return false
+		}
+	}
+	return true
+}
+
+// every compared value by path, object keys are implied by the paths of the members
+func flattenIgnoring(root JsonValue, ignorePaths []string) map[string]JsonValue {
+	result := map[string]JsonValue{}
+	_ = Walk(root, func(path string, v JsonValue) error {
+		for _, pattern := range ignorePaths {
+			if pointerMatch(pattern, path) {
+				return nil
+			}
+		}
+		result[path] = v
+		return nil
+	})
+	return result
+}
+
+// containers are only compared by type and length, their members are compared by path
+func shallowEqual(a, b JsonValue) bool {
+	switch av := a.(type) {
+	case JsonArray:
+		bv, ok := b.(JsonArray)
+		return ok && len(av) == len(bv)
+	case JsonMap, SortedMap:
+		return isObject(b)
+	default:
+		return Equal(a, b)
+	}
+}
@@ -0,0 +1,106 @@
+package json_go
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEqual(t *testing.T) {
+	same := func(a, b JsonValue) {
+		assert.True(t, Equal(a, b), "%v %v", a, b)
+		assert.True(t, Equal(b, a), "%v %v", b, a)
+	}
+	diff := func(a, b JsonValue) {
+		assert.False(t, Equal(a, b), "%v %v", a, b)
+		assert.False(t, Equal(b, a), "%v %v", b, a)
+	}
+
+	same(nil, nil)
+	same(true, true)
+	same("a", "a")
+	same(int64(2), int64(2))
+	same(int64(2), 2.0)
+	same(0.5, 0.5)
+	same(JsonArray{}, JsonArray{})
+	same(JsonMap{"a": JsonArray{int64(1)}}, JsonMap{"a": JsonArray{1.0}})
+
+	diff(nil, false)
+	diff("1", int64(1))
+	diff(int64(2), 2.5)
+	diff(int64(math.MaxInt64), math.Inf(1))
+	diff(math.NaN(), math.NaN())
+	diff(JsonArray{}, JsonMap{})
+	diff(JsonArray{int64(1)}, JsonArray{int64(1), int64(2)})
+	diff(JsonMap{"a": nil}, JsonMap{"b": nil})
+	diff(JsonMap{"a": nil}, JsonMap{"a": nil, "b": nil})
+}
+
+func TestFirstDiff(t *testing.T) {
+	diff := func(a, b string, expectPath string, expectA, expectB JsonValue) {
+		path, x, y, equal := FirstDiff(MustParse(t, a), MustParse(t, b))
+		assert.False(t, equal, "%s %s", a, b)
+		assert.Equal(t, expectPath, path, "%s %s", a, b)
+		assert.Equal(t, expectA, x, "%s %s", a, b)
+		assert.Equal(t, expectB, y, "%s %s", a, b)
+	}
+
+	diff(`{"items": [{}, {"price": 9.99}]}`, `{"items": [{}, {"price": 10.0}]}`, "/items/1/price", 9.99, 10.0)
+	diff(`1`, `"1"`, "", int64(1), "1")
+	diff(`[1, 2]`, `[1]`, "/1", int64(2), nil)
+	diff(`[1]`, `[1, null]`, "/1", nil, nil)
+	diff(`{"a": 1, "c": 3}`, `{"b": 2, "c": 4}`, "/a", int64(1), nil)
+	diff(`{"a": {"x": 1}, "b": 1}`, `{"a": {"x": 1}, "b": 2}`, "/b", int64(1), int64(2))
+	diff(`{"a~b": []}`, `{"a~b": {}}`, "/a~0b", JsonArray{}, JsonMap{})
+
+	// the first in Walk order, with sorted keys
+	diff(`{"z": 1, "a": [0, 1]}`, `{"z": 2, "a": [0, 2]}`, "/a/1", int64(1), int64(2))
+
+	path, x, y, equal := FirstDiff(MustParse(t, `{"a": [1, {"b": 2.0}]}`), MustParse(t, `{"a": [1.0, {"b": 2}]}`))
+	assert.True(t, equal)
+	assert.Equal(t, "", path)
+	assert.Nil(t, x)
+	assert.Nil(t, y)
+}
+
+func TestEqualIgnoring(t *testing.T) {
+	a := MustParse(t, `{"id": "x1", "items": [{"n": 1, "createdAt": 100}, {"n": 2, "createdAt": 101}]}`)
+	b := MustParse(t, `{"id": "y2", "items": [{"n": 1, "createdAt": 200}, {"n": 2.0}]}`)
+	ignore := []string{"/id", "/items/*/createdAt"}
+
+	assert.True(t, EqualIgnoring(a, b, ignore))
+	assert.True(t, EqualIgnoring(a, a, nil))
+	assert.False(t, EqualIgnoring(a, b, nil))
+	assert.False(t, EqualIgnoring(a, b, []string{"/id"}))
+	assert.True(t, EqualIgnoring(a, b, []string{"/id", "/items"}))
+	assert.True(t, EqualIgnoring(int64(1), "x", []string{""}))
+
+	c := MustParse(t, `{"id": "z", "items": [{"n": 1, "createdAt": 1}]}`)
+	assert.False(t, EqualIgnoring(a, c, ignore))
+	d := MustParse(t, `{"id": "z", "items": [{"n": 1}, {"n": 3}]}`)
+	assert.False(t, EqualIgnoring(a, d, ignore))
+	e := MustParse(t, `{"id": "z", "items": [{"n": 1}, {"n": 2, "extra": {}}]}`)
+	assert.False(t, EqualIgnoring(a, e, ignore))
+	assert.False(t, EqualIgnoring(JsonMap{"a": JsonMap{}}, JsonMap{"a": JsonArray{}}, nil))
+}
+
+func TestEqualUnordered(t *testing.T) {
+	a := MustParse(t, `{"tags": ["a", "b", "b"], "items": [{"ids": [1, 2]}, {"ids": [3]}], "list": [1, 2]}`)
+	b := MustParse(t, `{"tags": ["b", "a", "b"], "items": [{"ids": [2, 1.0]}, {"ids": [3]}], "list": [1, 2]}`)
+	unordered := []string{"/tags", "/items/*/ids"}
+	assert.True(t, EqualUnordered(a, b, unordered))
+	assert.False(t, EqualUnordered(a, b, nil))
+	assert.False(t, EqualUnordered(a, b, []string{"/tags"}))
+	assert.True(t, EqualUnordered(a, a, nil))
+
+	// duplicates count
+	assert.False(t, EqualUnordered(MustParse(t, `["a", "b", "b"]`), MustParse(t, `["a", "a", "b"]`), []string{""}))
+	assert.True(t, EqualUnordered(MustParse(t, `[[1], {"x": 2}]`), MustParse(t, `[{"x": 2}, [1]]`), []string{""}))
+	assert.False(t, EqualUnordered(MustParse(t, `[1, 2]`), MustParse(t, `[2, 1, 1]`), []string{""}))
+
+	// only arrays exactly at the paths, and elements are compared with Equal
+	assert.False(t, EqualUnordered(MustParse(t, `{"x": [[1, 2]]}`), MustParse(t, `{"x": [[2, 1]]}`), []string{"/x"}))
+	assert.True(t, EqualUnordered(MustParse(t, `{"x": [[1, 2]]}`), MustParse(t, `{"x": [[2, 1]]}`), []string{"/x/0"}))
+	assert.False(t, EqualUnordered(MustParse(t, `{"list": [1, 2]}`), MustParse(t, `{"list": [2, 1]}`), []string{"/items"}))
+}
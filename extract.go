@@ -0,0 +1,159 @@
+package json_go
+
+import (
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Extract streams a document from r and calls fn for each value matched by path,
+// without building the rest of the tree. path is a JSONPath limited to `$`,
+// `.name`, `['name']`, `*` and non-negative `[index]`.
+// Matches are reported in stream order. An error from fn stops the extraction and is returned.
+func Extract(r io.Reader, path string, fn func(JsonValue) error) (err error) {
+	var segments []pathSegment
+	segments, err = parseStreamPath(path)
+	if err != nil {
+		return
+	}
+
+	d := NewDecoder(r)
+	err = d.extract(segments, fn)
+	if err != nil {
+		return
+	}
+
+	_, err = d.Token()
+	if err == io.EOF {
+		return nil
+	}
+	if err == nil {
+		err = &ParseError{d.pos, "not terminated"}
+	}
+	return
+}
+
+// CountMatching streams a document from r like Extract and counts the values matched by path
+// for which predicate is true, like the records with an error status in `$.records.*`.
+// Only one matched value is held at a time. On error, count is the number so far.
+func CountMatching(r io.Reader, path string, predicate func(JsonValue) bool) (count int, err error) {
+	err = Extract(r, path, func(value JsonValue) error {
+		if predicate(value) {
+			count++
+		}
+		return nil
+	})
+	return
+}
+
+// GetTopLevel returns the value of the member key of the object document input, parsing only that value:
+// the other members are skipped as they are read, and it stops right after the value. Unlike Parse,
+// the first of repeated keys is returned. The input is checked up to where it stops, so found is false
+// with a nil error only for a valid object without the key. Anything but an object is a ParseError.
+func GetTopLevel(input string, key string) (value JsonValue, found bool, err error) {
+	d := NewDecoder(strings.NewReader(input))
+	var tok Token
+	tok, err = d.Token()
+	if err == io.EOF {
+		err = ErrEmptyInput
+	}
+	if err != nil {
+		return
+	}
+	if tok.Kind != BeginObject {
+		err = &ParseError{SkipSpace([]rune(input), 0), "expect object"}
+		return
+	}
+
+	for {
+		tok, err = d.token()
+		if err != nil || tok.Kind == EndObject {
+			return
+		}
+		if tok.Value.(string) == key {
+			value, err = d.Decode()
+			if err != nil {
+				value = nil
+				return
+			}
+			return value, true, nil
+		}
+		err = d.SkipValue()
+		if err != nil {
+			return
+		}
+	}
+}
+
+func parseStreamPath(path string) (segments []pathSegment, err error) {
+	segments, err = parseJsonPath([]rune(path))
+	if err != nil {
+		return
+	}
+	for _, seg := range segments {
+		if seg.recursive || seg.kind == selectSlice || (seg.kind == selectIndex && seg.index < 0) {
+			err = &JsonPathError{0, "only names, '*' and non-negative indexes are supported when streaming"}
+			return
+		}
+	}
+	return
+}
+
+func (seg *pathSegment) matchKey(key string) bool {
+	return seg.kind == selectWildcard || (seg.kind == selectName && seg.name == key)
+}
+
+func (seg *pathSegment) matchIndex(idx int) bool {
+	return seg.kind == selectWildcard ||
+		(seg.kind == selectIndex && seg.index == idx) ||
+		(seg.kind == selectName && seg.name == strconv.Itoa(idx))
+}
+
+func (d *Decoder) extract(segments []pathSegment, fn func(JsonValue) error) (err error) {
+	var tok Token
+	tok, err = d.token()
+	if err != nil {
+		return
+	}
+
+	if len(segments) == 0 {
+		var value JsonValue
+		value, err = d.decodeFrom(tok)
+		if err != nil {
+			return
+		}
+		return fn(value)
+	}
+
+	seg := &segments[0]
+	switch tok.Kind {
+	case BeginObject:
+		for {
+			tok, err = d.token()
+			if err != nil || tok.Kind == EndObject {
+				return
+			}
+			if seg.matchKey(tok.Value.(string)) {
+				err = d.extract(segments[1:], fn)
+			} else {
+				err = d.SkipValue()
+			}
+			if err != nil {
+				return
+			}
+		}
+	case BeginArray:
+		for idx := 0; d.More(); idx++ {
+			if seg.matchIndex(idx) {
+				err = d.extract(segments[1:], fn)
+			} else {
+				err = d.SkipValue()
+			}
+			if err != nil {
+				return
+			}
+		}
+		_, err = d.token() // ']'
+	}
+	return
+}
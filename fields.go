@@ -0,0 +1,186 @@
+package json_go
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type fieldInfo struct {
+	name      string
+	index     []int // for reflect.Value.FieldByIndex
+	tagged    bool  // name given by the json tag
+	omitEmpty bool
+}
+
+var fieldCache sync.Map // reflect.Type -> []fieldInfo
+
+// typeFields returns the JSON fields of a struct type, including the fields promoted
+// from embedded structs. `json:"-"` and unexported fields are skipped.
+// Name collisions are resolved like encoding/json: the shallowest field wins,
+// then the tagged one, otherwise all fields with that name are dropped.
+func typeFields(t reflect.Type) []fieldInfo {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]fieldInfo)
+	}
+	fields := dominantFields(collectFields(t))
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+// breadth first walk through embedded structs, one depth at a time
+func collectFields(t reflect.Type) (fields []fieldInfo) {
+	type embedded struct {
+		t     reflect.Type
+		index []int
+	}
+
+	visited := map[reflect.Type]bool{}
+	next := []embedded{{t, nil}}
+	for len(next) > 0 {
+		current := next
+		next = nil
+		for _, e := range current {
+			if visited[e.t] {
+				continue
+			}
+			for i := 0; i < e.t.NumField(); i++ {
+				sf := e.t.Field(i)
+				ft := sf.Type
+				if sf.Anonymous && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous {
+					// an unexported embedded struct may still promote exported fields,
+					// but an unexported embedded pointer can not be allocated
+					if !sf.IsExported() && (ft.Kind() != reflect.Struct || sf.Type.Kind() == reflect.Ptr) {
+						continue
+					}
+				} else if !sf.IsExported() {
+					continue
+				}
+
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts, _ := strings.Cut(tag, ",")
+				index := append(append([]int{}, e.index...), i)
+
+				if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+					next = append(next, embedded{ft, index})
+					continue
+				}
+
+				field := fieldInfo{name: sf.Name, index: index, tagged: name != ""}
+				if name != "" {
+					field.name = name
+				}
+				for _, opt := range strings.Split(opts, ",") {
+					if opt == "omitempty" {
+						field.omitEmpty = true
+					}
+				}
+				fields = append(fields, field)
+			}
+		}
+		// mark after the whole depth, so the same type embedded twice at one depth collides
+		for _, e := range current {
+			visited[e.t] = true
+		}
+	}
+	return
+}
+
+func dominantFields(fields []fieldInfo) (result []fieldInfo) {
+	byName := map[string][]fieldInfo{}
+	for _, field := range fields {
+		byName[field.name] = append(byName[field.name], field)
+	}
+
+	for _, field := range fields {
+		candidates := byName[field.name]
+		if winner, ok := dominantField(candidates); ok && sameIndex(winner.index, field.index) {
+			result = append(result, field)
+		}
+	}
+
+	// in the order of declaration
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i].index, result[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return
+}
+
+func dominantField(candidates []fieldInfo) (winner fieldInfo, ok bool) {
+	depth := len(candidates[0].index)
+	for _, field := range candidates {
+		if len(field.index) < depth {
+			depth = len(field.index)
+		}
+	}
+
+	var shallow, tagged []fieldInfo
+	for _, field := range candidates {
+		if len(field.index) == depth {
+			shallow = append(shallow, field)
+			if field.tagged {
+				tagged = append(tagged, field)
+			}
+		}
+	}
+	switch {
+	case len(shallow) == 1:
+		return shallow[0], true
+	case len(tagged) == 1:
+		return tagged[0], true
+	}
+	return
+}
+
+func sameIndex(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// like FieldByIndex, but reports false instead of panicking on a nil embedded pointer
+func fieldByIndex(rv reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return reflect.Value{}, false
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv, true
+}
+
+// like FieldByIndex, but allocates nil embedded pointers
+func fieldByIndexAlloc(rv reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+			rv = rv.Elem()
+		}
+		rv = rv.Field(x)
+	}
+	return rv
+}
@@ -0,0 +1,93 @@
+package json_go
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Base struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type Audit struct {
+	Base
+	Created string `json:"created"`
+	Name    string `json:"audit_name"`
+}
+
+type Tagged struct {
+	Label string `json:"name"`
+}
+
+type Untagged struct {
+	Label string
+}
+
+type Other struct {
+	Label string
+}
+
+type document struct {
+	*Audit        // two levels of embedding through a pointer
+	Name   string `json:"name"` // shadows Base.Name
+	Tagged
+	Untagged
+	Other       // collides with Untagged.Label at the same depth, both dropped
+	Nested Base `json:"nested"`
+}
+
+func TestEmbeddedFields(t *testing.T) {
+	names := []string{}
+	for _, field := range typeFields(reflect.TypeOf(document{})) {
+		names = append(names, field.name)
+	}
+	assert.Equal(t, []string{"id", "created", "audit_name", "name", "nested"}, names)
+
+	doc := document{
+		Audit:  &Audit{Base: Base{ID: 1, Name: "hidden"}, Created: "c", Name: "a"},
+		Name:   "top",
+		Tagged: Tagged{Label: "hidden too"},
+		Nested: Base{ID: 2},
+	}
+	output, err := MarshalGo(doc)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"audit_name":"a","created":"c","id":1,"name":"top","nested":{"id":2,"name":""}}`, output)
+	}
+
+	// a nil embedded pointer contributes nothing
+	output, err = MarshalGo(document{Name: "top"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"name":"top","nested":{"id":0,"name":""}}`, output)
+	}
+
+	var got document
+	err = Unmarshal(`{"id": 3, "created": "c", "name": "n", "Label": "l", "nested": {"id": 4}}`, &got)
+	if assert.NoError(t, err) {
+		assert.Equal(t, document{
+			Audit:  &Audit{Base: Base{ID: 3}, Created: "c"},
+			Name:   "n",
+			Nested: Base{ID: 4},
+		}, got)
+	}
+}
+
+func TestTaggedFieldWins(t *testing.T) {
+	type plain struct {
+		Name string
+	}
+	type titled struct {
+		Title string `json:"Name"`
+	}
+	type both struct {
+		plain
+		titled
+	}
+
+	output, err := MarshalGo(both{plain{"p"}, titled{"t"}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"Name":"t"}`, output)
+	}
+}
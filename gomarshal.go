@@ -0,0 +1,184 @@
+package json_go
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// JsonMarshaler is implemented by types that convert themselves to a JsonValue for FromGo.
+type JsonMarshaler interface {
+	MarshalJSONValue() (JsonValue, error)
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*JsonMarshaler)(nil)).Elem()
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+)
+
+// FromGo converts a Go value to a JsonValue using reflection.
+// Struct fields follow the `json:"name,omitempty"` tag convention of encoding/json.
+func FromGo(v interface{}) (value JsonValue, err error) {
+	return fromGo(reflect.ValueOf(v), "")
+}
+
+func MarshalGo(v interface{}) (output string, err error) {
+	var value JsonValue
+	value, err = FromGo(v)
+	if err != nil {
+		return
+	}
+	return Marshal(value)
+}
+
+func fromGo(rv reflect.Value, path string) (value JsonValue, err error) {
+	if !rv.IsValid() {
+		return
+	}
+	if (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) && rv.IsNil() {
+		return
+	}
+
+	if rv.Type().Implements(jsonMarshalerType) {
+		value, err = rv.Interface().(JsonMarshaler).MarshalJSONValue()
+		if err != nil {
+			err = &MarshalError{path: path, msg: fmt.Sprintf("MarshalJSONValue of %s", rv.Type()), cause: err}
+		}
+		return
+	}
+	if rv.Kind() != reflect.Ptr && rv.CanAddr() && rv.Addr().Type().Implements(jsonMarshalerType) {
+		return fromGo(rv.Addr(), path)
+	}
+
+	// time.Time as RFC 3339, time.Duration as "1h30m"
+	switch rv.Type() {
+	case timeType:
+		value = rv.Interface().(time.Time).Format(time.RFC3339Nano)
+		return
+	case durationType:
+		value = time.Duration(rv.Int()).String()
+		return
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		value = rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u := rv.Uint()
+		if u > math.MaxInt64 {
+			err = &MarshalError{path: path, msg: fmt.Sprintf("%d overflows int64", u)}
+			return
+		}
+		value = int64(u)
+	case reflect.Float32, reflect.Float64:
+		value = rv.Float()
+	case reflect.String:
+		value = rv.String()
+	case reflect.Ptr, reflect.Interface:
+		return fromGo(rv.Elem(), path)
+	case reflect.Slice:
+		if rv.IsNil() {
+			return
+		}
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			value = base64.StdEncoding.EncodeToString(rv.Bytes())
+			return
+		}
+		return sliceFromGo(rv, path)
+	case reflect.Array:
+		return sliceFromGo(rv, path)
+	case reflect.Map:
+		if rv.IsNil() {
+			return
+		}
+		return mapFromGo(rv, path)
+	case reflect.Struct:
+		return structFromGo(rv, path)
+	default:
+		err = &MarshalError{path: path, msg: fmt.Sprintf("unsupported type: %s", rv.Type())}
+	}
+	return
+}
+
+func sliceFromGo(rv reflect.Value, path string) (value JsonValue, err error) {
+	arr := make(JsonArray, rv.Len())
+	for i := range arr {
+		arr[i], err = fromGo(rv.Index(i), pointerIndex(path, i))
+		if err != nil {
+			return
+		}
+	}
+	value = arr
+	return
+}
+
+func mapFromGo(rv reflect.Value, path string) (value JsonValue, err error) {
+	obj := make(JsonMap, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		var key string
+		k := iter.Key()
+		switch k.Kind() {
+		case reflect.String:
+			key = k.String()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			key = strconv.FormatInt(k.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			key = strconv.FormatUint(k.Uint(), 10)
+		default:
+			err = &MarshalError{path: path, msg: fmt.Sprintf("unsupported map key type: %s", k.Type())}
+			return
+		}
+
+		obj[key], err = fromGo(iter.Value(), pointerJoin(path, key))
+		if err != nil {
+			return
+		}
+	}
+	value = obj
+	return
+}
+
+func structFromGo(rv reflect.Value, path string) (value JsonValue, err error) {
+	obj := JsonMap{}
+	for _, field := range typeFields(rv.Type()) {
+		fv, ok := fieldByIndex(rv, field.index)
+		if !ok { // behind a nil embedded pointer
+			continue
+		}
+		if field.omitEmpty && isEmptyGo(fv) {
+			continue
+		}
+		obj[field.name], err = fromGo(fv, pointerJoin(path, field.name))
+		if err != nil {
+			return
+		}
+	}
+	value = obj
+	return
+}
+
+// the same definition of empty as encoding/json
+func isEmptyGo(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return rv.IsNil()
+	}
+	return false
+}
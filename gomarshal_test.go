@@ -0,0 +1,106 @@
+package json_go
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type upperName string
+
+func (n upperName) MarshalJSONValue() (JsonValue, error) {
+	return strings.ToUpper(string(n)), nil
+}
+
+type failing struct{}
+
+var errFailing = errors.New("failing")
+
+func (*failing) MarshalJSONValue() (JsonValue, error) {
+	return nil, errFailing
+}
+
+type point struct {
+	X, Y int
+}
+
+func (p point) MarshalJSONValue() (JsonValue, error) {
+	return JsonArray{int64(p.X), int64(p.Y)}, nil
+}
+
+func TestFromGo(t *testing.T) {
+	good := func(v interface{}, expect string) {
+		got, err := MarshalGo(v)
+		if assert.NoError(t, err) {
+			assert.Equal(t, expect, got)
+		}
+	}
+
+	type inner struct {
+		A []int `json:"a"`
+	}
+	type outer struct {
+		Name    string           `json:"name"`
+		Skip    string           `json:"-"`
+		Omit    int              `json:"omit,omitempty"`
+		Float   float32          `json:",omitempty"`
+		Inner   inner            `json:"inner"`
+		Map     map[string]uint8 `json:"map"`
+		IntKeys map[int]bool     `json:"int_keys"`
+		Any     interface{}      `json:"any"`
+		Value   JsonValue        `json:"value"`
+		Nil     []string         `json:"nil"`
+		private string
+	}
+
+	good(nil, "null")
+	good(true, "true")
+	good(int8(-3), "-3")
+	good(uint(3), "3")
+	good(1.5, "1.5")
+	good("s", `"s"`)
+	good([2]string{"a", "b"}, `["a","b"]`)
+	good(JsonMap{"a": JsonArray{int64(1)}}, `{"a":[1]}`)
+	good(&outer{
+		Name:    "n",
+		Skip:    "skip",
+		Inner:   inner{A: []int{1, 2}},
+		Map:     map[string]uint8{"b": 2},
+		IntKeys: map[int]bool{-1: true},
+		Any:     []interface{}{"x"},
+		Value:   JsonMap{},
+		private: "p",
+	}, `{"any":["x"],"inner":{"a":[1,2]},"int_keys":{"-1":true},"map":{"b":2},"name":"n","nil":null,"value":{}}`)
+
+	_, err := MarshalGo(uint64(1) << 63)
+	assert.Error(t, err)
+	_, err = MarshalGo(make(chan int))
+	assert.Error(t, err)
+}
+
+func TestFromGoJsonMarshaler(t *testing.T) {
+	type doc struct {
+		Name   upperName  `json:"name"`
+		Points []point    `json:"points"`
+		Ptr    *upperName `json:"ptr"`
+	}
+	ptr := upperName("p")
+	got, err := MarshalGo(doc{Name: "bob", Points: []point{{1, 2}}, Ptr: &ptr})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"name":"BOB","points":[[1,2]],"ptr":"P"}`, got)
+	}
+	got, err = MarshalGo(doc{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"name":"","points":null,"ptr":null}`, got)
+	}
+
+	type withFailing struct {
+		Items []failing `json:"items"`
+	}
+	_, err = FromGo(&withFailing{Items: []failing{{}}})
+	assert.True(t, errors.Is(err, errFailing))
+	assert.Contains(t, err.Error(), `"/items/0"`)
+	t.Log(err)
+}
@@ -0,0 +1,27 @@
+package json_go
+
+import "net/http"
+
+// WriteJSON writes value with the Encoder as the response, with status and the Content-Type
+// "application/json; charset=utf-8". The value is marshaled in full before anything is written,
+// so on a MarshalError the header and status are not sent yet and the handler can still answer
+// with an error status. Other errors are from writing the body.
+func WriteJSON(w http.ResponseWriter, status int, value JsonValue) error {
+	return NewEncoder(&responseWriter{w: w, status: status}).Encode(value)
+}
+
+// sends the header with the first write
+type responseWriter struct {
+	w      http.ResponseWriter
+	status int
+	sent   bool
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	if !rw.sent {
+		rw.sent = true
+		rw.w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		rw.w.WriteHeader(rw.status)
+	}
+	return rw.w.Write(p)
+}
@@ -0,0 +1,28 @@
+package json_go
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	assert.NoError(t, WriteJSON(rec, http.StatusCreated, JsonMap{"id": int64(1), "tags": JsonArray{"a"}}))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "{\"id\":1,\"tags\":[\"a\"]}\n", rec.Body.String())
+
+	// nothing is sent on a marshal error
+	rec = httptest.NewRecorder()
+	err := WriteJSON(rec, http.StatusOK, JsonArray{int64(1), math.NaN()})
+	assert.Equal(t, &MarshalError{path: "/1", msg: "unsupported float: NaN"}, err)
+	assert.False(t, rec.Flushed)
+	assert.Equal(t, "", rec.Header().Get("Content-Type"))
+	assert.Equal(t, 0, rec.Body.Len())
+	http.Error(rec, "oops", http.StatusInternalServerError)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
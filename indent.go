@@ -0,0 +1,80 @@
+package json_go
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// IndentStream pretty-prints the JSON values read from r to w like MarshalIndent, one token at a time,
+// so memory stays bounded by the longest string or number instead of the document.
+// Numbers and strings are copied as written, without reformatting or changing escapes,
+// and object members keep their input order. Each top-level value ends with a newline.
+func IndentStream(r io.Reader, w io.Writer, indent string) (err error) {
+	d := NewDecoder(r)
+	bw := bufio.NewWriter(w)
+	var counts []int // elements so far in each open container
+	afterKey := false
+	for {
+		var tok Token
+		tok, err = d.Token()
+		if err == io.EOF {
+			return bw.Flush()
+		}
+		if err != nil {
+			return
+		}
+
+		depth := len(counts)
+		switch {
+		case tok.Kind == EndArray || tok.Kind == EndObject:
+			if counts[depth-1] > 0 {
+				writeIndent(bw, indent, depth-1)
+			}
+			counts = counts[:depth-1]
+		case afterKey:
+			afterKey = false
+		case depth > 0:
+			if counts[depth-1] > 0 {
+				_ = bw.WriteByte(',')
+			}
+			counts[depth-1]++
+			writeIndent(bw, indent, depth)
+		}
+
+		switch tok.Kind {
+		case BeginArray:
+			_ = bw.WriteByte('[')
+			counts = append(counts, 0)
+		case BeginObject:
+			_ = bw.WriteByte('{')
+			counts = append(counts, 0)
+		case EndArray:
+			_ = bw.WriteByte(']')
+		case EndObject:
+			_ = bw.WriteByte('}')
+		case Key:
+			_, _ = bw.WriteString(string(d.buf)) // the runes of the last scalar as written
+			_, _ = bw.WriteString(": ")
+			afterKey = true
+		case Scalar:
+			_, _ = bw.WriteString(string(d.buf))
+		}
+		if len(counts) == 0 {
+			_ = bw.WriteByte('\n')
+		}
+
+		// errors of w are kept by bw
+		if bw.Buffered() >= 4096 {
+			err = bw.Flush()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeIndent(bw *bufio.Writer, indent string, depth int) {
+	_ = bw.WriteByte('\n')
+	_, _ = bw.WriteString(strings.Repeat(indent, depth))
+}
@@ -0,0 +1,86 @@
+package json_go
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndentStream(t *testing.T) {
+	indent := func(input string) (string, error) {
+		var out strings.Builder
+		err := IndentStream(strings.NewReader(input), &out, "  ")
+		return out.String(), err
+	}
+
+	output, err := indent(`{"b":[1,2.50,{}],"a":{"x":"é\n"},"c":[],"d":[[true]],"e":1E+2}`)
+	assert.NoError(t, err)
+	assert.Equal(t, `{
+  "b": [
+    1,
+    2.50,
+    {}
+  ],
+  "a": {
+    "x": "é\n"
+  },
+  "c": [],
+  "d": [
+    [
+      true
+    ]
+  ],
+  "e": 1E+2
+}
+`, output)
+
+	// same as MarshalIndent when reformatting is not involved
+	input := `{"a": [1, "x", null, {"b": false}], "c": {}}`
+	expect, _ := MarshalIndent(MustParse(t, input), "  ")
+	output, err = indent(input)
+	assert.NoError(t, err)
+	assert.Equal(t, expect+"\n", output)
+
+	output, err = indent(" 1 [] \"a\" ")
+	assert.NoError(t, err)
+	assert.Equal(t, "1\n[]\n\"a\"\n", output)
+	output, err = indent("")
+	assert.NoError(t, err)
+	assert.Equal(t, "", output)
+
+	_, err = indent(`[1,]`)
+	assert.Error(t, err)
+	_, err = indent(`{"a" 1}`)
+	assert.Error(t, err)
+	_, err = indent(`[1`)
+	assert.Error(t, err)
+}
+
+type failWriter struct{ err error }
+
+func (w failWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+func TestIndentStreamLarge(t *testing.T) {
+	// a huge array is written out as it is read
+	r, pw := io.Pipe()
+	go func() {
+		_, _ = pw.Write([]byte("["))
+		for i := 0; i < 100000; i++ {
+			_, _ = pw.Write([]byte("1234567890,"))
+		}
+		_, _ = pw.Write([]byte("0]"))
+		_ = pw.Close()
+	}()
+	var out strings.Builder
+	assert.NoError(t, IndentStream(r, &out, "\t"))
+	assert.Equal(t, 100000*len("\t1234567890,\n")+len("[\n\t0\n]\n"), out.Len())
+
+	broken := errors.New("broken")
+	err := IndentStream(strings.NewReader("["+strings.Repeat("1,", 10000)+"1]"), failWriter{broken}, "  ")
+	assert.Equal(t, broken, err)
+}
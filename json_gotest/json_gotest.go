@@ -0,0 +1,58 @@
+// Package json_gotest has test helpers for checking inputs against the parser and marshaler of json_go.
+package json_gotest
+
+import (
+	"testing"
+
+	"github.com/account-login/json_go"
+)
+
+// AssertRoundTrip checks that input parses, that the marshaled tree parses again to an Equal tree,
+// and that input, its Compact form and the marshaled output all Canonicalize to the same string.
+// Failures are reported with t.Errorf.
+func AssertRoundTrip(t testing.TB, input string) bool {
+	t.Helper()
+
+	value, err := json_go.Parse(input)
+	if err != nil {
+		t.Errorf("parse %q: %v", input, err)
+		return false
+	}
+	output, err := json_go.Marshal(value)
+	if err != nil {
+		t.Errorf("marshal %q: %v", input, err)
+		return false
+	}
+	again, err := json_go.Parse(output)
+	if err != nil {
+		t.Errorf("parse marshaled %q: %v", output, err)
+		return false
+	}
+	if !json_go.Equal(value, again) {
+		t.Errorf("round trip of %q changed the value: %q", input, output)
+		return false
+	}
+
+	compact, err := json_go.Compact(input)
+	if err != nil {
+		t.Errorf("compact %q: %v", input, err)
+		return false
+	}
+	expect, err := json_go.Canonicalize(input)
+	if err != nil {
+		t.Errorf("canonicalize %q: %v", input, err)
+		return false
+	}
+	for _, other := range []string{compact, output, expect} {
+		canonical, err := json_go.Canonicalize(other)
+		if err != nil {
+			t.Errorf("canonicalize %q: %v", other, err)
+			return false
+		}
+		if canonical != expect {
+			t.Errorf("canonical form of %q is %q, expect %q from %q", other, canonical, expect, input)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package json_gotest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssertRoundTrip(t *testing.T) {
+	inputs := []string{
+		`null`, `true`, `0`, `-1.5e3`, `1e21`, `"a\"\\\/\b\f\n\r\t\u0001啊"`,
+		` [ 1 , 2.0 , [ ] , { } ] `,
+		`{"b": {"y": [true, false, null], "x": 1e-7}, "a": "😀", "\ufb33": 9007199254740993}`,
+	}
+	for _, input := range inputs {
+		assert.True(t, AssertRoundTrip(t, input), input)
+	}
+}
+
+// records failures instead of failing the test
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertRoundTripFailure(t *testing.T) {
+	r := &recorder{TB: t}
+	assert.False(t, AssertRoundTrip(r, `[1,]`))
+	if assert.Len(t, r.errors, 1) {
+		assert.Contains(t, r.errors[0], `parse "[1,]"`)
+	}
+}
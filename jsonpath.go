@@ -0,0 +1,251 @@
+package json_go
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type JsonPathError struct {
+	pos int
+	msg string
+}
+
+func (err *JsonPathError) Error() string {
+	return fmt.Sprintf("JsonPathError at %d: %s", err.pos, err.msg)
+}
+
+type selectorKind int
+
+const (
+	selectName selectorKind = iota
+	selectWildcard
+	selectIndex
+	selectSlice
+)
+
+type pathSegment struct {
+	recursive bool // `..`
+	kind      selectorKind
+	name      string
+	index     int  // also the slice start
+	end       int  // slice end
+	hasStart  bool // slice bounds present
+	hasEnd    bool
+}
+
+// Query evaluates a JSONPath subset: `$`, `.name`, `['name']`, `[index]`,
+// `[start:end]`, `*` and the recursive descent `..`.
+// Negative indexes count from the end of the array. Filter expressions are not supported.
+// Results are in document order, with object members visited in sorted key order.
+func Query(root JsonValue, expr string) (result []JsonValue, err error) {
+	var segments []pathSegment
+	segments, err = parseJsonPath([]rune(expr))
+	if err != nil {
+		return
+	}
+
+	result = []JsonValue{root}
+	for _, seg := range segments {
+		var next []JsonValue
+		for _, value := range result {
+			if seg.recursive {
+				for _, node := range descendants(value, nil) {
+					next = seg.apply(node, next)
+				}
+			} else {
+				next = seg.apply(value, next)
+			}
+		}
+		result = next
+	}
+
+	if result == nil {
+		result = []JsonValue{}
+	}
+	return
+}
+
+// the value itself and all values below it in pre-order
+func descendants(value JsonValue, result []JsonValue) []JsonValue {
+	result = append(result, value)
+	for _, child := range children(value) {
+		result = descendants(child, result)
+	}
+	return result
+}
+
+func (seg *pathSegment) apply(value JsonValue, result []JsonValue) []JsonValue {
+	switch seg.kind {
+	case selectName:
+		if child, ok := childAt(value, seg.name); ok && isObject(value) {
+			result = append(result, child)
+		}
+	case selectWildcard:
+		result = append(result, children(value)...)
+	case selectIndex:
+		if arr, ok := value.(JsonArray); ok {
+			idx := seg.index
+			if idx < 0 {
+				idx += len(arr)
+			}
+			if 0 <= idx && idx < len(arr) {
+				result = append(result, arr[idx])
+			}
+		}
+	case selectSlice:
+		if arr, ok := value.(JsonArray); ok {
+			start, end := 0, len(arr)
+			if seg.hasStart {
+				start = clampIndex(seg.index, len(arr))
+			}
+			if seg.hasEnd {
+				end = clampIndex(seg.end, len(arr))
+			}
+			for i := start; i < end; i++ {
+				result = append(result, arr[i])
+			}
+		}
+	}
+	return result
+}
+
+func clampIndex(idx int, length int) int {
+	if idx < 0 {
+		idx += length
+	}
+	if idx < 0 {
+		return 0
+	}
+	if idx > length {
+		return length
+	}
+	return idx
+}
+
+func parseJsonPath(expr []rune) (segments []pathSegment, err error) {
+	if len(expr) == 0 || expr[0] != '$' {
+		err = &JsonPathError{0, "expect '$'"}
+		return
+	}
+
+	for cur := 1; cur < len(expr); {
+		var seg pathSegment
+		switch expr[cur] {
+		case '.':
+			cur++
+			if cur < len(expr) && expr[cur] == '.' {
+				seg.recursive = true
+				cur++
+				if cur < len(expr) && expr[cur] == '[' {
+					seg, cur, err = parseBracket(expr, cur)
+					seg.recursive = true
+					break
+				}
+			}
+			start := cur
+			for cur < len(expr) && expr[cur] != '.' && expr[cur] != '[' {
+				cur++
+			}
+			if cur == start {
+				err = &JsonPathError{cur, "expect member name or '*'"}
+				return
+			}
+			seg.name = string(expr[start:cur])
+			if seg.name == "*" {
+				seg.kind = selectWildcard
+			}
+		case '[':
+			seg, cur, err = parseBracket(expr, cur)
+		default:
+			err = &JsonPathError{cur, fmt.Sprintf("expect '.' or '[', got '%c'", expr[cur])}
+		}
+		if err != nil {
+			return
+		}
+		segments = append(segments, seg)
+	}
+	return
+}
+
+// [*], [index], [start:end] or a quoted ['name']
+func parseBracket(expr []rune, cur int) (seg pathSegment, next int, err error) {
+	next = cur + 1
+	if next >= len(expr) {
+		err = &JsonPathError{next, "expect selector, got EOS"}
+		return
+	}
+
+	switch ch := expr[next]; {
+	case ch == '*':
+		seg.kind = selectWildcard
+		next++
+	case ch == '\'' || ch == '"':
+		seg.kind = selectName
+		seg.name, next, err = parseQuotedName(expr, next)
+	default:
+		seg.kind = selectIndex
+		seg.index, seg.hasStart, next, err = parseJsonPathInt(expr, next)
+		if err != nil {
+			return
+		}
+		if next < len(expr) && expr[next] == ':' {
+			seg.kind = selectSlice
+			seg.end, seg.hasEnd, next, err = parseJsonPathInt(expr, next+1)
+		} else if !seg.hasStart {
+			err = &JsonPathError{next, "expect index, slice, name or '*'"}
+		}
+	}
+	if err != nil {
+		return
+	}
+
+	if next >= len(expr) || expr[next] != ']' {
+		err = &JsonPathError{next, "expect ']'"}
+		return
+	}
+	next++
+	return
+}
+
+func parseJsonPathInt(expr []rune, cur int) (value int, ok bool, next int, err error) {
+	next = cur
+	if next < len(expr) && expr[next] == '-' {
+		next++
+	}
+	for next < len(expr) && IsDigit(expr[next]) {
+		next++
+	}
+	if next == cur {
+		return
+	}
+
+	value, err = strconv.Atoi(string(expr[cur:next]))
+	if err != nil {
+		err = &JsonPathError{cur, fmt.Sprintf("bad integer %q", string(expr[cur:next]))}
+		return
+	}
+	ok = true
+	return
+}
+
+func parseQuotedName(expr []rune, cur int) (name string, next int, err error) {
+	quote := expr[cur]
+	val := []rune{}
+	for next = cur + 1; next < len(expr); next++ {
+		ch := expr[next]
+		switch {
+		case ch == quote:
+			name = string(val)
+			next++
+			return
+		case ch == '\\' && next+1 < len(expr):
+			next++
+			val = append(val, expr[next])
+		default:
+			val = append(val, ch)
+		}
+	}
+
+	err = &JsonPathError{next, "name not terminated"}
+	return
+}
@@ -0,0 +1,65 @@
+package json_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuery(t *testing.T) {
+	root := MustParse(t, `{
+		"store": {
+			"book": [
+				{"title": "a", "price": 8},
+				{"title": "b", "price": 12.5},
+				{"title": "c", "isbn": "x"}
+			],
+			"bicycle": {"price": 20}
+		},
+		"a.b": [0, 1, [2, 3]]
+	}`)
+	good := func(expr string, expect ...JsonValue) {
+		if expect == nil {
+			expect = []JsonValue{}
+		}
+		got, err := Query(root, expr)
+		if assert.NoError(t, err, expr) {
+			assert.Equal(t, expect, got, expr)
+		}
+	}
+	bad := func(expr string) {
+		_, err := Query(root, expr)
+		assert.Error(t, err, expr)
+		t.Log(expr, "\t", err)
+	}
+
+	good("$", root)
+	good("$.store.book[0].title", "a")
+	good("$['store']['book'][1]['title']", "b")
+	good(`$["a.b"][2][0]`, int64(2))
+	good("$.store.book[-1].isbn", "x")
+	good("$.store.book[*].title", "a", "b", "c")
+	good("$.store.book.*.price", int64(8), 12.5)
+	good("$.store.book[1:].title", "b", "c")
+	good("$.store.book[:-1].title", "a", "b")
+	good("$.store.book[-2:10].title", "b", "c")
+	good("$.store.book[:].title", "a", "b", "c")
+	good("$..price", int64(20), int64(8), 12.5)
+	good("$..book[0].title", "a")
+	good("$..[2][1]", int64(3))
+	good("$.store.*.price", int64(20))
+	good("$.nope")
+	good("$.store.book[5]")
+	good("$.store.book.title")
+
+	bad("")
+	bad("store")
+	bad("$.")
+	bad("$.store[")
+	bad("$.store[0")
+	bad("$.store['book")
+	bad("$.store[]")
+	bad("$.store[-]")
+	bad("$store")
+	bad("$[?(@.price)]")
+}
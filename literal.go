@@ -0,0 +1,92 @@
+package json_go
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// V converts a Go literal to a JsonValue, for hand-written trees like test fixtures:
+// ints and uints become int64, float32 becomes float64, and slices and maps with string keys
+// are converted element by element, JsonArray and JsonMap included.
+// It panics on any other type, or a uint that overflows int64.
+func V(x interface{}) JsonValue {
+	switch v := x.(type) {
+	case nil, bool, string, int64, float64, Decimal, SortedMap:
+		return v
+	case int:
+		return int64(v)
+	case int8:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case float32:
+		return float64(v)
+	case JsonArray:
+		arr := make(JsonArray, len(v))
+		for i, item := range v {
+			arr[i] = V(item)
+		}
+		return arr
+	case JsonMap:
+		obj := make(JsonMap, len(v))
+		for key, item := range v {
+			obj[key] = V(item)
+		}
+		return obj
+	}
+
+	rv := reflect.ValueOf(x)
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > math.MaxInt64 {
+			panic(fmt.Sprintf("json_go.V: %d overflows int64", rv.Uint()))
+		}
+		return int64(rv.Uint())
+	case reflect.Slice, reflect.Array:
+		arr := make(JsonArray, rv.Len())
+		for i := range arr {
+			arr[i] = V(rv.Index(i).Interface())
+		}
+		return arr
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			panic(fmt.Sprintf("json_go.V: map key must be string, got %s", rv.Type().Key()))
+		}
+		obj := make(JsonMap, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			obj[iter.Key().String()] = V(iter.Value().Interface())
+		}
+		return obj
+	}
+	panic(fmt.Sprintf("json_go.V: unsupported type %T", x))
+}
+
+// Arr is a JsonArray of the items converted by V.
+func Arr(items ...interface{}) JsonArray {
+	arr := make(JsonArray, len(items))
+	for i, item := range items {
+		arr[i] = V(item)
+	}
+	return arr
+}
+
+// Obj is a JsonMap from alternating keys and values, like Obj("id", 1, "tags", Arr("a")),
+// with the values converted by V. It panics if a key is not a string or the last value is missing.
+func Obj(pairs ...interface{}) JsonMap {
+	if len(pairs)%2 != 0 {
+		panic(fmt.Sprintf("json_go.Obj: odd number of arguments: %d", len(pairs)))
+	}
+	obj := make(JsonMap, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			panic(fmt.Sprintf("json_go.Obj: key %d must be string, got %T", i/2, pairs[i]))
+		}
+		obj[key] = V(pairs[i+1])
+	}
+	return obj
+}
@@ -0,0 +1,29 @@
+package json_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestV(t *testing.T) {
+	assert.Equal(t, MustParse(t, `{"a": 1, "b": [1.5, "x", true, null, {}], "c": {"d": [2]}}`),
+		Obj("a", 1, "b", Arr(1.5, "x", true, nil, Obj()), "c", map[string][]uint8{"d": {2}}))
+	assert.Equal(t, int64(-3), V(int8(-3)))
+	assert.Equal(t, int64(3), V(uint(3)))
+	assert.Equal(t, float64(0.5), V(float32(0.5)))
+	assert.Equal(t, JsonArray{int64(1), int64(2)}, V([]int{1, 2}))
+	assert.Equal(t, JsonArray{int64(1), int64(2)}, V([2]int{1, 2}))
+	assert.Equal(t, JsonArray{}, Arr())
+	assert.Equal(t, Decimal{"1.0"}, V(Decimal{"1.0"}))
+
+	// contents of JsonValue containers are converted too
+	assert.Equal(t, JsonMap{"a": JsonArray{int64(1)}}, V(JsonMap{"a": JsonArray{1}}))
+
+	assert.PanicsWithValue(t, "json_go.V: unsupported type struct {}", func() { V(struct{}{}) })
+	assert.Panics(t, func() { V(uint64(1 << 63)) })
+	assert.Panics(t, func() { V(map[int]int{1: 1}) })
+	assert.Panics(t, func() { V([]interface{}{1, &struct{}{}}) })
+	assert.Panics(t, func() { Obj("a") })
+	assert.PanicsWithValue(t, "json_go.Obj: key 1 must be string, got int", func() { Obj("a", 1, 2, 3) })
+}
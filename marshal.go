@@ -0,0 +1,506 @@
+package json_go
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+type MarshalError struct {
+	path  string // JSON Pointer of the offending value
+	msg   string
+	cause error
+}
+
+func (err *MarshalError) Error() string {
+	if err.cause != nil {
+		return fmt.Sprintf("MarshalError at %q: %s: %v", err.path, err.msg, err.cause)
+	}
+	return fmt.Sprintf("MarshalError at %q: %s", err.path, err.msg)
+}
+
+func (err *MarshalError) Unwrap() error {
+	return err.cause
+}
+
+func Marshal(value JsonValue) (output string, err error) {
+	var buf []byte
+	buf, err = AppendMarshal(nil, value)
+	output = string(buf)
+	return
+}
+
+func AppendMarshal(dst []byte, value JsonValue) ([]byte, error) {
+	m := marshaler{buf: dst}
+	err := m.marshal(value, "", 0)
+	return m.buf, err
+}
+
+// MarshalIndent puts each array element and object member on its own line,
+// prefixed by indent repeated for each nesting level.
+func MarshalIndent(value JsonValue, indent string) (output string, err error) {
+	m := marshaler{indent: indent, pretty: true}
+	err = m.marshal(value, "", 0)
+	output = string(m.buf)
+	return
+}
+
+// ValidateForMarshal reports every value that Marshal would fail on, instead of only the first:
+// a NaN or infinite float64 and any value of a type that is not a JsonValue.
+// Each is a MarshalError with the JSON Pointer of the value, joined with errors.Join, or nil if none.
+func ValidateForMarshal(value JsonValue) error {
+	var errs []error
+	validateForMarshal(value, "", &errs)
+	return errors.Join(errs...)
+}
+
+func validateForMarshal(root JsonValue, prefix string, errs *[]error) {
+	_ = Walk(root, func(path string, v JsonValue) error {
+		path = prefix + path
+		switch n := v.(type) {
+		case nil, bool, string, int64, Decimal, RawValue, JsonArray, JsonMap:
+		case float64:
+			if math.IsNaN(n) || math.IsInf(n, 0) {
+				*errs = append(*errs, &MarshalError{path: path, msg: fmt.Sprintf("unsupported float: %v", n)})
+			}
+		case []JsonKeyValue: // a leaf for Walk
+			for _, kv := range n {
+				validateForMarshal(kv.value, pointerJoin(path, kv.key), errs)
+			}
+		case SortedMap:
+			validateForMarshal([]JsonKeyValue(n), path, errs)
+		default:
+			*errs = append(*errs, &MarshalError{path: path, msg: fmt.Sprintf("unsupported type: %T", v)})
+		}
+		return nil
+	})
+}
+
+type MarshalOptions struct {
+	// called for each object with its keys sorted, returns them in the order to write, like putting
+	// "id" and "type" first. Keys left out are written after them in sorted order.
+	KeyOrder func(keys []string) []string
+	// error on keys left out by KeyOrder instead of appending them
+	KeyOrderStrict bool
+	// called with the JSON Pointer of each value Marshal can't write, a NaN or infinite float64
+	// or a value of an unsupported type, returns the value to write instead, like nil or a string.
+	// A returned error fails the marshal as the cause of the MarshalError. The replacement
+	// itself must be valid, it is not passed to OnInvalid again.
+	OnInvalid func(path string, v JsonValue) (JsonValue, error)
+	// written between a key and its value instead of ":" or ": " with MarshalIndent, like " : ".
+	// It must be a ':' with only JSON whitespace around it.
+	ColonSeparator string
+	// written between elements and members instead of ",", like ", ". It must be a ',' with only
+	// JSON whitespace around it, and with MarshalIndent it is followed by the newline.
+	CommaSeparator string
+}
+
+func (opts MarshalOptions) Marshal(value JsonValue) (output string, err error) {
+	err = opts.checkSeparators()
+	if err != nil {
+		return
+	}
+	m := marshaler{opts: opts}
+	err = m.marshal(value, "", 0)
+	output = string(m.buf)
+	return
+}
+
+func (opts MarshalOptions) MarshalIndent(value JsonValue, indent string) (output string, err error) {
+	err = opts.checkSeparators()
+	if err != nil {
+		return
+	}
+	m := marshaler{opts: opts, indent: indent, pretty: true}
+	err = m.marshal(value, "", 0)
+	output = string(m.buf)
+	return
+}
+
+func (opts MarshalOptions) checkSeparators() error {
+	if opts.ColonSeparator != "" && !isSeparator(opts.ColonSeparator, ':') {
+		return &MarshalError{msg: fmt.Sprintf("bad ColonSeparator %q", opts.ColonSeparator)}
+	}
+	if opts.CommaSeparator != "" && !isSeparator(opts.CommaSeparator, ',') {
+		return &MarshalError{msg: fmt.Sprintf("bad CommaSeparator %q", opts.CommaSeparator)}
+	}
+	return nil
+}
+
+// punct once, with whitespace allowed by the JSON grammar around it
+func isSeparator(sep string, punct byte) bool {
+	count := 0
+	for i := 0; i < len(sep); i++ {
+		switch sep[i] {
+		case punct:
+			count++
+		case ' ', '\t', '\n', '\r':
+		default:
+			return false
+		}
+	}
+	return count == 1
+}
+
+type marshaler struct {
+	opts   MarshalOptions
+	buf    []byte
+	pretty bool
+	spaced bool // single line with a space after ',' and ':'
+	indent string
+	color  bool
+	// RFC 8785: keys in UTF-16 order and every number formatted as a double
+	canonical bool
+	order     *ObjectOrder // for MarshalOrdered
+	trivia    *Trivia      // for MarshalTrivia
+	sortPairs bool         // write []JsonKeyValue in key order, for MarshalCanonicalPretty
+}
+
+func (m *marshaler) marshal(value JsonValue, path string, depth int) (err error) {
+	switch v := value.(type) {
+	case nil:
+		m.colored(colorNull, "null")
+	case bool:
+		m.colored(colorBool, strconv.FormatBool(v))
+	case string:
+		m.paint(colorString)
+		m.buf = appendQuote(m.buf, v)
+		m.paint(colorReset)
+	case int64:
+		m.paint(colorNumber)
+		if m.canonical {
+			m.buf = appendFloat(m.buf, float64(v))
+		} else {
+			// every digit whatever the magnitude, never through float64
+			m.buf = strconv.AppendInt(m.buf, v, 10)
+		}
+		m.paint(colorReset)
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return m.invalid(value, path, depth, fmt.Sprintf("unsupported float: %v", v))
+		}
+		m.paint(colorNumber)
+		if abs := math.Abs(v); !m.canonical && abs >= 1<<53 && abs < 1e21 {
+			// all digits without exponent would read back as an int64 that is not the same number
+			m.buf = strconv.AppendFloat(m.buf, v, 'e', -1, 64)
+		} else {
+			m.buf = appendFloat(m.buf, v)
+		}
+		m.paint(colorReset)
+	case Decimal:
+		m.paint(colorNumber)
+		if m.canonical {
+			var f float64
+			f, err = v.Float64()
+			if err != nil {
+				return &MarshalError{path: path, msg: "unsupported number", cause: err}
+			}
+			m.buf = appendFloat(m.buf, f)
+		} else {
+			m.buf = append(m.buf, v.text...)
+		}
+		m.paint(colorReset)
+	case RawValue:
+		m.buf = append(m.buf, v...)
+	case JsonArray:
+		return m.marshalArray(v, path, depth)
+	case JsonMap:
+		return m.marshalMap(v, path, depth)
+	case []JsonKeyValue:
+		if m.sortPairs {
+			v = sortedPairs(v)
+		}
+		return m.marshalPairs(v, path, depth)
+	case SortedMap:
+		if m.canonical || m.opts.KeyOrder != nil {
+			return m.marshalMap(v.ToMap(), path, depth)
+		}
+		return m.marshalPairs(v, path, depth)
+	default:
+		return m.invalid(value, path, depth, fmt.Sprintf("unsupported type: %T", value))
+	}
+	return
+}
+
+// write the replacement from OnInvalid, or fail with msg
+func (m *marshaler) invalid(value JsonValue, path string, depth int, msg string) (err error) {
+	onInvalid := m.opts.OnInvalid
+	if onInvalid == nil {
+		return &MarshalError{path: path, msg: msg}
+	}
+	var replacement JsonValue
+	replacement, err = onInvalid(path, value)
+	if err != nil {
+		return &MarshalError{path: path, msg: msg, cause: err}
+	}
+
+	m.opts.OnInvalid = nil
+	err = m.marshal(replacement, path, depth)
+	m.opts.OnInvalid = onInvalid
+	return
+}
+
+func (m *marshaler) marshalArray(arr JsonArray, path string, depth int) (err error) {
+	m.colored(colorPunct, "[")
+	for i, item := range arr {
+		if i > 0 {
+			m.comma()
+		}
+		itemPath := pointerIndex(path, i)
+		m.blankLines(m.trivia.BlankLinesBefore(itemPath))
+		m.comments(m.trivia.CommentsBefore(itemPath), depth+1)
+		m.newline(depth + 1)
+		err = m.marshal(item, itemPath, depth+1)
+		if err != nil {
+			return
+		}
+	}
+	if len(arr) > 0 {
+		m.blankLines(m.trivia.BlankLinesAtEnd(path))
+		m.newline(depth)
+	}
+	m.colored(colorPunct, "]")
+	return
+}
+
+func (m *marshaler) marshalMap(obj JsonMap, path string, depth int) (err error) {
+	var keys []string
+	if m.order != nil {
+		keys = m.order.Keys(obj)
+	} else {
+		keys = make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		if m.canonical {
+			sort.Slice(keys, func(i, j int) bool { return lessUTF16(keys[i], keys[j]) })
+		} else {
+			sort.Strings(keys)
+		}
+	}
+	if m.opts.KeyOrder != nil {
+		keys, err = m.orderKeys(obj, keys, path)
+		if err != nil {
+			return
+		}
+	}
+
+	m.colored(colorPunct, "{")
+	for i, key := range keys {
+		err = m.member(i, key, obj[key], path, depth)
+		if err != nil {
+			return
+		}
+	}
+	if len(keys) > 0 {
+		m.blankLines(m.trivia.BlankLinesAtEnd(path))
+		m.newline(depth)
+	}
+	m.colored(colorPunct, "}")
+	return
+}
+
+func (m *marshaler) orderKeys(obj JsonMap, sorted []string, path string) (keys []string, err error) {
+	seen := make(map[string]bool, len(sorted))
+	for _, key := range m.opts.KeyOrder(append([]string(nil), sorted...)) {
+		if _, ok := obj[key]; !ok || seen[key] {
+			err = &MarshalError{path: path, msg: fmt.Sprintf("KeyOrder returned unknown or repeated key %q", key)}
+			return
+		}
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	for _, key := range sorted {
+		if seen[key] {
+			continue
+		}
+		if m.opts.KeyOrderStrict {
+			err = &MarshalError{path: path, msg: fmt.Sprintf("KeyOrder left out key %q", key)}
+			return
+		}
+		keys = append(keys, key)
+	}
+	return
+}
+
+// members in slice order, repeated keys are kept
+func (m *marshaler) marshalPairs(pairs []JsonKeyValue, path string, depth int) (err error) {
+	m.colored(colorPunct, "{")
+	for i, kv := range pairs {
+		err = m.member(i, kv.key, kv.value, path, depth)
+		if err != nil {
+			return
+		}
+	}
+	if len(pairs) > 0 {
+		m.newline(depth)
+	}
+	m.colored(colorPunct, "}")
+	return
+}
+
+func (m *marshaler) member(i int, key string, value JsonValue, path string, depth int) error {
+	if i > 0 {
+		m.comma()
+	}
+	memberPath := pointerJoin(path, key)
+	m.blankLines(m.trivia.BlankLinesBefore(memberPath))
+	m.comments(m.trivia.CommentsBefore(memberPath), depth+1)
+	m.newline(depth + 1)
+	m.paint(colorKey)
+	m.buf = appendQuoteKey(m.buf, key)
+	m.paint(colorReset)
+	if m.opts.ColonSeparator != "" {
+		m.colored(colorPunct, m.opts.ColonSeparator)
+	} else {
+		m.colored(colorPunct, ":")
+		if m.pretty || m.spaced {
+			m.buf = append(m.buf, ' ')
+		}
+	}
+	return m.marshal(value, memberPath, depth+1)
+}
+
+func (m *marshaler) comma() {
+	if m.opts.CommaSeparator != "" {
+		m.colored(colorPunct, m.opts.CommaSeparator)
+		return
+	}
+	m.colored(colorPunct, ",")
+	if m.spaced {
+		m.buf = append(m.buf, ' ')
+	}
+}
+
+func (m *marshaler) newline(depth int) {
+	if !m.pretty {
+		return
+	}
+	m.buf = append(m.buf, '\n')
+	for i := 0; i < depth; i++ {
+		m.buf = append(m.buf, m.indent...)
+	}
+}
+
+func (m *marshaler) blankLines(n int) {
+	if !m.pretty {
+		return
+	}
+	for i := 0; i < n; i++ {
+		m.buf = append(m.buf, '\n')
+	}
+}
+
+// each on its own line, for MarshalTrivia
+func (m *marshaler) comments(texts []string, depth int) {
+	if !m.pretty {
+		return
+	}
+	for _, text := range texts {
+		m.newline(depth)
+		m.buf = append(m.buf, "//"...)
+		m.buf = append(m.buf, text...)
+	}
+}
+
+func (m *marshaler) paint(color string) {
+	if m.color {
+		m.buf = append(m.buf, color...)
+	}
+}
+
+func (m *marshaler) colored(color string, token string) {
+	m.paint(color)
+	m.buf = append(m.buf, token...)
+	m.paint(colorReset)
+}
+
+// FormatNumber formats an int64 or float64 like ECMAScript's Number.prototype.toString:
+// the shortest decimal that reads back as the same double, without a trailing ".0",
+// and in exponent form only below 1e-6 or from 1e21 up. An int64 is first converted to a double,
+// so integers beyond 2^53 may be rounded, like in JavaScript.
+func FormatNumber(v JsonValue) (string, error) {
+	var f float64
+	switch n := v.(type) {
+	case int64:
+		f = float64(n)
+	case float64:
+		f = n
+	default:
+		return "", &MarshalError{msg: fmt.Sprintf("expect number, got %T", v)}
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", &MarshalError{msg: fmt.Sprintf("unsupported float: %v", f)}
+	}
+	return string(appendFloat(nil, f)), nil
+}
+
+// same formatting as ECMAScript: exponent form only for very large or small magnitudes
+func appendFloat(dst []byte, f float64) []byte {
+	if f == 0 {
+		f = 0 // -0 is written as 0
+	}
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	dst = strconv.AppendFloat(dst, f, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(dst)
+		if n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+	return dst
+}
+
+// QuoteString is str as a JSON string literal, as Marshal writes it: quotes, backslashes and control
+// characters are escaped, other characters are kept as UTF-8, and invalid UTF-8 becomes U+FFFD.
+func QuoteString(str string) string {
+	return string(appendQuote(nil, str))
+}
+
+// QuoteKey is key quoted for an object member, for building JSON fragments by hand.
+// It is QuoteString for now, the marshalers write keys with it.
+func QuoteKey(key string) string {
+	return string(appendQuoteKey(nil, key))
+}
+
+func appendQuoteKey(dst []byte, key string) []byte {
+	return appendQuote(dst, key)
+}
+
+func appendQuote(dst []byte, str string) []byte {
+	dst = append(dst, '"')
+	for _, ch := range str {
+		switch ch {
+		case '"':
+			dst = append(dst, '\\', '"')
+		case '\\':
+			dst = append(dst, '\\', '\\')
+		case '\b':
+			dst = append(dst, '\\', 'b')
+		case '\f':
+			dst = append(dst, '\\', 'f')
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		case '\r':
+			dst = append(dst, '\\', 'r')
+		case '\t':
+			dst = append(dst, '\\', 't')
+		default:
+			if ch < 0x20 {
+				dst = append(dst, fmt.Sprintf(`\u%04x`, ch)...)
+			} else {
+				dst = utf8.AppendRune(dst, ch)
+			}
+		}
+	}
+	return append(dst, '"')
+}
@@ -0,0 +1,330 @@
+package json_go
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshal(t *testing.T) {
+	good := func(value JsonValue, expect string) {
+		got, err := Marshal(value)
+		if assert.NoError(t, err) {
+			assert.Equal(t, expect, got)
+		}
+	}
+	bad := func(value JsonValue) {
+		_, err := Marshal(value)
+		assert.Error(t, err)
+		t.Log(value, "\t", err)
+	}
+
+	good(nil, "null")
+	good(true, "true")
+	good(false, "false")
+	good(int64(-123), "-123")
+	good(1.5, "1.5")
+	good(100.0, "100")
+	good(1e21, "1e+21")
+	good(1e-7, "1e-7")
+	good(math.Copysign(0, -1), "0")
+	good(1.0000000000000002e17, "1.0000000000000002e+17")
+	good(float64(1<<53), "9.007199254740992e+15")
+	good(float64(1<<53-1), "9007199254740991")
+	good(1.5e-10, "1.5e-10")
+	good("", `""`)
+	good("a\"\\/\b\f\n\r\t\x01啊", `"a\"\\/\b\f\n\r\t\u0001啊"`)
+	good(JsonArray{}, "[]")
+	good(JsonMap{}, "{}")
+	good(JsonArray{int64(1), "a", nil}, `[1,"a",null]`)
+	good(JsonMap{"b": int64(1), "a": JsonArray{JsonMap{}}}, `{"a":[{}],"b":1}`)
+
+	bad(math.NaN())
+	bad(math.Inf(-1))
+	bad(JsonArray{JsonMap{"a": 1}})
+}
+
+func TestMarshalLargeInt(t *testing.T) {
+	for _, n := range []int64{math.MaxInt64, math.MinInt64, 1<<53 + 1, -(1<<53 + 1), 1e18} {
+		expect := strconv.FormatInt(n, 10)
+		output, err := Marshal(n)
+		assert.NoError(t, err)
+		assert.Equal(t, expect, output)
+		assert.Equal(t, n, MustParse(t, output))
+
+		output, err = MarshalIndent(JsonArray{n}, "  ")
+		assert.NoError(t, err)
+		assert.Equal(t, "[\n  "+expect+"\n]", output)
+		output, err = MarshalCanonicalPretty(JsonMap{"n": n})
+		assert.NoError(t, err)
+		assert.Equal(t, "{\n  \"n\": "+expect+"\n}\n", output)
+	}
+
+	value := MustParse(t, `{"id": 9223372036854775807}`)
+	output, err := Marshal(value)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":9223372036854775807}`, output)
+}
+
+func TestMarshalIndent(t *testing.T) {
+	value := JsonMap{"b": JsonArray{int64(1), JsonArray{}}, "a": JsonMap{}}
+	got, err := MarshalIndent(value, "  ")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "{\n  \"a\": {},\n  \"b\": [\n    1,\n    []\n  ]\n}", got)
+	}
+}
+
+func TestMarshalKeyValues(t *testing.T) {
+	pairs := []JsonKeyValue{NewKeyValue("b", int64(1)), NewKeyValue("a", JsonArray{}), NewKeyValue("b", nil)}
+	assert.Equal(t, "b", pairs[0].Key())
+	assert.Equal(t, int64(1), pairs[0].Value())
+
+	got, err := Marshal(JsonArray{pairs, []JsonKeyValue{}})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"b":1,"a":[],"b":null},{}]`, got)
+
+	got, err = MarshalIndent(pairs[:2], "  ")
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n  \"b\": 1,\n  \"a\": []\n}", got)
+
+	// the pairs ParseObjectPairs returns read back as the same document
+	input := `{"z":{"y":1},"a":2,"z":3}`
+	parsed, err := ParseObjectPairs(input)
+	assert.NoError(t, err)
+	got, err = Marshal(parsed)
+	assert.NoError(t, err)
+	assert.Equal(t, input, got)
+
+	_, err = Marshal([]JsonKeyValue{NewKeyValue("a", math.NaN())})
+	assert.Equal(t, &MarshalError{path: "/a", msg: "unsupported float: NaN"}, err)
+}
+
+func TestMarshalKeyOrder(t *testing.T) {
+	first := func(names ...string) func([]string) []string {
+		return func(keys []string) (result []string) {
+			for _, name := range names {
+				for _, key := range keys {
+					if key == name {
+						result = append(result, key)
+					}
+				}
+			}
+			return
+		}
+	}
+
+	value := JsonMap{"b": int64(1), "type": "t", "id": JsonMap{"z": nil, "id": nil}, "a": JsonArray{}}
+	got, err := MarshalOptions{KeyOrder: first("id", "type")}.Marshal(value)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":{"id":null,"z":null},"type":"t","a":[],"b":1}`, got)
+
+	got, err = MarshalOptions{KeyOrder: first("type")}.MarshalIndent(JsonMap{"a": int64(1), "type": "t"}, "  ")
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n  \"type\": \"t\",\n  \"a\": 1\n}", got)
+
+	reverse := func(keys []string) []string {
+		for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+		return keys
+	}
+	got, err = MarshalOptions{KeyOrder: reverse, KeyOrderStrict: true}.Marshal(value)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"t","id":{"z":null,"id":null},"b":1,"a":[]}`, got)
+
+	_, err = MarshalOptions{KeyOrder: first("id", "type"), KeyOrderStrict: true}.Marshal(value)
+	assert.Equal(t, &MarshalError{path: "", msg: `KeyOrder left out key "a"`}, err)
+	_, err = MarshalOptions{KeyOrder: first("z"), KeyOrderStrict: true}.Marshal(JsonMap{"z": JsonMap{"a": nil}})
+	assert.Equal(t, &MarshalError{path: "/z", msg: `KeyOrder left out key "a"`}, err)
+	_, err = MarshalOptions{KeyOrder: func([]string) []string { return []string{"c"} }}.Marshal(value)
+	assert.Equal(t, &MarshalError{path: "", msg: `KeyOrder returned unknown or repeated key "c"`}, err)
+	_, err = MarshalOptions{KeyOrder: func(keys []string) []string { return append(keys, keys[0]) }}.Marshal(JsonMap{"x": JsonMap{"y": nil}})
+	assert.Equal(t, &MarshalError{path: "", msg: `KeyOrder returned unknown or repeated key "x"`}, err)
+
+	// the default is sorted
+	got, err = MarshalOptions{}.Marshal(value)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":[],"b":1,"id":{"id":null,"z":null},"type":"t"}`, got)
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	for _, input := range []string{
+		`[1, -2.5, 1e300, "\u0000퟿", {"a": {"b": [true, false, null]}}]`,
+		`{"": "", "\"": "\\"}`,
+	} {
+		value := MustParse(t, input)
+		output, err := Marshal(value)
+		if assert.NoError(t, err) {
+			assert.Equal(t, value, MustParse(t, output))
+		}
+	}
+}
+
+func TestMarshalColor(t *testing.T) {
+	defer func(saved bool) { NoColor = saved }(NoColor)
+
+	value := JsonMap{"k\"": JsonArray{"s\n", int64(1), true, nil}}
+
+	NoColor = false
+	got, err := MarshalColor(value, " ")
+	if assert.NoError(t, err) {
+		expect := colorPunct + "{" + colorReset + "\n " +
+			colorKey + `"k\""` + colorReset + colorPunct + ":" + colorReset + " " +
+			colorPunct + "[" + colorReset + "\n  " +
+			colorString + `"s\n"` + colorReset + colorPunct + "," + colorReset + "\n  " +
+			colorNumber + "1" + colorReset + colorPunct + "," + colorReset + "\n  " +
+			colorBool + "true" + colorReset + colorPunct + "," + colorReset + "\n  " +
+			colorNull + "null" + colorReset + "\n " +
+			colorPunct + "]" + colorReset + "\n" +
+			colorPunct + "}" + colorReset
+		assert.Equal(t, expect, got)
+	}
+
+	NoColor = true
+	got, err = MarshalColor(value, " ")
+	if assert.NoError(t, err) {
+		plain, _ := MarshalIndent(value, " ")
+		assert.Equal(t, plain, got)
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	format := func(v JsonValue, expect string) {
+		output, err := FormatNumber(v)
+		if assert.NoError(t, err, "%v", v) {
+			assert.Equal(t, expect, output, "%v", v)
+		}
+	}
+
+	format(1e21, "1e+21")
+	format(1e20, "100000000000000000000")
+	format(0.0001, "0.0001")
+	format(0.000001, "0.000001")
+	format(5e-7, "5e-7")
+	format(1.5e-10, "1.5e-10")
+	format(1.5e300, "1.5e+300")
+	format(123e-20, "1.23e-18")
+	a, b := 0.1, 0.2
+	format(a+b, "0.30000000000000004")
+	format(2.0, "2")
+	format(-1.5, "-1.5")
+	format(math.Copysign(0, -1), "0")
+	format(math.MaxFloat64, "1.7976931348623157e+308")
+	format(5e-324, "5e-324")
+	format(int64(100), "100")
+	format(int64(-7), "-7")
+	format(int64(1<<53+1), "9007199254740992")
+
+	_, err := FormatNumber(math.NaN())
+	assert.Error(t, err)
+	_, err = FormatNumber(math.Inf(-1))
+	assert.Error(t, err)
+	_, err = FormatNumber("1")
+	assert.Error(t, err)
+}
+
+func TestMarshalOnInvalid(t *testing.T) {
+	var paths []string
+	opts := MarshalOptions{OnInvalid: func(path string, v JsonValue) (JsonValue, error) {
+		paths = append(paths, path)
+		if f, ok := v.(float64); ok {
+			return fmt.Sprint(f), nil
+		}
+		return nil, nil
+	}}
+	value := JsonMap{
+		"a": JsonArray{1.0, math.NaN(), JsonMap{"b": math.Inf(-1)}},
+		"c": 1, // not int64
+	}
+	got, err := opts.Marshal(value)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":[1,"NaN",{"b":"-Inf"}],"c":null}`, got)
+	assert.Equal(t, []string{"/a/1", "/a/2/b", "/c"}, paths)
+
+	got, err = opts.MarshalIndent(JsonArray{struct{}{}}, "  ")
+	assert.NoError(t, err)
+	assert.Equal(t, "[\n  null\n]", got)
+
+	// an error from the callback
+	errSkip := errors.New("skip")
+	fail := MarshalOptions{OnInvalid: func(string, JsonValue) (JsonValue, error) { return nil, errSkip }}
+	_, err = fail.Marshal(JsonArray{int64(1), math.NaN()})
+	assert.ErrorIs(t, err, errSkip)
+	assert.Equal(t, `MarshalError at "/1": unsupported float: NaN: skip`, err.Error())
+
+	// the replacement is not replaced again
+	again := MarshalOptions{OnInvalid: func(string, JsonValue) (JsonValue, error) {
+		return JsonArray{nil, math.NaN()}, nil
+	}}
+	_, err = again.Marshal(JsonMap{"a": 1})
+	assert.Equal(t, &MarshalError{path: "/a/1", msg: "unsupported float: NaN"}, err)
+
+	// without the option
+	_, err = MarshalOptions{}.Marshal(value)
+	assert.Equal(t, `MarshalError at "/a/1": unsupported float: NaN`, err.Error())
+}
+
+func TestMarshalSeparators(t *testing.T) {
+	value := MustParse(t, `{"a": [1, 2], "b": {"c": null}}`)
+	got, err := MarshalOptions{CommaSeparator: ", "}.Marshal(value)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":[1, 2], "b":{"c":null}}`, got)
+	got, err = MarshalOptions{ColonSeparator: ": ", CommaSeparator: ","}.Marshal(value)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a": [1,2],"b": {"c": null}}`, got)
+	got, err = MarshalOptions{ColonSeparator: " : "}.MarshalIndent(value, "  ")
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n  \"a\" : [\n    1,\n    2\n  ],\n  \"b\" : {\n    \"c\" : null\n  }\n}", got)
+	got, err = MarshalOptions{ColonSeparator: ":"}.MarshalIndent(value, "")
+	assert.NoError(t, err)
+	assert.Equal(t, MustParse(t, got), value)
+
+	for _, opts := range []MarshalOptions{{ColonSeparator: "="}, {ColonSeparator: "::"}, {CommaSeparator: " "}, {CommaSeparator: ",\u00a0"}} {
+		_, err = opts.Marshal(value)
+		assert.Error(t, err, "%+v", opts)
+	}
+	_, err = MarshalOptions{ColonSeparator: " =>"}.MarshalIndent(value, "")
+	assert.Equal(t, &MarshalError{msg: `bad ColonSeparator " =>"`}, err)
+}
+
+func TestValidateForMarshal(t *testing.T) {
+	assert.NoError(t, ValidateForMarshal(MustParse(t, `{"a": [1, 2.5, "x", null, true, {}]}`)))
+	assert.NoError(t, ValidateForMarshal(Decimal{"1.5"}))
+
+	value := JsonMap{
+		"a": JsonArray{1.0, math.NaN(), JsonMap{"b/c": math.Inf(-1)}},
+		"d": 1, // not int64
+		"e": []JsonKeyValue{NewKeyValue("f", math.Inf(1))},
+	}
+	err := ValidateForMarshal(value)
+	var errs []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		errs = append(errs, e.Error())
+	}
+	assert.Equal(t, []string{
+		`MarshalError at "/a/1": unsupported float: NaN`,
+		`MarshalError at "/a/2/b~1c": unsupported float: -Inf`,
+		`MarshalError at "/d": unsupported type: int`,
+		`MarshalError at "/e/f": unsupported float: +Inf`,
+	}, errs)
+
+	// Marshal stops at the first
+	_, err = Marshal(value)
+	assert.Equal(t, `MarshalError at "/a/1": unsupported float: NaN`, err.Error())
+}
+
+func TestQuote(t *testing.T) {
+	assert.Equal(t, `"a\"b\\c\n\u0001é"`, QuoteString("a\"b\\c\n\x01é"))
+	assert.Equal(t, "\"\ufffd\"", QuoteString("\xff"))
+	assert.Equal(t, `"k/\t"`, QuoteKey("k/\t"))
+	assert.Equal(t, `""`, QuoteKey(""))
+
+	// a fragment built by hand parses back
+	value, err := Parse("{" + QuoteKey("a\"") + ":" + QuoteString("\r") + "}")
+	assert.NoError(t, err)
+	assert.Equal(t, JsonMap{"a\"": "\r"}, value)
+}
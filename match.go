@@ -0,0 +1,54 @@
+package json_go
+
+// MatchMarkers are the special template strings of Matches and the values they accept.
+// Add to it for custom markers.
+var MatchMarkers = map[string]func(v JsonValue) bool{
+	"*":         func(v JsonValue) bool { return true },
+	"<null>":    func(v JsonValue) bool { return v == nil },
+	"<bool>":    func(v JsonValue) bool { return TypeName(v) == "boolean" },
+	"<number>":  func(v JsonValue) bool { return TypeName(v) == "number" },
+	"<integer>": func(v JsonValue) bool { return isSchemaType(v, "integer") },
+	"<string>":  func(v JsonValue) bool { return TypeName(v) == "string" },
+	"<array>":   func(v JsonValue) bool { return TypeName(v) == "array" },
+	"<object>":  func(v JsonValue) bool { return TypeName(v) == "object" },
+}
+
+// Matches reports whether value has the shape of template. A string in the template
+// that is a key of MatchMarkers matches the values accepted by the marker, like "<number>" for any number
+// or "*" for anything. Everything else must match exactly as with Equal: objects have the same keys
+// and arrays the same length.
+func Matches(value JsonValue, template JsonValue) bool {
+	switch tv := template.(type) {
+	case string:
+		if marker, ok := MatchMarkers[tv]; ok {
+			return marker(value)
+		}
+		return Equal(value, template)
+	case JsonArray:
+		arr, ok := value.(JsonArray)
+		if !ok || len(arr) != len(tv) {
+			return false
+		}
+		for i := range tv {
+			if !Matches(arr[i], tv[i]) {
+				return false
+			}
+		}
+		return true
+	case JsonMap, SortedMap:
+		obj, ok := asMap(value)
+		tm, _ := asMap(tv)
+		if !ok || len(obj) != len(tm) {
+			return false
+		}
+		for key, sub := range tm {
+			item, ok := obj[key]
+			if !ok || !Matches(item, sub) {
+				return false
+			}
+		}
+		return true
+	default:
+		return Equal(value, template)
+	}
+}
@@ -0,0 +1,40 @@
+package json_go
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatches(t *testing.T) {
+	template := MustParse(t, `{"id": "<integer>", "name": "<string>", "tags": ["<string>", "*"],
+		"meta": "<object>", "ok": true, "score": "<number>", "gone": "<null>"}`)
+	match := func(input string, expect bool) {
+		assert.Equal(t, expect, Matches(MustParse(t, input), template), input)
+	}
+
+	match(`{"id": 1, "name": "a", "tags": ["x", [1]], "meta": {}, "ok": true, "score": 1.5, "gone": null}`, true)
+	match(`{"id": 1.0, "name": "", "tags": ["x", null], "meta": {"a": 1}, "ok": true, "score": 2, "gone": null}`, true)
+	match(`{"id": 1.5, "name": "a", "tags": ["x", 1], "meta": {}, "ok": true, "score": 1, "gone": null}`, false)
+	match(`{"id": 1, "name": 2, "tags": ["x", 1], "meta": {}, "ok": true, "score": 1, "gone": null}`, false)
+	match(`{"id": 1, "name": "a", "tags": ["x"], "meta": {}, "ok": true, "score": 1, "gone": null}`, false)
+	match(`{"id": 1, "name": "a", "tags": ["x", 1], "meta": [], "ok": true, "score": 1, "gone": null}`, false)
+	match(`{"id": 1, "name": "a", "tags": ["x", 1], "meta": {}, "ok": false, "score": 1, "gone": null}`, false)
+	match(`{"id": 1, "name": "a", "tags": ["x", 1], "meta": {}, "ok": true, "score": 1}`, false)
+	match(`{"id": 1, "name": "a", "tags": ["x", 1], "meta": {}, "ok": true, "score": 1, "gone": null, "x": 1}`, false)
+
+	assert.True(t, Matches("literal", "literal"))
+	assert.False(t, Matches("<string>x", "<string>"+"y"))
+	assert.True(t, Matches(int64(2), 2.0))
+	assert.True(t, Matches(JsonArray{true}, JsonArray{"<bool>"}))
+	assert.True(t, Matches(JsonArray{}, "<array>"))
+
+	MatchMarkers["<uuid>"] = func(v JsonValue) bool {
+		s, ok := v.(string)
+		return ok && len(s) == 36 && strings.Count(s, "-") == 4
+	}
+	defer delete(MatchMarkers, "<uuid>")
+	assert.True(t, Matches(JsonMap{"id": "123e4567-e89b-12d3-a456-426614174000"}, JsonMap{"id": "<uuid>"}))
+	assert.False(t, Matches(JsonMap{"id": "123"}, JsonMap{"id": "<uuid>"}))
+}
@@ -0,0 +1,200 @@
+package json_go
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type MergeError struct {
+	path string // JSON Pointer of the offending element
+	msg  string
+}
+
+func (err *MergeError) Error() string {
+	return fmt.Sprintf("MergeError at %q: %s", err.path, err.msg)
+}
+
+// Merge applies override to base as a JSON Merge Patch (RFC 7386): objects are merged member by member,
+// a null member of override removes the member from base, and any other value replaces the value of base,
+// so arrays are replaced whole, see MergeArraysByKey. The result is a new tree that shares nothing with
+// base or override.
+func Merge(base, override JsonValue) JsonValue {
+	patch, ok := asMap(override)
+	if !ok {
+		return cloneValue(override)
+	}
+	obj, ok := asMap(base)
+	if !ok {
+		obj = JsonMap{}
+	}
+	result := make(JsonMap, len(obj))
+	for key, value := range obj {
+		if _, ok := patch[key]; !ok {
+			result[key] = cloneValue(value)
+		}
+	}
+	for key, value := range patch {
+		if value != nil {
+			result[key] = Merge(obj[key], value)
+		}
+	}
+	return result
+}
+
+// what a Change did
+type ChangeKind int
+
+const (
+	Added   ChangeKind = iota + 1 // Old is nil
+	Removed                       // New is nil
+	Replaced
+)
+
+// a member or value that MergeWithLog changed, at a JSON Pointer
+type Change struct {
+	Path string
+	Kind ChangeKind
+	Old  JsonValue
+	New  JsonValue
+}
+
+// MergeWithLog is Merge that also lists what override changed in base, in document order with the keys
+// of each object sorted. A value merged into an object is logged member by member, and a member replaced
+// by an identical value or removed when missing is not logged. Values are compared exactly like in Diff,
+// so 1 and 1.0 differ. Neither the result nor the changes share anything with base or override.
+func MergeWithLog(base, override JsonValue) (result JsonValue, changes []Change) {
+	changes = []Change{}
+	result = mergeLog(base, override, "", &changes)
+	return
+}
+
+func mergeLog(base, override JsonValue, path string, changes *[]Change) JsonValue {
+	patch, ok := asMap(override)
+	obj, isObj := asMap(base)
+	if !ok || !isObj {
+		result := Merge(base, override)
+		if !identical(base, result) {
+			*changes = append(*changes, Change{path, Replaced, cloneValue(base), cloneValue(result)})
+		}
+		return result
+	}
+
+	result := make(JsonMap, len(obj))
+	for key, value := range obj {
+		if _, ok := patch[key]; !ok {
+			result[key] = cloneValue(value)
+		}
+	}
+	for _, key := range sortedKeys(patch) {
+		value := patch[key]
+		old, inBase := obj[key]
+		switch {
+		case value == nil:
+			if inBase {
+				*changes = append(*changes, Change{pointerJoin(path, key), Removed, cloneValue(old), nil})
+			}
+		case !inBase:
+			result[key] = Merge(nil, value)
+			*changes = append(*changes, Change{pointerJoin(path, key), Added, nil, Merge(nil, value)})
+		default:
+			result[key] = mergeLog(old, value, pointerJoin(path, key), changes)
+		}
+	}
+	return result
+}
+
+func MergeArraysByKey(base, override JsonArray, keyField string) (JsonArray, error) {
+	return MergeOptions{}.MergeArraysByKey(base, override, keyField)
+}
+
+type MergeOptions struct {
+	// keep the elements that are not objects with keyField, from base in place and from override
+	// at the end, instead of failing
+	KeepUnkeyed bool
+}
+
+// MergeArraysByKey merges two arrays of objects identified by the member keyField, like "name":
+// an element of override is merged with Merge into the element of base with an Equal key, in place,
+// and an element with a new key is appended, in the order of override. A key must be a string,
+// number, bool or null, and be unique in each array. The result shares nothing with base or override.
+func (opts MergeOptions) MergeArraysByKey(base, override JsonArray, keyField string) (result JsonArray, err error) {
+	result = make(JsonArray, 0, len(base)+len(override))
+	index := map[string]int{} // by the canonical key, into result
+	for i, item := range base {
+		key, ok, suberr := mergeKey(item, keyField, pointerIndex("/base", i))
+		if suberr != nil {
+			return nil, suberr
+		}
+		if !ok && !opts.KeepUnkeyed {
+			return nil, &MergeError{pointerIndex("/base", i), fmt.Sprintf("expect object with %q", keyField)}
+		}
+		if ok {
+			if _, dup := index[key]; dup {
+				return nil, &MergeError{pointerIndex("/base", i), fmt.Sprintf("duplicate %q: %s", keyField, key)}
+			}
+			index[key] = len(result)
+		}
+		result = append(result, cloneValue(item))
+	}
+
+	seen := map[string]bool{}
+	for i, item := range override {
+		key, ok, suberr := mergeKey(item, keyField, pointerIndex("/override", i))
+		if suberr != nil {
+			return nil, suberr
+		}
+		if !ok && !opts.KeepUnkeyed {
+			return nil, &MergeError{pointerIndex("/override", i), fmt.Sprintf("expect object with %q", keyField)}
+		}
+		if !ok {
+			result = append(result, cloneValue(item))
+			continue
+		}
+		if seen[key] {
+			return nil, &MergeError{pointerIndex("/override", i), fmt.Sprintf("duplicate %q: %s", keyField, key)}
+		}
+		seen[key] = true
+		if j, found := index[key]; found {
+			result[j] = Merge(result[j], item)
+		} else {
+			result = append(result, cloneValue(item))
+		}
+	}
+	return
+}
+
+// the identity of an element as the canonical JSON of its key, with a number written from its exact
+// digits like 15e-1, so that 1 and 1.0 are the same key and large integers stay distinct.
+// ok is false for an element that isn't an object with the key.
+func mergeKey(item JsonValue, keyField string, path string) (key string, ok bool, err error) {
+	obj, isObj := asMap(item)
+	if !isObj {
+		return
+	}
+	value, has := obj[keyField]
+	if !has {
+		return
+	}
+	switch value.(type) {
+	case nil, bool, string, int64, float64, Decimal:
+	default:
+		return "", false, &MergeError{pointerJoin(path, keyField), fmt.Sprintf("expect scalar key, got %s", TypeName(value))}
+	}
+	var buf [32]byte
+	if negative, digits, exp, exact := exactDigits(value, buf[:0]); exact {
+		if len(digits) == 0 {
+			return "0", true, nil
+		}
+		if negative {
+			key = "-"
+		}
+		key += string(digits)
+		if exp != 0 {
+			key += "e" + strconv.Itoa(exp)
+		}
+		return key, true, nil
+	}
+	m := marshaler{canonical: true}
+	err = m.marshal(value, pointerJoin(path, keyField), 0)
+	return string(m.buf), err == nil, err
+}
@@ -0,0 +1,110 @@
+package json_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMerge(t *testing.T) {
+	base := MustParse(t, `{"a": 1, "b": {"c": [1], "d": true}, "e": "x"}`)
+	override := MustParse(t, `{"a": null, "b": {"c": [2, 3], "f": {"g": null}}, "h": 0}`)
+	result := Merge(base, override)
+	assert.Equal(t, MustParse(t, `{"b": {"c": [2, 3], "d": true, "f": {}}, "e": "x", "h": 0}`), result)
+	assert.Equal(t, MustParse(t, `{"a": 1, "b": {"c": [1], "d": true}, "e": "x"}`), base)
+
+	assert.Equal(t, MustParse(t, `[1]`), Merge(base, MustParse(t, `[1]`)))
+	assert.Equal(t, MustParse(t, `{"a": 1}`), Merge(MustParse(t, `[1]`), MustParse(t, `{"a": 1, "b": null}`)))
+	assert.Equal(t, nil, Merge(base, nil))
+
+	// nothing is shared
+	result.(JsonMap)["b"].(JsonMap)["c"].(JsonArray)[0] = "changed"
+	assert.Equal(t, MustParse(t, `{"a": null, "b": {"c": [2, 3], "f": {"g": null}}, "h": 0}`), override)
+}
+
+func TestMergeWithLog(t *testing.T) {
+	base := MustParse(t, `{"a": 1, "b": {"c": [1], "d": true, "e": "x"}, "f": "y", "n": null}`)
+	override := MustParse(t, `{"f": "y", "a": null, "b": {"e": null, "c": [2], "d": true, "g": {"h": null, "i": 1}}, "j": 1.0, "n": null, "z": null}`)
+	result, changes := MergeWithLog(base, override)
+	assert.Equal(t, Merge(base, override), result)
+	assert.Equal(t, []Change{
+		{"/a", Removed, int64(1), nil},
+		{"/b/c", Replaced, JsonArray{int64(1)}, JsonArray{int64(2)}},
+		{"/b/e", Removed, "x", nil},
+		{"/b/g", Added, nil, JsonMap{"i": int64(1)}},
+		{"/j", Added, nil, 1.0},
+		{"/n", Removed, nil, nil},
+	}, changes)
+
+	// the log is the same as applying the patch
+	patch := JsonArray{}
+	for _, change := range changes {
+		op := map[ChangeKind]string{Added: "add", Removed: "remove", Replaced: "replace"}[change.Kind]
+		item := JsonMap{"op": op, "path": change.Path}
+		if change.Kind != Removed {
+			item["value"] = change.New
+		}
+		patch = append(patch, item)
+	}
+	patched, err := ApplyPatch(base, patch)
+	assert.NoError(t, err)
+	assert.Equal(t, result, patched)
+
+	// exact comparison, and no objects on one side
+	_, changes = MergeWithLog(MustParse(t, `{"a": 1}`), MustParse(t, `{"a": 1.0}`))
+	assert.Equal(t, []Change{{"/a", Replaced, int64(1), 1.0}}, changes)
+	_, changes = MergeWithLog(MustParse(t, `[1]`), MustParse(t, `{"a": {"b": null}}`))
+	assert.Equal(t, []Change{{"", Replaced, JsonArray{int64(1)}, JsonMap{"a": JsonMap{}}}}, changes)
+	result, changes = MergeWithLog(base, base)
+	assert.Equal(t, MustParse(t, `{"a": 1, "b": {"c": [1], "d": true, "e": "x"}, "f": "y"}`), result)
+	assert.Equal(t, []Change{{"/n", Removed, nil, nil}}, changes)
+
+	// nothing is shared
+	result, changes = MergeWithLog(base, override)
+	changes[1].Old.(JsonArray)[0] = "changed"
+	changes[1].New.(JsonArray)[0] = "changed"
+	assert.Equal(t, JsonArray{int64(1)}, base.(JsonMap)["b"].(JsonMap)["c"])
+	assert.Equal(t, JsonArray{int64(2)}, result.(JsonMap)["b"].(JsonMap)["c"])
+}
+
+func TestMergeArraysByKey(t *testing.T) {
+	base := MustParse(t, `[{"name": "web", "port": 80, "env": {"A": "1"}}, {"name": "db", "port": 5432}, {"name": 1}]`).(JsonArray)
+	override := MustParse(t, `[{"name": "db", "port": 5433}, {"name": "cache"}, {"name": "web", "env": {"B": "2"}}, {"name": 1.0, "x": null}]`).(JsonArray)
+	result, err := MergeArraysByKey(base, override, "name")
+	assert.NoError(t, err)
+	assert.Equal(t, MustParse(t, `[
+		{"name": "web", "port": 80, "env": {"A": "1", "B": "2"}},
+		{"name": "db", "port": 5433},
+		{"name": 1.0},
+		{"name": "cache"}
+	]`), result)
+	assert.Equal(t, "web", base[0].(JsonMap)["name"])
+	assert.Equal(t, JsonMap{"A": "1"}, base[0].(JsonMap)["env"])
+
+	// elements without the key
+	unkeyed := MustParse(t, `[{"name": "a"}, 1, {"other": true}]`).(JsonArray)
+	_, err = MergeArraysByKey(unkeyed, nil, "name")
+	assert.Equal(t, &MergeError{"/base/1", `expect object with "name"`}, err)
+	_, err = MergeArraysByKey(nil, unkeyed, "name")
+	assert.Equal(t, &MergeError{"/override/1", `expect object with "name"`}, err)
+	result, err = MergeOptions{KeepUnkeyed: true}.MergeArraysByKey(unkeyed, MustParse(t, `[{"x": 1}, {"name": "a", "v": 2}]`).(JsonArray), "name")
+	assert.NoError(t, err)
+	assert.Equal(t, MustParse(t, `[{"name": "a", "v": 2}, 1, {"other": true}, {"x": 1}]`), result)
+
+	// bad keys
+	_, err = MergeArraysByKey(MustParse(t, `[{"name": "a"}, {"name": "a"}]`).(JsonArray), nil, "name")
+	assert.Equal(t, &MergeError{"/base/1", `duplicate "name": "a"`}, err)
+	_, err = MergeArraysByKey(nil, MustParse(t, `[{"name": 2}, {"name": 2.0}]`).(JsonArray), "name")
+	assert.Equal(t, &MergeError{"/override/1", `duplicate "name": 2`}, err)
+	_, err = MergeArraysByKey(nil, JsonArray{JsonMap{"name": 1.5}, JsonMap{"name": Decimal{"15.0e-1"}}}, "name")
+	assert.Equal(t, &MergeError{"/override/1", `duplicate "name": 15e-1`}, err)
+
+	// integers above 2^53 are distinct keys
+	ids := MustParse(t, `[{"id": 9007199254740992, "v": 1}, {"id": 9007199254740993, "v": 2}]`).(JsonArray)
+	result, err = MergeArraysByKey(ids, MustParse(t, `[{"id": 9007199254740993, "v": 3}]`).(JsonArray), "id")
+	assert.NoError(t, err)
+	assert.Equal(t, MustParse(t, `[{"id": 9007199254740992, "v": 1}, {"id": 9007199254740993, "v": 3}]`), result)
+
+	_, err = MergeArraysByKey(nil, MustParse(t, `[{"name": {}}]`).(JsonArray), "name")
+	assert.Equal(t, &MergeError{"/override/0/name", `expect scalar key, got object`}, err)
+}
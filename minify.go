@@ -0,0 +1,76 @@
+package json_go
+
+import (
+	"bytes"
+	"io"
+)
+
+type minifyWriter struct {
+	w        io.Writer
+	pos      int // runes written
+	inString bool
+	escaped  bool
+	start    int // position of the open quote
+	buf      []byte
+}
+
+// NewMinifyWriter returns a writer that forwards the JSON written to it to w without
+// the whitespace between tokens. The input is not validated otherwise, only enough state
+// is kept to leave strings intact, even when they are split across Write calls.
+// Close reports an unterminated string, it does not close w.
+func NewMinifyWriter(w io.Writer) io.WriteCloser {
+	return &minifyWriter{w: w}
+}
+
+func (m *minifyWriter) Write(p []byte) (n int, err error) {
+	m.buf = m.buf[:0]
+	for _, b := range p {
+		if b&0xc0 != 0x80 { // not a UTF-8 continuation byte
+			m.pos++
+		}
+
+		switch {
+		case m.inString:
+			if m.escaped {
+				m.escaped = false
+			} else if b == '\\' {
+				m.escaped = true
+			} else if b == '"' {
+				m.inString = false
+			}
+		case b == ' ' || b == '\t' || b == '\n' || b == '\r':
+			continue
+		case b == '"':
+			m.inString = true
+			m.start = m.pos - 1
+		}
+		m.buf = append(m.buf, b)
+	}
+
+	_, err = m.w.Write(m.buf)
+	if err != nil {
+		return
+	}
+	return len(p), nil
+}
+
+func (m *minifyWriter) Close() error {
+	if m.inString {
+		return &ParseError{m.start, "string not terminated"}
+	}
+	return nil
+}
+
+// Compact validates input and removes the whitespace between tokens,
+// keeping the original key order and number spelling.
+func Compact(input string) (output string, err error) {
+	_, err = Parse(input)
+	if err != nil {
+		return
+	}
+	buf := bytes.Buffer{}
+	w := NewMinifyWriter(&buf)
+	_, _ = w.Write([]byte(input))
+	err = w.Close()
+	return buf.String(), err
+}
@@ -0,0 +1,62 @@
+package json_go
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func minifyChunks(chunks ...string) (string, error) {
+	out := bytes.Buffer{}
+	w := NewMinifyWriter(&out)
+	for _, chunk := range chunks {
+		n, err := w.Write([]byte(chunk))
+		if err != nil {
+			return out.String(), err
+		}
+		if n != len(chunk) {
+			panic("short write")
+		}
+	}
+	return out.String(), w.Close()
+}
+
+func TestMinifyWriter(t *testing.T) {
+	input := " {\n\t\"a b\": [1, 2 ,\r\n\"x \\\" y\"],\n \"c\" : \"\\\\\" } "
+	expect := `{"a b":[1,2,"x \" y"],"c":"\\"}`
+
+	out, err := minifyChunks(input)
+	assert.NoError(t, err)
+	assert.Equal(t, expect, out)
+
+	// every possible split point, including inside escapes
+	for i := 0; i <= len(input); i++ {
+		out, err = minifyChunks(input[:i], input[i:])
+		assert.NoError(t, err)
+		assert.Equal(t, expect, out, "split at %d", i)
+	}
+
+	chunks := []string{}
+	for _, b := range []byte(input) {
+		chunks = append(chunks, string([]byte{b}))
+	}
+	out, err = minifyChunks(chunks...)
+	assert.NoError(t, err)
+	assert.Equal(t, expect, out)
+}
+
+func TestMinifyWriterUnterminated(t *testing.T) {
+	out, err := minifyChunks(`[ "啊", "a `, ` b\"`)
+	assert.Equal(t, `["啊","a  b\"`, out)
+	assert.Equal(t, &ParseError{7, "string not terminated"}, err)
+}
+
+func TestCompact(t *testing.T) {
+	output, err := Compact(" {\"b\" : [1.0, 2e3 ],\n \"a\": \"x y\" } ")
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"b":[1.0,2e3],"a":"x y"}`, output)
+	}
+	_, err = Compact(`{"a" 1}`)
+	assert.Error(t, err)
+}
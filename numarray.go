@@ -0,0 +1,93 @@
+package json_go
+
+import "unicode/utf8"
+
+// ParseFloatArray parses a JSON array of numbers straight into a []float64,
+// without boxing each element in a JsonValue. Integers are converted to float64.
+// An element that is not a number is an error.
+func ParseFloatArray(input string) ([]float64, error) {
+	return parseNumberArray(input, func(isInt bool, i int64, f float64) (float64, bool) {
+		if isInt {
+			return float64(i), true
+		}
+		return f, true
+	}, "expect number")
+}
+
+// ParseIntArray is ParseFloatArray for arrays of integers that fit an int64.
+// Numbers with a fraction or exponent are errors, even if whole like 1.0.
+func ParseIntArray(input string) ([]int64, error) {
+	return parseNumberArray(input, func(isInt bool, i int64, f float64) (int64, bool) {
+		return i, isInt
+	}, "expect int64")
+}
+
+func parseNumberArray[N int64 | float64](input string, convert func(isInt bool, i int64, f float64) (N, bool), expect string) (arr []N, err error) {
+	buf := []byte(input)
+	arr, err = scanNumberArray(buf, convert, expect)
+	if perr, ok := err.(*ParseError); ok {
+		// rune offsets like Parse
+		err = &ParseError{utf8.RuneCount(buf[:perr.pos]), perr.msg}
+	}
+	return
+}
+
+func scanNumberArray[N int64 | float64](input []byte, convert func(isInt bool, i int64, f float64) (N, bool), expect string) (arr []N, err error) {
+	next, err := consume(input, 0, "[")
+	if err != nil {
+		return
+	}
+	arr = []N{}
+	if end, suberr := consume(input, next, "]"); suberr == nil {
+		next = end
+	} else {
+		for {
+			next = skipSpace(input, next)
+			if next >= len(input) {
+				err = &ParseError{next, expect + ", got EOS"}
+				return
+			}
+			if !(input[next] == '-' || IsDigit(rune(input[next]))) {
+				err = &ParseError{next, expect}
+				return
+			}
+
+			start := next
+			var isInt bool
+			var i int64
+			var f float64
+			isInt, i, f, next, err = scanNumber(input, next)
+			if err != nil {
+				return
+			}
+			n, ok := convert(isInt, i, f)
+			if !ok {
+				err = &ParseError{start, expect}
+				return
+			}
+			arr = append(arr, n)
+
+			var suberr error
+			next, suberr = consume(input, next, ",")
+			if suberr == nil {
+				continue
+			}
+			next, suberr = consume(input, next, "]")
+			if suberr != nil {
+				err = &ParseError{next, "expect ']' or ','"}
+				return
+			}
+			break
+		}
+	}
+
+	next = skipSpace(input, next)
+	if next != len(input) {
+		var ch rune
+		ch, _, err = decodeChar(input, next)
+		if err == nil {
+			err = trailingError(next, ch)
+		}
+	}
+	return
+}
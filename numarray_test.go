@@ -0,0 +1,82 @@
+package json_go
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFloatArray(t *testing.T) {
+	arr, err := ParseFloatArray(" [1.5, -2, 3e2,0 ] ")
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1.5, -2, 300, 0}, arr)
+
+	arr, err = ParseFloatArray("[ ]")
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{}, arr)
+
+	for _, c := range []struct {
+		input string
+		pos   int
+	}{
+		{``, 0},
+		{`{}`, 0},
+		{`[1, "2"]`, 4},
+		{`[1, null]`, 4},
+		{`["é", 1]`, 1},
+		{`[1, 2`, 5},
+		{`[1, 2,`, 6},
+		{`[1,]`, 3},
+		{`[1 2]`, 3},
+		{`[1.]`, 3},
+		{`[1e999]`, 1},
+		{`["é"]`, 1},
+		{`[1] 2`, 4},
+		{`[é, 1]`, 1},
+	} {
+		_, err = ParseFloatArray(c.input)
+		if assert.IsType(t, &ParseError{}, err, c.input) {
+			assert.Equal(t, c.pos, err.(*ParseError).Pos(), c.input)
+		}
+	}
+
+	// positions are rune offsets
+	_, err = ParseFloatArray("[1,\t2] é")
+	assert.Equal(t, 7, err.(*ParseError).Pos())
+}
+
+func TestParseIntArray(t *testing.T) {
+	arr, err := ParseIntArray("[1, -2, 9223372036854775807]")
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, -2, 9223372036854775807}, arr)
+
+	for _, input := range []string{`[1.0]`, `[1e2]`, `[9223372036854775808]`, `[1, true]`} {
+		_, err = ParseIntArray(input)
+		assert.Error(t, err, input)
+	}
+	_, err = ParseIntArray(`[1, 2.5]`)
+	assert.Equal(t, &ParseError{4, "expect int64"}, err)
+}
+
+func BenchmarkParseFloatArray(b *testing.B) {
+	items := make([]string, 10000)
+	for i := range items {
+		items[i] = strconv.FormatFloat(float64(i)*1.25, 'f', -1, 64)
+	}
+	input := "[" + strings.Join(items, ",") + "]"
+
+	b.Run("Parse", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = Parse(input)
+		}
+	})
+	b.Run("ParseFloatArray", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = ParseFloatArray(input)
+		}
+	})
+}
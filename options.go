@@ -0,0 +1,153 @@
+package json_go
+
+// Options for Parse. The zero value is the default RFC 8259 behavior.
+type Options struct {
+	// reject a bare scalar like `42` as the whole document, as RFC 4627 did
+	TopLevelMustBeObjectOrArray bool
+	// also accept the capitalized and upper case spellings of literals:
+	// True, TRUE, False, FALSE, Null, NULL. Other mixed cases are still rejected.
+	CaseInsensitiveLiterals bool
+	// apply Unicode NFC normalization to every string and key, so that canonically
+	// equivalent strings like "e\u0301" and "\u00e9" compare equal.
+	// Off by default to keep the exact code points from the input.
+	NormalizeNFC bool
+	// parse every number as a Decimal with the exact digits of the input, instead of int64 or float64
+	UseDecimal bool
+	// parse a number as a Decimal only when it wouldn't round trip: an int64 or float64 is kept when
+	// its shortest round-trip form has the same decimal value as the input, and a Decimal is returned
+	// otherwise. An integer that fits is an int64, and other numbers are checked as a float64, so
+	// 0.1 and 1.50 are float64s while 0.30000000000000001, 9007199254740993.0, 123456789012345678901
+	// and numbers out of range are Decimals. UseDecimal takes precedence.
+	LazyBigNumber bool
+	// parse floats without a fractional part, like 2.0 or 1e3, as int64 when they fit
+	CoerceWholeFloatsToInt bool
+	// lenient: accept an unquoted word as a string value, like {"status": ok}.
+	// A bare word starts with an ASCII letter or '_', followed by ASCII letters, digits, '_', '-' or '.'.
+	// Words spelling true, false or null are still those literals. Keys must be quoted.
+	AllowBareWords bool
+	// abort once the document has more tokens than this, 0 for no limit.
+	// Every scalar, key, ',' and ':' is a token, and an array or object counts 2 for its brackets,
+	// so the budget bounds the total work whatever the nesting or the length of the input.
+	MaxTokens int
+	// lenient: also accept the escapes \xNN for the code point of two hex digits and \0 for NUL in strings,
+	// as some legacy producers write them
+	AllowLegacyEscapes bool
+	// share one string between equal object keys during a parse, to save memory on arrays of records.
+	// Values are not interned.
+	InternKeys bool
+	// what to do about a key repeated in the same object, see DuplicateKeyMode
+	DuplicateKeys DuplicateKeyMode
+	// parse objects as SortedMap instead of JsonMap, which takes less memory for many small objects.
+	// ParseOrdered and ParseTrivia fail with ErrSortedMapsOrder, as there is no input order to keep
+	SortedMaps bool
+	// reject a number with more digits before the decimal point than this, 0 for no limit
+	MaxIntegerDigits int
+	// reject a number with an exponent larger than this either way, like 1e1000000, 0 for no limit.
+	// Leading zeros of the exponent don't count, and neither limit looks at the digits after the point.
+	MaxExponent int
+	// keep the values at these places as RawValue with their input text, instead of parsing them
+	// into a tree. An entry starting with '/' is a JSON Pointer, where a `*` token matches any key or index,
+	// "" is the whole document, and any other entry is a key matching members of that name at any depth.
+	// A raw value is still checked to be valid JSON.
+	RawPaths []string
+	// lenient: accept line comments from "//" to the end of the line outside of strings, as in JSONC.
+	// With ParseTrivia, a comment on its own line right before an element, a member or the document
+	// is kept in the Trivia and written back by MarshalTrivia. Other comments are skipped.
+	PreserveComments bool
+}
+
+type DuplicateKeyMode int
+
+const (
+	// the value of the last occurrence is kept
+	LastWins DuplicateKeyMode = iota
+	// a repeated key is a ParseError at the second occurrence
+	Error
+	// the value of the first occurrence is kept
+	FirstWins
+	// a repeated key maps to a JsonArray of all its values in input order, like HTTP headers.
+	// The wrapping only happens for repeated keys: a key that appears once keeps its value as is,
+	// even if that value is an array itself, so {"a": [1], "a": 2} gives {"a": [[1], 2]}.
+	Collect
+)
+
+// Option sets one field of Options, for ParseWith and NewOptions.
+type Option func(opts *Options)
+
+// NewOptions applies opts to the default Options, for entry points like Options.ParseBytes or Options.ParseContext.
+func NewOptions(opts ...Option) (result Options) {
+	for _, opt := range opts {
+		opt(&result)
+	}
+	return
+}
+
+// ParseWith is Parse with the options applied in order, like
+// ParseWith(input, WithMaxTokens(1000), WithDuplicateKeys(Error)).
+func ParseWith(input string, opts ...Option) (value JsonValue, err error) {
+	return NewOptions(opts...).Parse(input)
+}
+
+func WithTopLevelMustBeObjectOrArray() Option {
+	return func(opts *Options) { opts.TopLevelMustBeObjectOrArray = true }
+}
+
+func WithCaseInsensitiveLiterals() Option {
+	return func(opts *Options) { opts.CaseInsensitiveLiterals = true }
+}
+
+func WithNormalizeNFC() Option {
+	return func(opts *Options) { opts.NormalizeNFC = true }
+}
+
+func WithUseDecimal() Option {
+	return func(opts *Options) { opts.UseDecimal = true }
+}
+
+func WithLazyBigNumber() Option {
+	return func(opts *Options) { opts.LazyBigNumber = true }
+}
+
+func WithCoerceWholeFloatsToInt() Option {
+	return func(opts *Options) { opts.CoerceWholeFloatsToInt = true }
+}
+
+func WithAllowBareWords() Option {
+	return func(opts *Options) { opts.AllowBareWords = true }
+}
+
+func WithAllowLegacyEscapes() Option {
+	return func(opts *Options) { opts.AllowLegacyEscapes = true }
+}
+
+func WithMaxTokens(n int) Option {
+	return func(opts *Options) { opts.MaxTokens = n }
+}
+
+func WithInternKeys() Option {
+	return func(opts *Options) { opts.InternKeys = true }
+}
+
+func WithDuplicateKeys(mode DuplicateKeyMode) Option {
+	return func(opts *Options) { opts.DuplicateKeys = mode }
+}
+
+func WithSortedMaps() Option {
+	return func(opts *Options) { opts.SortedMaps = true }
+}
+
+func WithMaxIntegerDigits(n int) Option {
+	return func(opts *Options) { opts.MaxIntegerDigits = n }
+}
+
+func WithMaxExponent(n int) Option {
+	return func(opts *Options) { opts.MaxExponent = n }
+}
+
+func WithPreserveComments() Option {
+	return func(opts *Options) { opts.PreserveComments = true }
+}
+
+func WithRawPaths(paths ...string) Option {
+	return func(opts *Options) { opts.RawPaths = append(opts.RawPaths, paths...) }
+}
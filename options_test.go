@@ -0,0 +1,344 @@
+package json_go
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func GoodWith(t *testing.T, opts Options, input string, expect JsonValue) {
+	got, err := opts.Parse(input)
+	if assert.NoError(t, err, input) {
+		assert.Equal(t, expect, got)
+	}
+	got, err = opts.Parse(" " + input + " ")
+	if assert.NoError(t, err, input) {
+		assert.Equal(t, expect, got)
+	}
+}
+
+func BadWith(t *testing.T, opts Options, input string) {
+	_, err := opts.Parse(input)
+	assert.Error(t, err, input)
+	t.Log(input, "\t", err)
+}
+
+func TestTopLevelMustBeObjectOrArray(t *testing.T) {
+	opts := Options{TopLevelMustBeObjectOrArray: true}
+	good := func(input string, expect JsonValue) { GoodWith(t, opts, input, expect) }
+	bad := func(input string) { BadWith(t, opts, input) }
+
+	good("[]", JsonArray{})
+	good(`{"a": 1}`, JsonMap{"a": int64(1)})
+	good(`[1, "a"]`, JsonArray{int64(1), "a"})
+
+	bad("")
+	bad("42")
+	bad(` "hi"`)
+	bad("null")
+
+	_, err := opts.Parse(" true")
+	assert.Equal(t, &ParseError{1, "top level must be object or array"}, err)
+
+	// default stays permissive
+	Good(t, "42", int64(42))
+	Good(t, `"hi"`, "hi")
+}
+
+func TestCaseInsensitiveLiterals(t *testing.T) {
+	opts := Options{CaseInsensitiveLiterals: true}
+	good := func(input string, expect JsonValue) { GoodWith(t, opts, input, expect) }
+	bad := func(input string) { BadWith(t, opts, input) }
+
+	for _, input := range []string{"true", "True", "TRUE"} {
+		good(input, true)
+	}
+	for _, input := range []string{"false", "False", "FALSE"} {
+		good(input, false)
+	}
+	for _, input := range []string{"null", "Null", "NULL"} {
+		good(input, nil)
+	}
+	good(`[True, {"a": NULL}]`, JsonArray{true, JsonMap{"a": nil}})
+
+	bad("tRUE")
+	bad("TRue")
+	bad("nULL")
+	bad("Nul")
+	bad("Truee")
+
+	// where the longest spelling, "TRUE", stops matching
+	_, err := opts.Parse("[1, TRue]")
+	assert.Equal(t, &ParseError{6, "expect true|false|null"}, err)
+
+	// strict by default
+	Bad(t, "True")
+	Bad(t, "FALSE")
+	Bad(t, "[NULL]")
+}
+
+func TestNormalizeNFC(t *testing.T) {
+	opts := Options{NormalizeNFC: true}
+	good := func(input string, expect JsonValue) { GoodWith(t, opts, input, expect) }
+
+	good(`"e\u0301"`, "\u00e9")
+	good("\"e\u0301\"", "\u00e9")
+	good(`"\u00e9"`, "\u00e9")
+	good(`{"cafe\u0301": ["\u1100\u1161"]}`, JsonMap{"caf\u00e9": JsonArray{"\uac00"}})
+
+	a, _ := opts.Parse(`["e\u0301"]`)
+	b, _ := opts.Parse(`["\u00e9"]`)
+	assert.True(t, Equal(a, b))
+
+	// exact code points by default
+	Good(t, `"e\u0301"`, "e\u0301")
+}
+
+func TestCoerceWholeFloatsToInt(t *testing.T) {
+	opts := Options{CoerceWholeFloatsToInt: true}
+	good := func(input string, expect JsonValue) { GoodWith(t, opts, input, expect) }
+
+	good("2.0", int64(2))
+	good("-3.000", int64(-3))
+	good("1e3", int64(1000))
+	good("-0.0", int64(0))
+	good("[2, 2.0, 2.5]", JsonArray{int64(2), int64(2), 2.5})
+	good(`{"a": 1.5e1}`, JsonMap{"a": int64(15)})
+	good("1e19", 1e19)
+	good("1e18", int64(1e18))
+
+	// source fidelity by default
+	Good(t, "2.0", 2.0)
+}
+
+func TestAllowBareWords(t *testing.T) {
+	opts := Options{AllowBareWords: true}
+	good := func(input string, expect JsonValue) { GoodWith(t, opts, input, expect) }
+	bad := func(input string) { BadWith(t, opts, input) }
+
+	good(`{"status": ok}`, JsonMap{"status": "ok"})
+	good(`[a, _b1, x-y.z, A]`, JsonArray{"a", "_b1", "x-y.z", "A"})
+	good(`[true, false, null]`, JsonArray{true, false, nil})
+	good(`[trueish, nullable, True]`, JsonArray{"trueish", "nullable", "True"})
+	good(`ok`, "ok")
+
+	bad(`{status: ok}`)
+	bad(`[ok ok]`)
+	bad(`[-x]`)
+	bad(`[1a]`)
+	bad(`[o@k]`)
+
+	_, err := opts.Parse(`[ok, o k]`)
+	assert.Equal(t, &ParseError{7, "expect ']' or ','"}, err)
+
+	// strict by default
+	Bad(t, `{"status": ok}`)
+}
+
+func TestMaxTokens(t *testing.T) {
+	limit := func(n int, input string, ok bool) {
+		_, err := Options{MaxTokens: n}.Parse(input)
+		if ok {
+			assert.NoError(t, err, "%d %s", n, input)
+		} else {
+			assert.Error(t, err, "%d %s", n, input)
+		}
+	}
+
+	limit(1, `1`, true)
+	limit(1, `[]`, false)
+	limit(2, `[]`, true)
+	limit(5, `[1,2]`, true) // [ 1 , 2 ]
+	limit(4, `[1,2]`, false)
+	limit(6, `{"a":1}`, true)
+	limit(5, `{"a":1}`, true) // { "a" : 1 }
+	limit(4, `{"a":1}`, false)
+	limit(10, `{"a":[],"b":{}}`, false)
+	limit(11, `{"a":[],"b":{}}`, true)
+
+	_, err := Options{MaxTokens: 4}.Parse(`[1, 2, 3]`)
+	assert.Equal(t, &ParseError{4, "more than 4 tokens"}, err)
+
+	// unlimited by default
+	_, err = Parse("[" + strings.Repeat("0,", 10000) + "0]")
+	assert.NoError(t, err)
+}
+
+func TestDuplicateKeys(t *testing.T) {
+	parse := func(mode DuplicateKeyMode, input string) JsonValue {
+		value, err := Options{DuplicateKeys: mode}.Parse(input)
+		assert.NoError(t, err, input)
+		return value
+	}
+
+	two := `{"a": 1, "b": 0, "a": 2}`
+	three := `{"a": 1, "a": 2, "b": 0, "a": 3}`
+
+	assert.Equal(t, JsonMap{"a": int64(2), "b": int64(0)}, parse(LastWins, two))
+	assert.Equal(t, JsonMap{"a": int64(3), "b": int64(0)}, parse(LastWins, three))
+
+	assert.Equal(t, JsonMap{"a": int64(1), "b": int64(0)}, parse(FirstWins, two))
+	assert.Equal(t, JsonMap{"a": int64(1), "b": int64(0)}, parse(FirstWins, three))
+
+	assert.Equal(t, JsonMap{"a": JsonArray{int64(1), int64(2)}, "b": int64(0)}, parse(Collect, two))
+	assert.Equal(t, JsonMap{"a": JsonArray{int64(1), int64(2), int64(3)}, "b": int64(0)}, parse(Collect, three))
+	// only repeated keys are wrapped
+	assert.Equal(t, JsonMap{"a": JsonArray{JsonArray{int64(1)}, int64(2)}, "b": JsonArray{int64(3)}},
+		parse(Collect, `{"a": [1], "b": [3], "a": 2}`))
+	assert.Equal(t, JsonMap{"a": JsonArray{JsonMap{"a": JsonArray{nil, nil}}, JsonMap{}}},
+		parse(Collect, `{"a": {"a": null, "a": null}, "a": {}}`))
+
+	_, err := Options{DuplicateKeys: Error}.Parse(two)
+	assert.Equal(t, &ParseError{17, `duplicate key "a"`}, err)
+	_, err = Options{DuplicateKeys: Error}.Parse(three)
+	assert.Equal(t, &ParseError{9, `duplicate key "a"`}, err)
+	assert.Equal(t, JsonMap{"a": JsonMap{"a": int64(1)}}, parse(Error, `{"a": {"a": 1}}`))
+}
+
+func TestInternKeys(t *testing.T) {
+	// the string stored as a map key, not the one used to look it up
+	keyData := func(value JsonValue, key string) uintptr {
+		for k := range value.(JsonMap) {
+			if k == key {
+				return uintptr(unsafe.Pointer(unsafe.StringData(k)))
+			}
+		}
+		return 0
+	}
+
+	input := `[{"id": 1, "name": "id"}, {"id": 2, "name": {"id": null}}, {"\u00e9": 3}, {"\u00e9": 4}]`
+	value, err := Options{InternKeys: true}.Parse(input)
+	assert.NoError(t, err)
+	assert.Equal(t, MustParse(t, input), value)
+
+	arr := value.(JsonArray)
+	id := keyData(arr[0], "id")
+	assert.Equal(t, id, keyData(arr[1], "id"))
+	assert.Equal(t, id, keyData(arr[1].(JsonMap)["name"], "id"))
+	assert.Equal(t, keyData(arr[0], "name"), keyData(arr[1], "name"))
+	assert.Equal(t, keyData(arr[2], "\u00e9"), keyData(arr[3], "\u00e9"))
+	// values are not interned
+	assert.NotEqual(t, id, uintptr(unsafe.Pointer(unsafe.StringData(arr[0].(JsonMap)["name"].(string)))))
+
+	// a fresh table for each parse
+	again, _ := Options{InternKeys: true}.Parse(input)
+	assert.NotEqual(t, id, keyData(again.(JsonArray)[0], "id"))
+
+	value, err = Options{InternKeys: true, NormalizeNFC: true}.Parse("[{\"e\u0301\": 1}, {\"\u00e9\": 2}, {\"e\u0301\": 3}]")
+	assert.NoError(t, err)
+	assert.Equal(t, JsonArray{JsonMap{"\u00e9": int64(1)}, JsonMap{"\u00e9": int64(2)}, JsonMap{"\u00e9": int64(3)}}, value)
+
+	_, err = Options{InternKeys: true}.Parse(`[{"a": 1}, {"a`)
+	assert.Error(t, err)
+}
+
+func BenchmarkInternKeys(b *testing.B) {
+	item := `{"id": 12345, "name": "some name", "created_at": "2020-01-01", "tags": [], "active": true}`
+	input := "[" + strings.Repeat(item+",", 9999) + item + "]"
+	for _, opts := range []Options{{}, {InternKeys: true}} {
+		b.Run(fmt.Sprintf("InternKeys=%v", opts.InternKeys), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, err := opts.Parse(input)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseWith(t *testing.T) {
+	value, err := ParseWith(`{"a": ok, "a": True}`, WithAllowBareWords(), WithCaseInsensitiveLiterals(), WithDuplicateKeys(Collect))
+	assert.NoError(t, err)
+	assert.Equal(t, JsonMap{"a": JsonArray{"ok", true}}, value)
+
+	// later options win
+	_, err = ParseWith(`[1, 2]`, WithMaxTokens(1), WithMaxTokens(0))
+	assert.NoError(t, err)
+	_, err = ParseWith(`1`, WithTopLevelMustBeObjectOrArray())
+	assert.Error(t, err)
+
+	// no options is Parse
+	value, err = ParseWith(`[2.0]`)
+	assert.NoError(t, err)
+	assert.Equal(t, JsonArray{2.0}, value)
+
+	assert.Equal(t, Options{}, NewOptions())
+	assert.Equal(t,
+		Options{TopLevelMustBeObjectOrArray: true, CaseInsensitiveLiterals: true, NormalizeNFC: true, CoerceWholeFloatsToInt: true,
+			AllowBareWords: true, MaxTokens: 10, InternKeys: true, DuplicateKeys: FirstWins},
+		NewOptions(WithTopLevelMustBeObjectOrArray(), WithCaseInsensitiveLiterals(), WithNormalizeNFC(), WithCoerceWholeFloatsToInt(),
+			WithAllowBareWords(), WithMaxTokens(10), WithInternKeys(), WithDuplicateKeys(FirstWins)))
+
+	value, err = NewOptions(WithCoerceWholeFloatsToInt()).ParseBytes([]byte(`[2.0]`))
+	assert.NoError(t, err)
+	assert.Equal(t, JsonArray{int64(2)}, value)
+}
+
+func TestOptionsParseAny(t *testing.T) {
+	input := []rune(`{"a": 1, "a": 2} rest`)
+	value, next, err := NewOptions(WithDuplicateKeys(FirstWins)).ParseAny(input, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, JsonMap{"a": int64(1)}, value)
+	assert.Equal(t, 16, next)
+
+	_, _, err = NewOptions(WithDuplicateKeys(Error)).ParseAny(input, 0)
+	assert.Equal(t, &ParseError{9, `duplicate key "a"`}, err)
+}
+
+func TestAllowLegacyEscapes(t *testing.T) {
+	legacy := Options{AllowLegacyEscapes: true}
+	GoodWith(t, legacy, `"a\x41\x00\xe9\0b"`, "aA\x00é\x00b")
+	GoodWith(t, legacy, `{"\x6b": "A\n"}`, JsonMap{"k": "A\n"})
+
+	_, err := legacy.Parse(`"\x4"`)
+	assert.Equal(t, &ParseError{4, "expect hex, got '\"' (0x22)"}, err)
+	_, err = legacy.Parse(`"\x`)
+	assert.Equal(t, &ParseError{3, "expect 2 hex digit"}, err)
+	_, err = legacy.Parse(`"\xg0"`)
+	assert.Equal(t, &ParseError{3, "expect hex, got 'g' (0x67)"}, err)
+	_, err = legacy.Parse(`"\q"`)
+	assert.Equal(t, &ParseError{2, "bad escape char: 'q' (0x71)"}, err)
+
+	// rejected by default, like any unknown escape
+	_, err = Parse(`"a\x41"`)
+	assert.Equal(t, &ParseError{3, "bad escape char: 'x' (0x78)"}, err)
+	_, err = Parse(`"\0"`)
+	assert.Equal(t, &ParseError{2, "bad escape char: '0' (0x30)"}, err)
+	_, _, err = ParseString([]rune(`"\0"`), 0)
+	assert.Equal(t, &ParseError{2, "bad escape char: '0' (0x30)"}, err)
+}
+
+func TestNumberSizeLimits(t *testing.T) {
+	digits := NewOptions(WithMaxIntegerDigits(3))
+	for _, input := range []string{`123`, `-123`, `123.4567`, `0.12345`, `123e10`, `[1, 999]`} {
+		_, err := digits.Parse(input)
+		assert.NoError(t, err, input)
+	}
+	_, err := digits.Parse(`1234`)
+	assert.Equal(t, &ParseError{3, "more than 3 integer digits"}, err)
+	_, err = digits.Parse(`[1, -1234.5]`)
+	assert.Equal(t, &ParseError{8, "more than 3 integer digits"}, err)
+
+	exp := NewOptions(WithMaxExponent(308))
+	for _, input := range []string{`1e308`, `1E-308`, `1e+0000308`, `1.5e3`, `12345678901234567890`} {
+		_, err := exp.Parse(input)
+		assert.NoError(t, err, input)
+	}
+	for _, input := range []string{`1e309`, `1E-309`, `1e+0000309`, `1e1000000`, `1e99999999999999999999999`} {
+		_, err := exp.Parse(input)
+		assert.Equal(t, &ParseError{1, "exponent larger than 308"}, err, input)
+	}
+	_, err = NewOptions(WithMaxExponent(5), WithUseDecimal()).Parse(`[0.5e6]`)
+	assert.Equal(t, &ParseError{4, "exponent larger than 5"}, err)
+
+	// malformed numbers fail as usual
+	_, err = exp.Parse(`1e`)
+	assert.Equal(t, &ParseError{2, "expect digits"}, err)
+	_, err = digits.Parse(`-`)
+	assert.Equal(t, &ParseError{1, "expects digits, got EOS"}, err)
+}
@@ -0,0 +1,83 @@
+package json_go
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+)
+
+// ErrSortedMapsOrder is from ParseOrdered and ParseTrivia with Options.SortedMaps:
+// the order is keyed by JsonMap, and a SortedMap has its members sorted anyway.
+var ErrSortedMapsOrder = errors.New("SortedMaps don't keep the member order")
+
+// ObjectOrder is a side table of the member order of the objects of a parsed tree,
+// keyed by the identity of each JsonMap. It recovers the input order for output
+// while the tree keeps using plain JsonMap.
+//
+// The order belongs to the map values themselves: copies of a map, like the ones made by Map,
+// have no recorded order, and the table keeps the maps it knows alive.
+// Members added after parsing come after the recorded ones in sorted order,
+// and members deleted are skipped.
+type ObjectOrder struct {
+	keys map[uintptr][]string
+	maps []JsonMap // keeps the keyed maps alive, so their addresses are not reused
+}
+
+func ParseOrdered(input string) (value JsonValue, order *ObjectOrder, err error) {
+	return Options{}.ParseOrdered(input)
+}
+
+// ParseOrdered is Parse that also records the member order of every object.
+// For duplicated keys, the position of the first one is kept.
+func (opts Options) ParseOrdered(input string) (value JsonValue, order *ObjectOrder, err error) {
+	if opts.SortedMaps {
+		return nil, nil, ErrSortedMapsOrder
+	}
+	var decoded []rune
+	decoded, err = DecodeString(input)
+	if err != nil {
+		return
+	}
+	order = &ObjectOrder{keys: map[uintptr][]string{}}
+	p := parser[rune]{opts: opts, order: order}
+	value, err = p.parseDocument(decoded)
+	return
+}
+
+func (order *ObjectOrder) record(obj JsonMap, keys []string) {
+	order.keys[reflect.ValueOf(obj).Pointer()] = keys
+	order.maps = append(order.maps, obj)
+}
+
+// Keys returns the keys of obj in the recorded order, or sorted if obj was not recorded.
+func (order *ObjectOrder) Keys(obj JsonMap) []string {
+	recorded := order.keys[reflect.ValueOf(obj).Pointer()]
+	keys := make([]string, 0, len(obj))
+	seen := make(map[string]bool, len(recorded))
+	for _, key := range recorded {
+		if _, ok := obj[key]; ok {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+	if len(keys) == len(obj) {
+		return keys
+	}
+
+	added := make([]string, 0, len(obj)-len(keys))
+	for key := range obj {
+		if !seen[key] {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	return append(keys, added...)
+}
+
+// MarshalOrdered is Marshal with the keys of each object in the order of order.Keys.
+func MarshalOrdered(value JsonValue, order *ObjectOrder) (output string, err error) {
+	m := marshaler{order: order}
+	err = m.marshal(value, "", 0)
+	output = string(m.buf)
+	return
+}
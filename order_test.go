@@ -0,0 +1,50 @@
+package json_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOrdered(t *testing.T) {
+	input := `{"z": 1, "a": {"y": [{"q": 1, "p": 2}], "x": {}}, "m": null, "z": 2}`
+	value, order, err := ParseOrdered(input)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, MustParse(t, input), value)
+
+	obj := value.(JsonMap)
+	assert.Equal(t, []string{"z", "a", "m"}, order.Keys(obj))
+	output, err := MarshalOrdered(value, order)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"z":2,"a":{"y":[{"q":1,"p":2}],"x":{}},"m":null}`, output)
+	}
+
+	// changed after parsing
+	delete(obj, "a")
+	obj["c"] = true
+	obj["b"] = false
+	assert.Equal(t, []string{"z", "m", "b", "c"}, order.Keys(obj))
+	// not recorded
+	assert.Equal(t, []string{"a", "b"}, order.Keys(JsonMap{"b": 1, "a": 2}))
+	output, err = MarshalOrdered(Map(value, func(path string, v JsonValue) JsonValue { return v }), order)
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"b":false,"c":true,"m":null,"z":2}`, output)
+	}
+
+	_, _, err = ParseOrdered(`{"a": 1,}`)
+	assert.Error(t, err)
+}
+
+func TestParseOrderedSortedMaps(t *testing.T) {
+	opts := NewOptions(WithSortedMaps())
+	value, order, err := opts.ParseOrdered(`{"b": 1, "a": 2}`)
+	assert.Equal(t, ErrSortedMapsOrder, err)
+	assert.Nil(t, value)
+	assert.Nil(t, order)
+	value, trivia, err := opts.ParseTrivia(`{"b": 1, "a": 2}`)
+	assert.Equal(t, ErrSortedMapsOrder, err)
+	assert.Nil(t, value)
+	assert.Nil(t, trivia)
+}
@@ -1,11 +1,18 @@
 package json_go
 
 import (
+	"context"
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
 )
 
-type JsonValue interface{} // float64, int64, bool, nil, JsonMap, JsonArray
+type JsonValue interface{} // float64, int64, bool, nil, JsonMap, JsonArray, or Decimal with UseDecimal, SortedMap with SortedMaps and RawValue with RawPaths
 type JsonMap map[string]JsonValue
 type JsonArray []JsonValue
 
@@ -14,7 +21,24 @@ type JsonKeyValue struct {
 	value JsonValue
 }
 
+// NewKeyValue makes an object member. A []JsonKeyValue marshals as an object with the members in slice order.
+func NewKeyValue(key string, value JsonValue) JsonKeyValue {
+	return JsonKeyValue{key, value}
+}
+
+func (kv JsonKeyValue) Key() string {
+	return kv.key
+}
+
+func (kv JsonKeyValue) Value() JsonValue {
+	return kv.value
+}
+
 func SkipSpace(input []rune, cur int) int {
+	return skipSpace(input, cur)
+}
+
+func skipSpace[T char](input []T, cur int) int {
 	for i := cur; i < len(input); i++ {
 		switch input[i] {
 		case ' ', '\t', '\n', '\r':
@@ -25,21 +49,23 @@ func SkipSpace(input []rune, cur int) int {
 	return len(input)
 }
 
+// Consume matches tok after skipping spaces. On failure, next is still the position after the spaces,
+// while the error points at the first rune of tok that is mismatched or missing at the end of input.
 func Consume(input []rune, cur int, tok string) (next int, err error) {
-	next = SkipSpace(input, cur)
-	tokrune := []rune(tok)
-	if len(input)-next < len(tokrune) {
-		err = &ParseError{next, fmt.Sprintf("expect %q", tok)}
-		return
-	}
+	return consume(input, cur, tok)
+}
 
-	for i, ch := range tokrune {
-		if input[next+i] != ch {
+func consume[T char](input []T, cur int, tok string) (next int, err error) {
+	next = skipSpace(input, cur)
+	i := 0
+	for _, ch := range tok {
+		if next+i >= len(input) || rune(input[next+i]) != ch {
 			err = &ParseError{next + i, fmt.Sprintf("expect %q", tok)}
 			return
 		}
+		i++
 	}
-	next += len(tokrune)
+	next += i
 	return
 }
 
@@ -52,48 +78,511 @@ func (err *ParseError) Error() string {
 	return fmt.Sprintf("ParseError at %d: %s", err.pos, err.msg)
 }
 
+// ErrEmptyInput is the ParseError for a document with nothing but whitespace, after an optional BOM,
+// to tell a missing body from a malformed one. Compare with == or errors.Is.
+var ErrEmptyInput error = &ParseError{0, "empty input"}
+
+// Pos is the rune offset of the error, see PositionMap for line and column.
+func (err *ParseError) Pos() int {
+	return err.pos
+}
+
 func Parse(input string) (value JsonValue, err error) {
+	return Options{}.Parse(input)
+}
+
+func ParseRunes(input []rune) (value JsonValue, err error) {
+	return Options{}.ParseRunes(input)
+}
+
+func (opts Options) Parse(input string) (value JsonValue, err error) {
 	var decoded []rune
 	decoded, err = DecodeString(input)
 	if err != nil {
 		return
 	}
-	return ParseRunes(decoded)
+	return opts.ParseRunes(decoded)
 }
 
-func ParseRunes(input []rune) (value JsonValue, err error) {
-	var next int
-	value, next, err = ParseAny(input, 0)
+func (opts Options) ParseRunes(input []rune) (value JsonValue, err error) {
+	p := parser[rune]{opts: opts}
+	return p.parseDocument(input)
+}
+
+func (p *parser[T]) parseDocument(input []T) (value JsonValue, err error) {
+	if p.opts.PreserveComments {
+		input, p.comments = stripLineComments(input)
+	}
+	start := 0
+	if len(input) > 0 {
+		if ch, size, _ := decodeChar(input, 0); ch == '\uFEFF' {
+			start = size // BOM
+		}
+	}
+	next := skipSpace(input, start)
+	if next == len(input) && !p.partial { // a cut off document may be empty
+		err = ErrEmptyInput
+		return
+	}
+	if p.trivia != nil {
+		p.recordBefore("", input, start, next)
+	}
+	if p.opts.TopLevelMustBeObjectOrArray && input[next] != '[' && input[next] != '{' {
+		err = &ParseError{next, "top level must be object or array"}
+		return
+	}
+
+	value, next, err = p.parseAny(input, start)
 
 	if err == nil {
-		next = SkipSpace(input, next)
+		next = skipSpace(input, next)
 		if next != len(input) {
-			err = &ParseError{next, "not terminated"}
+			var ch rune
+			ch, _, err = decodeChar(input, next)
+			if err == nil {
+				err = trailingError(next, ch)
+			}
 		}
 	}
 	return
 }
 
+// for content after a complete document, ch is its first rune
+func trailingError(pos int, ch rune) error {
+	switch {
+	case ch == '{' || ch == '[' || ch == '"' || ch == 't' || ch == 'f' || ch == 'n' || ch == '-' || IsDigit(ch):
+		return &ParseError{pos, fmt.Sprintf("extra value after the document, starting with '%c'", ch)}
+	default:
+		return &ParseError{pos, fmt.Sprintf("junk after the document: '%c' (%#x)", ch, ch)}
+	}
+}
+
+// the input of the parser, decoded runes or UTF-8 bytes
+type char interface {
+	rune | byte
+}
+
+// parser carries the options and the per-parse state through the recursive descent
+type parser[T char] struct {
+	opts   Options
+	ctx    context.Context // may be nil
+	values int             // values started so far
+	// for ParsePartial, close the open containers when the input ends early
+	partial    bool
+	incomplete bool
+	order      *ObjectOrder // for ParseOrdered
+	trivia     *Trivia      // for ParseTrivia
+	tokens     int          // counted for MaxTokens
+	// for InternKeys, by the UTF-8 of keys without escapes, and by the value otherwise
+	keys    map[string]string
+	scratch []byte
+	pairs   []JsonKeyValue // members of the open SortedMaps
+	// for PreserveComments, by position, blanked out of the input
+	comments []lineComment
+}
+
+// Parser parses with a fixed set of Options. State like the keys interned for InternKeys and limits
+// like MaxTokens apply to each call, so a long-lived Parser doesn't grow with the documents it has seen.
+// A Parser must not be used concurrently, but Parsers with different options can run side by side.
+type Parser struct {
+	p parser[rune]
+}
+
+func NewParser(opts Options) *Parser {
+	return &Parser{parser[rune]{opts: opts}}
+}
+
+func (ps *Parser) Options() Options {
+	return ps.p.opts
+}
+
+// the parser with the per-call state reset
+func (ps *Parser) begin() *parser[rune] {
+	ps.p.tokens = 0
+	ps.p.values = 0
+	ps.p.pairs = ps.p.pairs[:0] // left by a failed parse
+	clear(ps.p.keys)
+	return &ps.p
+}
+
+func (ps *Parser) Parse(input string) (value JsonValue, err error) {
+	var decoded []rune
+	decoded, err = DecodeString(input)
+	if err != nil {
+		return
+	}
+	return ps.ParseRunes(decoded)
+}
+
+func (ps *Parser) ParseRunes(input []rune) (value JsonValue, err error) {
+	return ps.begin().parseDocument(input)
+}
+
+func (ps *Parser) ParseAny(input []rune, cur int) (value JsonValue, next int, err error) {
+	return ps.begin().parseAny(input, cur)
+}
+
+func (ps *Parser) ParseArray(input []rune, cur int) (value JsonValue, next int, err error) {
+	return ps.begin().parseArray(input, cur)
+}
+
+func (ps *Parser) ParseMap(input []rune, cur int) (value JsonValue, next int, err error) {
+	return ps.begin().parseMap(input, cur)
+}
+
+func (ps *Parser) ParseString(input []rune, cur int) (value string, next int, err error) {
+	return ps.begin().parseString(input, cur)
+}
+
+func (ps *Parser) ParseNum(input []rune, cur int) (value JsonValue, next int, err error) {
+	return ps.begin().parseNum(input, cur)
+}
+
+func (p *parser[T]) countTokens(pos int, n int) (err error) {
+	p.tokens += n
+	if p.opts.MaxTokens > 0 && p.tokens > p.opts.MaxTokens {
+		err = &ParseError{pos, fmt.Sprintf("more than %d tokens", p.opts.MaxTokens)}
+	}
+	return
+}
+
 func ParseAny(input []rune, cur int) (value JsonValue, next int, err error) {
-	next = SkipSpace(input, cur)
+	return Options{}.ParseAny(input, cur)
+}
+
+// ParseAny parses the value at cur, leaving the rest of input to the caller.
+func (opts Options) ParseAny(input []rune, cur int) (value JsonValue, next int, err error) {
+	p := parser[rune]{opts: opts}
+	return p.parseAny(input, cur)
+}
+
+// parseAny keeps the open containers on an explicit stack instead of recursing,
+// so a deeply nested document can't overflow the goroutine stack.
+func (p *parser[T]) parseAny(input []T, cur int) (value JsonValue, next int, err error) {
+	var stack []parseFrame
+	next = cur
+	for {
+		var open bool
+		start := skipSpace(input, next)
+		if len(p.opts.RawPaths) > 0 && p.isRawPath(stack) {
+			value, next, err = p.parseRaw(input, next)
+		} else {
+			value, next, open, err = p.parseValueStart(input, next)
+		}
+		if err != nil {
+			if len(stack) > 0 {
+				value = nil
+			}
+			return p.recoverPartial(input, start, stack, value, next, err)
+		}
+		if open {
+			frame := parseFrame{closing: "]"}
+			if input[next-1] == '{' && p.opts.SortedMaps {
+				frame = parseFrame{pairs: &p.pairs, base: len(p.pairs), closing: "}"}
+			} else if input[next-1] == '{' {
+				frame = parseFrame{obj: JsonMap{}, closing: "}"}
+			} else {
+				frame.arr = JsonArray{}
+			}
+			if (p.trivia != nil || len(p.opts.RawPaths) > 0) && len(stack) > 0 {
+				frame.path = stack[len(stack)-1].childPath()
+			}
+
+			// empty container
+			after, suberr := consume(input, next, frame.closing)
+			if suberr != nil {
+				stack = append(stack, frame)
+				next, err = p.parseMemberKey(input, next, &stack[len(stack)-1])
+				if err != nil {
+					return p.recoverPartial(input, after, stack, nil, next, err)
+				}
+				continue
+			}
+			value, next = frame.container(), after
+		}
+
+		// the value is complete, add it to the enclosing containers until one of them continues
+		for {
+			if len(stack) == 0 {
+				return
+			}
+			top := &stack[len(stack)-1]
+			top.add(value, p.opts.DuplicateKeys)
+
+			var suberr error
+			end := next
+			next, suberr = consume(input, next, ",")
+			if suberr == nil {
+				start := skipSpace(input, next)
+				err = p.countTokens(next-1, 1)
+				if err == nil {
+					next, err = p.parseMemberKey(input, next, top)
+				}
+				if err != nil {
+					return p.recoverPartial(input, start, stack, nil, next, err)
+				}
+				break
+			}
+			next, suberr = consume(input, next, top.closing)
+			if suberr != nil {
+				err = &ParseError{next, fmt.Sprintf("expect '%s' or ','", top.closing)}
+				return p.recoverPartial(input, -1, stack, nil, next, err)
+			}
+			if p.trivia != nil {
+				p.trivia.recordEnd(top.path, blankLines(input[end:next-1]))
+			}
+			value = top.container()
+			if p.order != nil && top.obj != nil {
+				p.order.record(top.obj, top.keys)
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
+
+// an open array or object of parseAny
+type parseFrame struct {
+	arr JsonArray
+	obj JsonMap // nil for arrays and SortedMaps
+	// the members of a SortedMap are on the buffer of the parser from base,
+	// so only the finished map is allocated at its size
+	pairs   *[]JsonKeyValue
+	base    int
+	index   map[string]int // of the members, once there are many
+	key     string         // of the member being parsed
+	keys    []string       // in input order, only for ParseOrdered
+	path    string         // JSON Pointer, only for ParseTrivia and RawPaths
+	closing string
+	// repeated keys already wrapped in an array, for Collect
+	collected map[string]bool
+}
+
+func (frame *parseFrame) isArray() bool {
+	return frame.obj == nil && frame.pairs == nil
+}
+
+func (frame *parseFrame) add(value JsonValue, mode DuplicateKeyMode) {
+	if frame.isArray() {
+		frame.arr = append(frame.arr, value)
+		return
+	}
+
+	prev, dup := frame.get(frame.key)
+	switch {
+	case !dup || mode == LastWins:
+		frame.set(frame.key, value)
+	case mode == Collect:
+		if frame.collected[frame.key] {
+			frame.set(frame.key, append(prev.(JsonArray), value))
+		} else {
+			if frame.collected == nil {
+				frame.collected = map[string]bool{}
+			}
+			frame.collected[frame.key] = true
+			frame.set(frame.key, JsonArray{prev, value})
+		}
+	}
+}
+
+// a linear search is faster for small SortedMaps than building an index
+const sortedMapIndexMin = 16
+
+func (frame *parseFrame) members() []JsonKeyValue {
+	return (*frame.pairs)[frame.base:]
+}
+
+func (frame *parseFrame) find(key string) int {
+	if frame.index != nil {
+		if i, ok := frame.index[key]; ok {
+			return i
+		}
+		return -1
+	}
+	for i, kv := range frame.members() {
+		if kv.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func (frame *parseFrame) get(key string) (value JsonValue, ok bool) {
+	if frame.pairs == nil {
+		value, ok = frame.obj[key]
+		return
+	}
+	if i := frame.find(key); i >= 0 {
+		return frame.members()[i].value, true
+	}
+	return
+}
+
+func (frame *parseFrame) set(key string, value JsonValue) {
+	if frame.pairs == nil {
+		frame.obj[key] = value
+		return
+	}
+	if i := frame.find(key); i >= 0 {
+		frame.members()[i].value = value
+		return
+	}
+	*frame.pairs = append(*frame.pairs, JsonKeyValue{key, value})
+	n := len(frame.members())
+	if frame.index != nil {
+		frame.index[key] = n - 1
+	} else if n >= sortedMapIndexMin {
+		frame.index = make(map[string]int, n)
+		for i, kv := range frame.members() {
+			frame.index[kv.key] = i
+		}
+	}
+}
+
+// the JSON Pointer of the element or member being parsed
+func (frame *parseFrame) childPath() string {
+	if !frame.isArray() {
+		return pointerJoin(frame.path, frame.key)
+	}
+	return pointerIndex(frame.path, len(frame.arr))
+}
+
+// the finished container, frames must be finished from the innermost
+func (frame *parseFrame) container() JsonValue {
+	switch {
+	case frame.pairs != nil:
+		obj := make(SortedMap, len(frame.members()))
+		copy(obj, frame.members())
+		for i := range frame.members() {
+			frame.members()[i] = JsonKeyValue{} // don't keep the values alive
+		}
+		*frame.pairs = (*frame.pairs)[:frame.base]
+		sortPairs(obj)
+		return obj
+	case frame.obj != nil:
+		return frame.obj
+	default:
+		return frame.arr
+	}
+}
+
+// the key and colon before an object member, nothing for arrays
+func (p *parser[T]) parseMemberKey(input []T, cur int, frame *parseFrame) (next int, err error) {
+	start := skipSpace(input, cur)
+	if frame.isArray() {
+		if p.trivia != nil {
+			p.recordBefore(frame.childPath(), input, cur, start)
+		}
+		return cur, nil
+	}
+	err = p.countTokens(start, 2) // the key and ':'
+	if err != nil {
+		return
+	}
+	frame.key, next, err = p.parseKey(input, cur)
+	if err != nil {
+		return
+	}
+	if p.trivia != nil {
+		p.recordBefore(frame.childPath(), input, cur, start)
+	}
+	if _, dup := frame.get(frame.key); dup && p.opts.DuplicateKeys == Error {
+		err = &ParseError{start, fmt.Sprintf("duplicate key %q", frame.key)}
+		return
+	}
+	if _, dup := frame.get(frame.key); p.order != nil && !dup {
+		frame.keys = append(frame.keys, frame.key)
+	}
+	return consume(input, next, ":")
+}
+
+func (p *parser[T]) parseKey(input []T, cur int) (key string, next int, err error) {
+	if !p.opts.InternKeys {
+		return p.parseString(input, cur)
+	}
+
+	// look up a key without escapes before allocating it
+	plain := false
+	raw := p.scratch[:0]
+	start := skipSpace(input, cur)
+	for i := start + 1; start < len(input) && input[start] == '"' && i < len(input); i++ {
+		ch := input[i]
+		if ch == '"' {
+			if key, ok := p.keys[string(raw)]; ok {
+				return key, i + 1, nil
+			}
+			plain = true
+			break
+		}
+		if !IsNoEscape(rune(ch)) {
+			break
+		}
+		raw = appendChar(raw, ch)
+	}
+	p.scratch = raw
+
+	key, next, err = p.parseString(input, cur)
+	if err != nil {
+		return
+	}
+	if p.keys == nil {
+		p.keys = map[string]string{}
+	}
+	if plain {
+		p.keys[string(raw)] = key
+	} else if canonical, ok := p.keys[key]; ok {
+		key = canonical
+	} else {
+		p.keys[key] = key
+	}
+	return
+}
+
+// parse a scalar, or only the bracket of an array or object with open set
+func (p *parser[T]) parseValueStart(input []T, cur int) (value JsonValue, next int, open bool, err error) {
+	next = skipSpace(input, cur)
+	err = p.checkContext(next)
+	if err != nil {
+		return
+	}
 	if next >= len(input) {
 		err = &ParseError{next, "expect something, got EOS"}
 		return
 	}
+	tokens := 1
+	if input[next] == '[' || input[next] == '{' {
+		tokens = 2 // both brackets
+	}
+	err = p.countTokens(next, tokens)
+	if err != nil {
+		return
+	}
+	if p.opts.AllowBareWords && isBareWordStart(rune(input[next])) {
+		value, next = p.parseBareWord(input, next)
+		return
+	}
 
 	switch input[next] {
-	case '[':
-		value, next, err = ParseArray(input, next)
-	case '{':
-		value, next, err = ParseMap(input, next)
+	case '[', '{':
+		next++
+		open = true
 	case '"':
-		value, next, err = ParseString(input, next)
+		value, next, err = p.parseString(input, next)
 	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9', '-':
-		value, next, err = ParseNum(input, next)
+		value, next, err = p.parseNum(input, next)
 	case 't', 'f', 'n':
-		value, next, err = ParseBoolNull(input, next)
+		value, next, err = p.parseBoolNull(input, next)
+	case 'T', 'F', 'N':
+		if p.opts.CaseInsensitiveLiterals {
+			value, next, err = p.parseBoolNull(input, next)
+			break
+		}
+		fallthrough
 	default:
-		err = &ParseError{next, fmt.Sprintf("bad char: '%c' (%#x)", input[next], input[next])}
+		var ch rune
+		ch, _, err = decodeChar(input, next)
+		if err == nil {
+			err = &ParseError{next, fmt.Sprintf("bad char: '%c' (%#x)", ch, ch)}
+		}
 	}
 
 	return
@@ -104,7 +593,11 @@ func IsNoEscape(ch rune) bool {
 }
 
 func Hex2Num(input []rune, cur int) (value rune, err error) {
-	ch := input[cur]
+	return hex2Num(input, cur)
+}
+
+func hex2Num[T char](input []T, cur int) (value rune, err error) {
+	ch := rune(input[cur])
 	switch {
 	case '0' <= ch && ch <= '9':
 		value = ch - '0'
@@ -113,12 +606,17 @@ func Hex2Num(input []rune, cur int) (value rune, err error) {
 	case 'A' <= ch && ch <= 'F':
 		value = ch - 'A' + 10
 	default:
+		ch, _, _ = decodeChar(input, cur)
 		err = &ParseError{cur, fmt.Sprintf("expect hex, got '%c' (%#x)", ch, ch)}
 	}
 	return
 }
 
 func ScanHex(input []rune, cur int) (value rune, err error) {
+	return scanHex(input, cur)
+}
+
+func scanHex[T char](input []T, cur int) (value rune, err error) {
 	if cur+4 > len(input) {
 		err = &ParseError{cur, "expect 4 hex digit"}
 		return
@@ -126,7 +624,7 @@ func ScanHex(input []rune, cur int) (value rune, err error) {
 
 	for i := 0; i < 4; i++ {
 		var d rune
-		d, err = Hex2Num(input, cur+i)
+		d, err = hex2Num(input, cur+i)
 		if err != nil {
 			return
 		}
@@ -138,14 +636,22 @@ func ScanHex(input []rune, cur int) (value rune, err error) {
 	return
 }
 
+// ParseEscape decodes the escape after a backslash. A \u escape follows the same policy as raw UTF-8 input:
+// noncharacters like U+FFFE and U+FFFF are accepted as is, while a surrogate that isn't part
+// of a high and low pair is an error, since it is not a character on its own.
 func ParseEscape(input []rune, cur int) (value rune, next int, err error) {
+	return parseEscape(input, cur, false)
+}
+
+// legacy also accepts \xNN and \0, for AllowLegacyEscapes
+func parseEscape[T char](input []T, cur int, legacy bool) (value rune, next int, err error) {
 	next = cur
 	if cur >= len(input) {
 		err = &ParseError{next, "string not terminated, expect escape"}
 		return
 	}
 
-	ch := input[next]
+	ch := rune(input[next])
 	switch ch {
 	case '"', '\\', '/':
 		value = ch
@@ -159,14 +665,50 @@ func ParseEscape(input []rune, cur int) (value rune, next int, err error) {
 		value = '\r'
 	case 't':
 		value = '\t'
+	case 'x', '0':
+		if !legacy {
+			ch, _, _ = decodeChar(input, next)
+			err = &ParseError{next, fmt.Sprintf("bad escape char: '%c' (%#x)", ch, ch)}
+			return
+		}
+		if ch == 'x' {
+			if next+3 > len(input) {
+				err = &ParseError{next + 1, "expect 2 hex digit"}
+				return
+			}
+			var hi, lo rune
+			hi, err = hex2Num(input, next+1)
+			if err == nil {
+				lo, err = hex2Num(input, next+2)
+			}
+			if err != nil {
+				return
+			}
+			value = hi<<4 | lo
+			next += 2
+		}
 	case 'u':
 		next++
-		value, err = ScanHex(input, next)
+		value, err = scanHex(input, next)
 		if err != nil {
 			return
 		}
 		next += 3
+
+		// a UTF-16 surrogate pair is a single character
+		if 0xd800 <= value && value < 0xdc00 && next+2 < len(input) && input[next+1] == '\\' && input[next+2] == 'u' {
+			low, suberr := scanHex(input, next+3)
+			if suberr == nil && 0xdc00 <= low && low < 0xe000 {
+				value = utf16.DecodeRune(value, low)
+				next += 6
+			}
+		}
+		if 0xd800 <= value && value < 0xe000 {
+			err = &ParseError{cur, fmt.Sprintf("lone surrogate \\u%04x", value)}
+			return
+		}
 	default:
+		ch, _, _ = decodeChar(input, next)
 		err = &ParseError{next, fmt.Sprintf("bad escape char: '%c' (%#x)", ch, ch)}
 		return
 	}
@@ -175,15 +717,27 @@ func ParseEscape(input []rune, cur int) (value rune, next int, err error) {
 	return
 }
 
+func (p *parser[T]) parseString(input []T, cur int) (value string, next int, err error) {
+	value, next, err = parseString(input, cur, p.opts.AllowLegacyEscapes)
+	if err == nil && p.opts.NormalizeNFC {
+		value = norm.NFC.String(value)
+	}
+	return
+}
+
 func ParseString(input []rune, cur int) (value string, next int, err error) {
-	next, err = Consume(input, cur, "\"")
+	return parseString(input, cur, false)
+}
+
+func parseString[T char](input []T, cur int, legacy bool) (value string, next int, err error) {
+	next, err = consume(input, cur, "\"")
 	if err != nil {
 		return
 	}
 
 	val := []rune{}
 	for next < len(input) {
-		ch := input[next]
+		ch := rune(input[next])
 		switch {
 		case ch == '"': // terminated
 			value = string(val)
@@ -191,11 +745,22 @@ func ParseString(input []rune, cur int) (value string, next int, err error) {
 			return
 		case ch == '\\':
 			next++
-			ch, next, err = ParseEscape(input, next)
+			ch, next, err = parseEscape(input, next, legacy)
 			if err != nil {
 				return
 			}
 			val = append(val, ch)
+		case ch >= utf8.RuneSelf:
+			var size int
+			ch, size, err = decodeChar(input, next)
+			if err == nil && !IsNoEscape(ch) {
+				err = &ParseError{next, fmt.Sprintf("unescaped char: '%c' (%#x)", ch, ch)}
+			}
+			if err != nil {
+				return
+			}
+			val = append(val, ch)
+			next += size
 		case IsNoEscape(ch):
 			val = append(val, ch)
 			next++
@@ -220,18 +785,136 @@ func ScanInt(input []rune, cur int) (value int64, next int, err error) {
 	}
 
 	for next = cur; next < len(input) && IsDigit(input[next]); next++ {
+		digit := int64(input[next] - '0')
+		if value > (math.MaxInt64-digit)/10 {
+			err = &ParseError{cur, "integer overflow"}
+			return
+		}
 		value *= 10
-		value += int64(input[next] - '0')
+		value += digit
+	}
+	return
+}
+
+func (p *parser[T]) parseNum(input []T, cur int) (value JsonValue, next int, err error) {
+	if p.opts.MaxIntegerDigits > 0 || p.opts.MaxExponent > 0 {
+		err = p.checkNumberSize(input, cur)
+		if err != nil {
+			return
+		}
+	}
+	if p.opts.UseDecimal {
+		return parseDecimal(input, cur)
+	}
+	if p.opts.LazyBigNumber {
+		value, next, err = parseLazyNumber(input, cur)
+	} else {
+		value, next, err = parseNum(input, cur)
+	}
+	if f, ok := value.(float64); ok && p.opts.CoerceWholeFloatsToInt && floatIntEqual(f, int64(f)) {
+		value = int64(f)
+	}
+	return
+}
+
+// MaxIntegerDigits and MaxExponent, checked on the text before any conversion.
+// A malformed number is left to the parse.
+func (p *parser[T]) checkNumberSize(input []T, cur int) (err error) {
+	i := cur
+	if i < len(input) && input[i] == '-' {
+		i++
+	}
+	start := i
+	for i < len(input) && IsDigit(rune(input[i])) {
+		i++
+	}
+	if max := p.opts.MaxIntegerDigits; max > 0 && i-start > max {
+		return &ParseError{start + max, fmt.Sprintf("more than %d integer digits", max)}
+	}
+
+	if i < len(input) && input[i] == '.' {
+		for i++; i < len(input) && IsDigit(rune(input[i])); i++ {
+		}
+	}
+	if i >= len(input) || (input[i] != 'e' && input[i] != 'E') || p.opts.MaxExponent <= 0 {
+		return
+	}
+	expStart := i
+	i++
+	if i < len(input) && (input[i] == '+' || input[i] == '-') {
+		i++
+	}
+	exp := 0
+	for ; i < len(input) && IsDigit(rune(input[i])); i++ {
+		exp = exp*10 + int(input[i]-'0')
+		if exp > p.opts.MaxExponent {
+			return &ParseError{expStart, fmt.Sprintf("exponent larger than %d", p.opts.MaxExponent)}
+		}
 	}
 	return
 }
 
+// ParseNum returns an int64 for integers that fit, and a float64 otherwise.
+// The float64 is the nearest to the exact decimal value.
 func ParseNum(input []rune, cur int) (value JsonValue, next int, err error) {
-	neg := false
-	var suberr error
-	next, suberr = Consume(input, cur, "-")
-	if suberr == nil {
-		neg = true
+	return parseNum(input, cur)
+}
+
+func parseNum[T char](input []T, cur int) (value JsonValue, next int, err error) {
+	isInt, i, f, next, err := scanNumber(input, cur)
+	if err != nil {
+		return
+	}
+	if isInt {
+		value = i
+	} else {
+		value = f
+	}
+	return
+}
+
+// ScanNumber is ParseNum without boxing the result in a JsonValue, so it doesn't allocate:
+// i is set if isInt, f otherwise.
+func ScanNumber(input []rune, cur int) (isInt bool, i int64, f float64, next int, err error) {
+	return scanNumber(input, cur)
+}
+
+func scanNumber[T char](input []T, cur int) (isInt bool, i int64, f float64, next int, err error) {
+	var isfloat bool
+	next, isfloat, err = numberEnd(input, cur)
+	if err != nil {
+		return
+	}
+
+	// the text is only ASCII, copy it to the stack instead of converting the runes to a string
+	var buf [64]byte
+	text := buf[:0]
+	for _, ch := range input[cur:next] {
+		text = append(text, byte(ch))
+	}
+	if !isfloat {
+		var perr error
+		i, perr = strconv.ParseInt(string(text), 10, 64)
+		if perr == nil {
+			isInt = true
+			return
+		}
+		i = 0
+	}
+
+	f, _ = strconv.ParseFloat(string(text), 64)
+	if math.IsInf(f, 0) {
+		f = 0
+		err = &ParseError{cur, "number out of range"}
+	}
+	return
+}
+
+// the end of the number grammar at cur, isfloat if it has a fraction or exponent
+func numberEnd[T char](input []T, cur int) (next int, isfloat bool, err error) {
+	next = cur
+	if next < len(input) && input[next] == '-' {
+		next++
 	}
 
 	// unreachable
@@ -241,127 +924,139 @@ func ParseNum(input []rune, cur int) (value JsonValue, next int, err error) {
 	}
 
 	// integer part
-	var whole int64
 	if input[next] == '0' {
-		whole = 0
 		next++
 	} else {
-		whole, next, err = ScanInt(input, next)
+		next, err = scanDigits(input, next)
 		if err != nil {
 			return
 		}
 	}
 
 	// frac part
-	isfloat := false
-	frac := float64(0)
 	if next < len(input) && input[next] == '.' {
 		isfloat = true
-		next++
-
-		if !(next < len(input) && IsDigit(input[next])) {
-			err = &ParseError{next, "expect digits"}
+		next, err = scanDigits(input, next+1)
+		if err != nil {
 			return
 		}
-
-		scale := float64(10)
-		for ; next < len(input) && IsDigit(input[next]); next++ {
-			frac += float64(input[next]-'0') / scale
-			scale *= 10
-		}
 	}
 
 	// exp part
-	hasexp := false
-	expnum := int64(0)
 	if next < len(input) && (input[next] == 'e' || input[next] == 'E') {
-		next++
 		isfloat = true
-		hasexp = true
-		expneg := false
-
-		for sign, val := range map[string]bool{"+": false, "-": true} {
-			next, err = Consume(input, next, sign)
-			if err == nil {
-				expneg = val
-				break
-			}
+		next++
+		if next < len(input) && (input[next] == '+' || input[next] == '-') {
+			next++
 		}
+		next, err = scanDigits(input, next)
+	}
+	return
+}
 
-		expnum, next, err = ScanInt(input, next)
-		if err != nil {
-			return
-		}
-		if expneg {
-			expnum = -expnum
-		}
+func scanDigits[T char](input []T, cur int) (next int, err error) {
+	if !(cur < len(input) && IsDigit(rune(input[cur]))) {
+		err = &ParseError{cur, "expect digits"}
+		return
+	}
+	for next = cur; next < len(input) && IsDigit(rune(input[next])); next++ {
 	}
+	return
+}
 
-	if !isfloat {
-		if neg {
-			value = -whole
-		} else {
-			value = whole
-		}
-	} else {
-		fval := float64(whole)
-		fval += frac
-		if hasexp {
-			fval *= math.Pow10(int(expnum))
-		}
+func isBareWordStart(ch rune) bool {
+	return ('a' <= ch && ch <= 'z') || ('A' <= ch && ch <= 'Z') || ch == '_'
+}
 
-		if neg {
-			fval = -fval
-		}
-		value = fval
-	}
+func isBareWordChar(ch rune) bool {
+	return isBareWordStart(ch) || IsDigit(ch) || ch == '-' || ch == '.'
+}
 
-	return
+// a bare word spelling a literal is still the literal
+func (p *parser[T]) parseBareWord(input []T, cur int) (value JsonValue, next int) {
+	next = cur
+	for next < len(input) && isBareWordChar(rune(input[next])) {
+		next++
+	}
+	literal, end, err := p.parseBoolNull(input[:next], cur)
+	if err == nil && end == next {
+		return literal, next
+	}
+	word := make([]byte, 0, next-cur)
+	for _, ch := range input[cur:next] {
+		word = append(word, byte(ch))
+	}
+	return string(word), next
 }
 
+var literals = []struct {
+	text  string
+	value JsonValue
+}{{"true", true}, {"false", false}, {"null", nil}}
+
 func ParseBoolNull(input []rune, cur int) (value JsonValue, next int, err error) {
-	var suberr error
-	for literal, val := range map[string]JsonValue{"true": true, "false": false, "null": nil} {
-		next, suberr = Consume(input, cur, literal)
-		if suberr == nil {
-			value = val
-			return
+	p := parser[rune]{}
+	return p.parseBoolNull(input, cur)
+}
+
+func (p *parser[T]) parseBoolNull(input []T, cur int) (value JsonValue, next int, err error) {
+	// the error is where the spelling that matched longest stops matching
+	furthest := cur
+	for _, literal := range literals {
+		spellings := []string{literal.text}
+		if p.opts.CaseInsensitiveLiterals {
+			spellings = append(spellings,
+				strings.ToUpper(literal.text[:1])+literal.text[1:], strings.ToUpper(literal.text))
+		}
+		for _, spelling := range spellings {
+			var suberr error
+			next, suberr = consume(input, cur, spelling)
+			if suberr == nil {
+				value = literal.value
+				return
+			}
+			if pos := suberr.(*ParseError).pos; pos > furthest {
+				furthest = pos
+			}
 		}
 	}
 
+	next = furthest
 	err = &ParseError{next, "expect true|false|null"}
 	return
 }
 
 func ParseMap(input []rune, cur int) (value JsonValue, next int, err error) {
-	value, next, err = ParseArrayLike(input, cur, ParseKeyValue, [2]string{"{", "}"})
+	p := parser[rune]{}
+	return p.parseMap(input, cur)
+}
 
-	// convert array to map
-	if err == nil {
-		jmap := JsonMap{}
-		for _, item := range value.(JsonArray) {
-			kv := item.(JsonKeyValue)
-			// TODO: warn duplicated key
-			jmap[kv.key] = kv.value
-		}
-		value = jmap
+func (p *parser[T]) parseMap(input []T, cur int) (value JsonValue, next int, err error) {
+	next, err = consume(input, cur, "{")
+	if err != nil {
+		return
 	}
-	return
+	return p.parseAny(input, cur)
 }
 
 func ParseKeyValue(input []rune, cur int) (value JsonValue, next int, err error) {
+	p := parser[rune]{}
+	return p.parseKeyValue(input, cur)
+}
+
+func (p *parser[T]) parseKeyValue(input []T, cur int) (value JsonValue, next int, err error) {
 	var kv JsonKeyValue
-	kv.key, next, err = ParseString(input, cur)
+	kv.key, next, err = p.parseString(input, cur)
 	if err != nil {
 		return
 	}
 
-	next, err = Consume(input, next, ":")
+	next, err = consume(input, next, ":")
 	if err != nil {
 		return
 	}
 
-	kv.value, next, err = ParseAny(input, next)
+	kv.value, next, err = p.parseAny(input, next)
 	if err != nil {
 		return
 	}
@@ -370,21 +1065,64 @@ func ParseKeyValue(input []rune, cur int) (value JsonValue, next int, err error)
 	return
 }
 
+// ParseObjectPairs parses a document that is an object into its members in input order,
+// keeping repeated keys. Nested objects are still parsed as JsonMap.
+func ParseObjectPairs(input string) (pairs []JsonKeyValue, err error) {
+	var decoded []rune
+	decoded, err = DecodeString(input)
+	if err != nil {
+		return
+	}
+
+	p := parser[rune]{}
+	cur := SkipSpace(decoded, 0)
+	if cur < len(decoded) && decoded[cur] != '{' {
+		err = &ParseError{cur, "expect object"}
+		return
+	}
+	var members JsonValue
+	var next int
+	members, next, err = ParseArrayLike(decoded, cur, p.parseKeyValue, [2]string{"{", "}"})
+	if err != nil {
+		return
+	}
+	next = SkipSpace(decoded, next)
+	if next != len(decoded) {
+		err = trailingError(next, decoded[next])
+		return
+	}
+
+	pairs = make([]JsonKeyValue, 0, len(members.(JsonArray)))
+	for _, kv := range members.(JsonArray) {
+		pairs = append(pairs, kv.(JsonKeyValue))
+	}
+	return
+}
+
 func ParseArray(input []rune, cur int) (value JsonValue, next int, err error) {
-	return ParseArrayLike(input, cur, ParseAny, [2]string{"[", "]"})
+	p := parser[rune]{}
+	return p.parseArray(input, cur)
+}
+
+func (p *parser[T]) parseArray(input []T, cur int) (value JsonValue, next int, err error) {
+	next, err = consume(input, cur, "[")
+	if err != nil {
+		return
+	}
+	return p.parseAny(input, cur)
 }
 
 type ParseFunc func(input []rune, cur int) (value JsonValue, next int, err error)
 
 func ParseArrayLike(input []rune, cur int, itemParser ParseFunc, bracket [2]string) (value JsonValue, next int, err error) {
-	next, err = Consume(input, cur, bracket[0])
+	next, err = consume(input, cur, bracket[0])
 	if err != nil { // unreachable
 		return
 	}
 
 	// empty array []
 	var suberr error
-	next, suberr = Consume(input, next, bracket[1])
+	next, suberr = consume(input, next, bracket[1])
 	if suberr == nil {
 		value = JsonArray{}
 		return
@@ -399,11 +1137,11 @@ func ParseArrayLike(input []rune, cur int, itemParser ParseFunc, bracket [2]stri
 		}
 		arr = append(arr, subval)
 
-		next, suberr = Consume(input, next, ",")
+		next, suberr = consume(input, next, ",")
 		if suberr == nil {
 			continue
 		}
-		next, suberr = Consume(input, next, bracket[1])
+		next, suberr = consume(input, next, bracket[1])
 		if suberr != nil {
 			err = &ParseError{next, fmt.Sprintf("expect '%s' or ','", bracket[1])}
 			return
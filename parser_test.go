@@ -1,7 +1,11 @@
 package json_go
 
 import (
+	"errors"
+	"math"
+	"strings"
 	"testing"
+	"unsafe"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -75,6 +79,57 @@ func TestParseNum(t *testing.T) {
 	bad("1e.")
 
 	bad("1.e1")
+	bad("1e 2")
+	bad("1e+ 2")
+	bad("- 1")
+	bad("1. 5")
+
+	// exact conversion
+	goodf("1.23", 1.23)
+	goodf("0.3", 0.3)
+	goodf("123.456e-7", 123.456e-7)
+	goodf("2.2250738585072014e-308", 2.2250738585072014e-308)
+	goodf("1e-400", 0)
+	bad("1e400")
+	bad("-1e400")
+
+	// integers beyond int64 are floats
+	goodi("9223372036854775807", math.MaxInt64)
+	goodi("-9223372036854775808", math.MinInt64)
+	goodf("9223372036854775808", 9223372036854775808.0)
+	goodf("-99999999999999999999", -1e20)
+
+	_, _, err := ScanInt([]rune("99999999999999999999"), 0)
+	assert.Equal(t, &ParseError{0, "integer overflow"}, err)
+}
+
+func TestScanNumber(t *testing.T) {
+	input := []rune("[-12, 1.5e3, 9223372036854775808, 1e400, " + strings.Repeat("1", 100) + "]")
+
+	isInt, i, f, next, err := ScanNumber(input, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{true, int64(-12), 0.0, 4}, []interface{}{isInt, i, f, next})
+
+	isInt, i, f, next, err = ScanNumber(input, 6)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{false, int64(0), 1500.0, 11}, []interface{}{isInt, i, f, next})
+
+	isInt, i, f, next, err = ScanNumber(input, 13)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{false, int64(0), 9223372036854775808.0, 32}, []interface{}{isInt, i, f, next})
+
+	_, _, _, _, err = ScanNumber(input, 34)
+	assert.Equal(t, &ParseError{34, "number out of range"}, err)
+
+	isInt, _, f, _, err = ScanNumber(input, 41)
+	assert.NoError(t, err)
+	assert.False(t, isInt)
+	assert.InDelta(t, 1.1111e99, f, 1e96)
+
+	assert.Equal(t, 0.0, testing.AllocsPerRun(100, func() {
+		_, _, _, _, _ = ScanNumber(input, 1)
+		_, _, _, _, _ = ScanNumber(input, 6)
+	}))
 }
 
 func TestParseArray(t *testing.T) {
@@ -110,6 +165,14 @@ func TestParseBoolNull(t *testing.T) {
 
 	bad("nul")
 	bad("nulll")
+
+	// at the first character that doesn't match or is missing
+	for input, pos := range map[string]int{"trux": 3, "tru": 3, "nul": 3, "fals": 4, " nuLL": 3, "[true, fxlse]": 8} {
+		_, err := Parse(input)
+		assert.Equal(t, &ParseError{pos, "expect true|false|null"}, err, input)
+	}
+	_, _, err := ParseBoolNull([]rune("trux"), 0)
+	assert.Equal(t, &ParseError{3, "expect true|false|null"}, err)
 }
 
 func TestParseString(t *testing.T) {
@@ -130,6 +193,26 @@ func TestParseString(t *testing.T) {
 
 	bad(`"\x"`)
 	bad("\"\x19\"")
+
+	// surrogate pairs
+	good(`"\ud83d\ude00"`, "\U0001f600")
+	good(`"a\uD83D\uDE00b"`, "a\U0001f600b")
+	bad(`"\ud83d\u12"`)
+
+	// lone surrogates are rejected like in raw UTF-8, noncharacters are kept
+	bad(`"\ud800"`)
+	bad(`"\ude00\ud83d"`)
+	bad(`"\ud83d\u0041"`)
+	bad(`"\ud83d\n"`)
+	bad("\"\xed\xa0\x80\"")
+	good(`"\uFFFE"`, "\ufffe")
+	good(`"\uffff"`, "\uffff")
+	good("\"\xef\xbf\xbe\xef\xbf\xbf\"", "\ufffe\uffff")
+
+	_, err := Parse(`"a\ud800"`)
+	assert.Equal(t, &ParseError{3, `lone surrogate \ud800`}, err)
+	_, err = Parse("\"\xed\xa0\x80\"")
+	assert.Equal(t, &DecodingError{1, 0xed, "surrogate code point", 1, 4, 1, false}, err)
 }
 
 func TestParseMap(t *testing.T) {
@@ -146,3 +229,229 @@ func TestParseMap(t *testing.T) {
 	bad(`{"b": }`)
 	bad(`{"b", "c": 1}`)
 }
+
+func TestDeeplyNested(t *testing.T) {
+	const N = 100000
+	input := strings.Repeat(`[{"a":`, N) + "1" + strings.Repeat("}]", N)
+	value, err := Parse(input)
+	assert.NoError(t, err)
+
+	for i := 0; i < N; i++ {
+		value = value.(JsonArray)[0].(JsonMap)["a"]
+	}
+	assert.Equal(t, int64(1), value)
+
+	_, err = Parse(input[:len(input)-1])
+	assert.Equal(t, &ParseError{len(input) - 1, "expect ']' or ','"}, err)
+}
+
+func TestParser(t *testing.T) {
+	ps := NewParser(Options{MaxTokens: 5, InternKeys: true, NormalizeNFC: true, CoerceWholeFloatsToInt: true})
+	assert.Equal(t, 5, ps.Options().MaxTokens)
+
+	// the token limit and interned keys are per call
+	interner := NewParser(Options{InternKeys: true})
+	pair, err := interner.Parse(`[{"id": 1}, {"id": 2}]`)
+	assert.NoError(t, err)
+	assert.Equal(t, JsonArray{JsonMap{"id": int64(1)}, JsonMap{"id": int64(2)}}, pair)
+	keyData := func(obj JsonValue) uintptr {
+		for k := range obj.(JsonMap) {
+			return uintptr(unsafe.Pointer(unsafe.StringData(k)))
+		}
+		return 0
+	}
+	assert.Equal(t, keyData(pair.(JsonArray)[0]), keyData(pair.(JsonArray)[1]))
+	_, err = interner.Parse(`{"a": 1}`)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "a"}, interner.p.keys)
+	_, err = ps.Parse(`[1, 2, 3]`)
+	assert.Equal(t, &ParseError{5, "more than 5 tokens"}, err)
+
+	value, next, err := ps.ParseAny([]rune(`[2.0] x`), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, JsonArray{int64(2)}, value)
+	assert.Equal(t, 5, next)
+
+	value, _, err = ps.ParseArray([]rune(` [1e1]`), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, JsonArray{int64(10)}, value)
+	_, _, err = ps.ParseArray([]rune(`{}`), 0)
+	assert.Error(t, err)
+
+	value, _, err = ps.ParseMap([]rune(`{"e\u0301": 1.5}`), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, JsonMap{"\u00e9": 1.5}, value)
+
+	str, next, err := ps.ParseString([]rune("\"e\u0301\","), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "\u00e9", str)
+	assert.Equal(t, 4, next)
+
+	value, _, err = ps.ParseNum([]rune(`3.0`), 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), value)
+
+	// independent of other parsers
+	value, err = NewParser(Options{}).Parse(`3.0`)
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, value)
+}
+
+func TestTrailingContent(t *testing.T) {
+	_, err := Parse(`{} {}`)
+	assert.Equal(t, &ParseError{3, "extra value after the document, starting with '{'"}, err)
+	_, err = Parse(`{} x`)
+	assert.Equal(t, &ParseError{3, "junk after the document: 'x' (0x78)"}, err)
+	_, err = Parse(`{},`)
+	assert.Equal(t, &ParseError{2, "junk after the document: ',' (0x2c)"}, err)
+	_, err = Parse("1\n\t-2")
+	assert.Equal(t, &ParseError{3, "extra value after the document, starting with '-'"}, err)
+	_, err = Parse("\"\u00e9\"\u00e9")
+	assert.Equal(t, &ParseError{3, "junk after the document: '\u00e9' (0xe9)"}, err)
+	_, err = Parse("[] \n ")
+	assert.NoError(t, err)
+}
+
+func TestParseObjectPairs(t *testing.T) {
+	pairs, err := ParseObjectPairs(` {"b": 1, "a": {"x": [], "x": null}, "b": "2"} `)
+	assert.NoError(t, err)
+	kvs := []JsonValue{}
+	for _, kv := range pairs {
+		kvs = append(kvs, kv.Key(), kv.Value())
+	}
+	assert.Equal(t, []JsonValue{"b", int64(1), "a", JsonMap{"x": nil}, "b", "2"}, kvs)
+
+	pairs, err = ParseObjectPairs(`{}`)
+	assert.NoError(t, err)
+	assert.Equal(t, []JsonKeyValue{}, pairs)
+
+	_, err = ParseObjectPairs(` [1]`)
+	assert.Equal(t, &ParseError{1, "expect object"}, err)
+	_, err = ParseObjectPairs(`{"a": 1,}`)
+	assert.Error(t, err)
+	_, err = ParseObjectPairs(`{"a": 1} 2`)
+	assert.Equal(t, &ParseError{9, "extra value after the document, starting with '2'"}, err)
+	_, err = ParseObjectPairs(``)
+	assert.Error(t, err)
+}
+
+func BenchmarkParse(b *testing.B) {
+	item := `{"id": 12345, "name": "some name \"quoted\"", "tags": ["a", "b", "c"], "score": -1.5e3, "ok": true, "next": null}`
+	input := "[" + strings.Repeat(item+",", 99) + item + "]"
+	b.SetBytes(int64(len(input)))
+	for i := 0; i < b.N; i++ {
+		_, err := Parse(input)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// many numbers consumed one at a time, like an event parser does
+var numbers = []rune(strings.Repeat("12345, -1.5e3, 1234567, 0.25, ", 250))
+
+func BenchmarkParseNum(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		sum := 0.0
+		for cur := 0; cur < len(numbers); cur += 2 {
+			var value JsonValue
+			value, cur, _ = ParseNum(numbers, cur)
+			f, _ := toFloat(value)
+			sum += f
+		}
+	}
+}
+
+func BenchmarkScanNumber(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		sum := 0.0
+		for cur := 0; cur < len(numbers); cur += 2 {
+			var isInt bool
+			var i int64
+			var f float64
+			isInt, i, f, cur, _ = ScanNumber(numbers, cur)
+			if isInt {
+				f = float64(i)
+			}
+			sum += f
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	seeds := []string{
+		``, `null`, `true`, `-0`, `1.5e-3`, `9223372036854775808`, `1e400`, `"\u12"`,
+		`"\"\\\/\b\f\n\r\t\u1234\ud83d\ude00"`, "\"\xe5\x95\x8a\"", "\"\xed\xa0\x80\"",
+		`[[], {}, [1, [2]], {"a": {"b": null}}]`, ` {"a" : [1 , 2.0 , "x"] } `,
+		`[1,]`, `{"a"}`, `{"a":}`, `[tru`, `"x`, `1.`, `-`, `1e+`, "[" + strings.Repeat("[", 100),
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		value, err := Parse(input)
+		if err != nil {
+			return
+		}
+		output, err := Marshal(value)
+		if err != nil {
+			t.Fatalf("marshal %q: %v", input, err)
+		}
+		again, err := Parse(output)
+		if err != nil {
+			t.Fatalf("parse marshaled %q of %q: %v", output, input, err)
+		}
+		if !Equal(value, again) {
+			t.Fatalf("round trip of %q gives %q", input, output)
+		}
+	})
+}
+
+func TestConsume(t *testing.T) {
+	consume := func(input string, cur int, tok string, expectNext int, expectErr error) {
+		next, err := Consume([]rune(input), cur, tok)
+		assert.Equal(t, expectNext, next, "%q %q", input, tok)
+		assert.Equal(t, expectErr, err, "%q %q", input, tok)
+	}
+
+	consume("true", 0, "true", 4, nil)
+	consume("  true", 0, "true", 6, nil)
+	consume("[true", 1, "true", 5, nil)
+	consume("啊]", 1, "]", 2, nil)
+
+	// the error is at the first missing or mismatched rune, next is after the spaces
+	consume("tru", 0, "true", 0, &ParseError{3, `expect "true"`})
+	consume("  tru", 0, "true", 2, &ParseError{5, `expect "true"`})
+	consume("trUe", 0, "true", 0, &ParseError{2, `expect "true"`})
+	consume("xtrue", 0, "true", 0, &ParseError{0, `expect "true"`})
+	consume("", 0, "true", 0, &ParseError{0, `expect "true"`})
+	consume(" ", 0, "]", 1, &ParseError{1, `expect "]"`})
+	consume("[1", 2, "]", 2, &ParseError{2, `expect "]"`})
+	consume("a", 5, "]", 1, &ParseError{1, `expect "]"`}) // cur past the end
+	consume("啊", 0, "啊啊", 0, &ParseError{1, `expect "啊啊"`})
+}
+
+func TestEmptyInput(t *testing.T) {
+	for _, input := range []string{"", " \t\r\n", "\ufeff", "\ufeff  "} {
+		_, err := Parse(input)
+		assert.True(t, errors.Is(err, ErrEmptyInput), input)
+		_, err = ParseBytes([]byte(input))
+		assert.Equal(t, ErrEmptyInput, err, input)
+		_, err = ParseWith(input, WithTopLevelMustBeObjectOrArray())
+		assert.Equal(t, ErrEmptyInput, err, input)
+	}
+	assert.Equal(t, "ParseError at 0: empty input", ErrEmptyInput.Error())
+
+	// not empty
+	value, err := Parse("\ufeff [1]")
+	assert.NoError(t, err)
+	assert.Equal(t, JsonArray{int64(1)}, value)
+	_, err = ParseBytes([]byte("\xef\xbb\xbf1 x"))
+	assert.Equal(t, &ParseError{5, "junk after the document: 'x' (0x78)"}, err)
+	for _, input := range []string{"[", "\ufeff\ufeff", " x", "\ufeff]"} {
+		_, err = Parse(input)
+		assert.Error(t, err, input)
+		assert.False(t, errors.Is(err, ErrEmptyInput), input)
+	}
+}
@@ -0,0 +1,114 @@
+package json_go
+
+import "unicode/utf8"
+
+// ParsePartial parses a document that may be cut off, like a truncated log capture.
+// If the input ends early, the open arrays and objects are closed with the members parsed so far,
+// a trailing member or scalar that is not complete is dropped, and incomplete is true.
+// Errors other than the early end are reported like Parse. This is for recovery, not validation.
+func ParsePartial(input string) (value JsonValue, incomplete bool, err error) {
+	// a multi-byte character cut at the end
+	if i := lastRuneStart(input); !utf8.FullRuneInString(input[i:]) {
+		input = input[:i]
+		incomplete = true
+	}
+
+	var decoded []rune
+	decoded, err = DecodeString(input)
+	if err != nil {
+		return
+	}
+	p := parser[rune]{partial: true}
+	value, err = p.parseDocument(decoded)
+	incomplete = incomplete || p.incomplete
+	return
+}
+
+func lastRuneStart(input string) int {
+	i := len(input) - 1
+	for i > 0 && len(input)-i < utf8.UTFMax && !utf8.RuneStart(input[i]) {
+		i--
+	}
+	if i < 0 {
+		return 0
+	}
+	return i
+}
+
+// in partial mode, an error caused by the end of input closes the open containers instead.
+// start is where the failed token begins, or -1 for a missing comma or bracket.
+func (p *parser[T]) recoverPartial(input []T, start int, stack []parseFrame, value JsonValue, next int, err error) (JsonValue, int, error) {
+	perr, ok := err.(*ParseError)
+	if !p.partial || !ok {
+		return value, next, err
+	}
+	if perr.pos < len(input) && (start < 0 || !isTokenPrefix(input[start:])) {
+		return value, next, err
+	}
+
+	p.incomplete = true
+	value = nil
+	for i := len(stack) - 1; i >= 0; i-- {
+		if i < len(stack)-1 {
+			stack[i].add(value, p.opts.DuplicateKeys)
+		}
+		value = stack[i].container()
+	}
+	return value, len(input), nil
+}
+
+// whether rest could be the beginning of a string or literal,
+// numbers cut short always fail at the end of input
+func isTokenPrefix[T char](rest []T) bool {
+	if len(rest) == 0 {
+		return true
+	}
+	if rest[0] == '"' {
+		return isStringPrefix(rest)
+	}
+	for _, literal := range literals {
+		if len(rest) < len(literal.text) && hasPrefix(literal.text, rest) {
+			return true
+		}
+	}
+	return false
+}
+
+// whether text starts with the ASCII of prefix
+func hasPrefix[T char](text string, prefix []T) bool {
+	for i, ch := range prefix {
+		if rune(text[i]) != rune(ch) {
+			return false
+		}
+	}
+	return true
+}
+
+func isStringPrefix[T char](rest []T) bool {
+	for i := 1; i < len(rest); i++ {
+		switch ch := rune(rest[i]); {
+		case ch == '"':
+			return false
+		case ch == '\\':
+			i++
+			if i >= len(rest) {
+				return true
+			}
+			if rest[i] != 'u' {
+				if _, _, err := parseEscape(rest, i, false); err != nil {
+					return false
+				}
+				continue
+			}
+			for j := i + 1; j < len(rest) && j <= i+4; j++ {
+				if _, err := hex2Num(rest, j); err != nil {
+					return false
+				}
+			}
+			i += 4
+		case !IsNoEscape(ch):
+			return false
+		}
+	}
+	return true
+}
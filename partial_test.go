@@ -0,0 +1,64 @@
+package json_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePartial(t *testing.T) {
+	partial := func(input string, expect JsonValue) {
+		value, incomplete, err := ParsePartial(input)
+		if assert.NoError(t, err, input) {
+			assert.True(t, incomplete, input)
+			assert.Equal(t, expect, value, input)
+		}
+	}
+
+	partial(`[1, 2, {"a": [true, "x"`, JsonArray{int64(1), int64(2), JsonMap{"a": JsonArray{true, "x"}}})
+	partial(`{"a": 1, "b": "hel`, JsonMap{"a": int64(1)})
+	partial(`{"a": 1, "b"`, JsonMap{"a": int64(1)})
+	partial(`{"a": 1, "b": `, JsonMap{"a": int64(1)})
+	partial(`{"a": 1,`, JsonMap{"a": int64(1)})
+	partial(`{"a": {"b": [`, JsonMap{"a": JsonMap{"b": JsonArray{}}})
+	partial(`[1.5e`, JsonArray{})
+	partial(`[-`, JsonArray{})
+	partial(`[12`, JsonArray{int64(12)})
+	partial(`[tr`, JsonArray{})
+	partial(`["a\`, JsonArray{})
+	partial(`["a\u00`, JsonArray{})
+	partial(`["aAb`, JsonArray{})
+	partial("[\"\xe5\x95", JsonArray{})
+	partial("[\"\xe5\x95\x8a\"", JsonArray{"啊"})
+	partial(`[`, JsonArray{})
+	partial(`"abc`, nil)
+	partial(``, nil)
+
+	value, incomplete, err := ParsePartial(` {"a": [1]} `)
+	assert.NoError(t, err)
+	assert.False(t, incomplete)
+	assert.Equal(t, JsonMap{"a": JsonArray{int64(1)}}, value)
+
+	// not caused by the end of input
+	bad := func(input string) {
+		_, _, err := ParsePartial(input)
+		assert.Error(t, err, input)
+		t.Log(input, "\t", err)
+	}
+	bad(`[1 2`)
+	bad(`[1 t`)
+	bad(`[trux`)
+	bad(`{"a" 1`)
+	bad(`["a\q`)
+	bad(`["a\u00x`)
+	bad(`["a" "b`)
+	bad(`[1] 2`)
+	bad(`{1`)
+
+	// every truncation point
+	input := `{"a": [1, -2.5e3, "x\"\u0041y", true, null, {}], "b": {"c": "啊"}}`
+	for i := 0; i <= len(input); i++ {
+		_, _, err := ParsePartial(input[:i])
+		assert.NoError(t, err, input[:i])
+	}
+}
@@ -0,0 +1,354 @@
+package json_go
+
+import "fmt"
+
+type PatchError struct {
+	op   int    // index of the operation in the patch
+	path string // "path" of the operation
+	msg  string
+}
+
+func (err *PatchError) Error() string {
+	return fmt.Sprintf("PatchError at op %d (%q): %s", err.op, err.path, err.msg)
+}
+
+func (err *PatchError) Op() int {
+	return err.op
+}
+
+// PatchesError is returned by ApplyPatches, it unwraps to the error of the failing patch.
+type PatchesError struct {
+	patch int // index of the failing patch
+	cause error
+}
+
+func (err *PatchesError) Error() string {
+	return fmt.Sprintf("PatchesError at patch %d: %v", err.patch, err.cause)
+}
+
+func (err *PatchesError) Patch() int {
+	return err.patch
+}
+
+func (err *PatchesError) Unwrap() error {
+	return err.cause
+}
+
+// ApplyPatch applies a JSON Patch (RFC 6902): add, remove, replace, move, copy and test operations
+// addressed by JSON Pointers. The patch is all or nothing: on error the result is doc.
+//
+// doc itself is never modified. Only the arrays and objects on the path to each changed value
+// are copied, the rest of the result is shared with doc, so a small patch to a large document
+// costs about the size of the containers it goes through. The sharing is safe as long as trees are
+// treated as immutable: to modify a value below the changed paths in place, in the result or in doc,
+// Clone it first. Values from the patch are copied and never shared.
+func ApplyPatch(doc JsonValue, patch JsonArray) (result JsonValue, err error) {
+	result = doc
+	for i, item := range patch {
+		p := patcher{op: i}
+		result, err = p.apply(result, item)
+		if err != nil {
+			return doc, err
+		}
+	}
+	return
+}
+
+func ApplyPatches(doc JsonValue, patches []JsonArray) (result JsonValue, err error) {
+	return PatchOptions{}.ApplyPatches(doc, patches)
+}
+
+type PatchOptions struct {
+	// on error, return the document as it was before the first patch
+	// instead of before the failing one
+	Atomic bool
+}
+
+// ApplyPatches applies the patches in order with ApplyPatch, and stops at the first failing one
+// with a PatchesError. The result is then the document before that patch, or doc with Atomic.
+func (opts PatchOptions) ApplyPatches(doc JsonValue, patches []JsonArray) (result JsonValue, err error) {
+	result = doc
+	for i, patch := range patches {
+		var next JsonValue
+		next, err = ApplyPatch(result, patch)
+		if err != nil {
+			if opts.Atomic {
+				result = doc
+			}
+			err = &PatchesError{i, err}
+			return
+		}
+		result = next
+	}
+	return
+}
+
+// SetPointer returns doc with the value at the JSON Pointer replaced, or added if it is a new
+// object member or the array index "-", like a JSON Patch replace or add of one operation.
+// It shares the rest of doc like ApplyPatch, and the error is the PatchError of that operation.
+func SetPointer(doc JsonValue, pointer string, value JsonValue) (JsonValue, error) {
+	op := "add"
+	if tokens, ok := splitPointer(pointer); ok {
+		if _, err := (&patcher{}).get(doc, tokens); err == nil {
+			op = "replace"
+		}
+	}
+	return ApplyPatch(doc, JsonArray{JsonMap{"op": op, "path": pointer, "value": value}})
+}
+
+// Clone is a deep copy of value, which can be modified without affecting value.
+func Clone(value JsonValue) JsonValue {
+	return cloneValue(value)
+}
+
+func cloneValue(value JsonValue) JsonValue {
+	return Map(value, func(path string, v JsonValue) JsonValue { return v })
+}
+
+// a copy of an array or object that can be modified without affecting the members it shares
+func shallowCopy(value JsonValue) JsonValue {
+	switch v := value.(type) {
+	case JsonMap:
+		obj := make(JsonMap, len(v)+1)
+		for key, item := range v {
+			obj[key] = item
+		}
+		return obj
+	case SortedMap:
+		obj := make(SortedMap, len(v), len(v)+1)
+		copy(obj, v)
+		return obj
+	case JsonArray:
+		arr := make(JsonArray, len(v), len(v)+1)
+		copy(arr, v)
+		return arr
+	}
+	return value
+}
+
+// applies one operation, copying the containers it changes, the root may be replaced
+type patcher struct {
+	op   int
+	path string
+}
+
+func (p *patcher) fail(format string, args ...interface{}) error {
+	return &PatchError{p.op, p.path, fmt.Sprintf(format, args...)}
+}
+
+func (p *patcher) apply(doc JsonValue, item JsonValue) (JsonValue, error) {
+	op, ok := item.(JsonMap)
+	if !ok {
+		return doc, p.fail("expect object, got %s", TypeName(item))
+	}
+	name, ok := op["op"].(string)
+	if !ok {
+		return doc, p.fail(`missing or bad "op"`)
+	}
+	p.path, ok = op["path"].(string)
+	if !ok {
+		return doc, p.fail(`missing or bad "path"`)
+	}
+	path, ok := splitPointer(p.path)
+	if !ok {
+		return doc, p.fail("bad JSON Pointer")
+	}
+
+	value, hasValue := op["value"]
+	var from []string
+	switch name {
+	case "add", "replace", "test":
+		if !hasValue {
+			return doc, p.fail(`missing "value"`)
+		}
+	case "move", "copy":
+		fromPath, ok := op["from"].(string)
+		if !ok {
+			return doc, p.fail(`missing or bad "from"`)
+		}
+		from, ok = splitPointer(fromPath)
+		if !ok {
+			return doc, p.fail(`bad JSON Pointer in "from"`)
+		}
+	}
+
+	switch name {
+	case "add":
+		return p.add(doc, path, cloneValue(value))
+	case "remove":
+		return p.remove(doc, path)
+	case "replace":
+		if _, err := p.get(doc, path); err != nil {
+			return doc, err
+		}
+		return p.set(doc, path, cloneValue(value))
+	case "move":
+		if len(from) < len(path) && isPathPrefix(from, path) {
+			return doc, p.fail("cannot move a value into itself")
+		}
+		moved, err := p.get(doc, from)
+		if err != nil {
+			return doc, err
+		}
+		doc, err = p.remove(doc, from)
+		if err != nil {
+			return doc, err
+		}
+		return p.add(doc, path, moved)
+	case "copy":
+		copied, err := p.get(doc, from)
+		if err != nil {
+			return doc, err
+		}
+		return p.add(doc, path, copied)
+	case "test":
+		actual, err := p.get(doc, path)
+		if err != nil {
+			return doc, err
+		}
+		if !Equal(actual, value) {
+			return doc, p.fail("test failed")
+		}
+		return doc, nil
+	default:
+		return doc, p.fail("unknown op %q", name)
+	}
+}
+
+func isPathPrefix(prefix []string, tokens []string) bool {
+	for i := range prefix {
+		if prefix[i] != tokens[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *patcher) get(doc JsonValue, tokens []string) (value JsonValue, err error) {
+	value = doc
+	for _, tok := range tokens {
+		switch v := value.(type) {
+		case JsonMap:
+			var ok bool
+			value, ok = v[tok]
+			if !ok {
+				return nil, p.fail("no member %q", tok)
+			}
+		case SortedMap:
+			var ok bool
+			value, ok = v.Get(tok)
+			if !ok {
+				return nil, p.fail("no member %q", tok)
+			}
+		case JsonArray:
+			idx, ok := pointerArrayIndex(tok)
+			if !ok || idx >= len(v) {
+				return nil, p.fail("bad array index %q", tok)
+			}
+			value = v[idx]
+		default:
+			return nil, p.fail("cannot index %s with %q", TypeName(value), tok)
+		}
+	}
+	return
+}
+
+// calls fn with a copy of the container of the last token and stores the container it returns
+// into copies of the containers above it
+func (p *patcher) update(doc JsonValue, tokens []string, fn func(parent JsonValue, tok string) (JsonValue, error)) (JsonValue, error) {
+	if len(tokens) == 1 {
+		result, err := fn(shallowCopy(doc), tokens[0])
+		if err != nil {
+			return doc, err
+		}
+		return result, nil
+	}
+	child, err := p.get(doc, tokens[:1])
+	if err != nil {
+		return doc, err
+	}
+	child, err = p.update(child, tokens[1:], fn)
+	if err != nil {
+		return doc, err
+	}
+	doc = shallowCopy(doc)
+	switch v := doc.(type) {
+	case JsonMap:
+		v[tokens[0]] = child
+	case SortedMap:
+		v.set(tokens[0], child)
+	case JsonArray:
+		idx, _ := pointerArrayIndex(tokens[0])
+		v[idx] = child
+	}
+	return doc, nil
+}
+
+func (p *patcher) add(doc JsonValue, tokens []string, value JsonValue) (JsonValue, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return p.update(doc, tokens, func(parent JsonValue, tok string) (JsonValue, error) {
+		switch v := parent.(type) {
+		case JsonMap:
+			v[tok] = value
+			return v, nil
+		case SortedMap:
+			return v.set(tok, value), nil
+		case JsonArray:
+			if tok == "-" {
+				return append(v, value), nil
+			}
+			idx, ok := pointerArrayIndex(tok)
+			if !ok || idx > len(v) {
+				return v, p.fail("bad array index %q", tok)
+			}
+			v = append(v, nil)
+			copy(v[idx+1:], v[idx:])
+			v[idx] = value
+			return v, nil
+		default:
+			return parent, p.fail("cannot add to %s", TypeName(parent))
+		}
+	})
+}
+
+func (p *patcher) remove(doc JsonValue, tokens []string) (JsonValue, error) {
+	if len(tokens) == 0 {
+		return doc, p.fail("cannot remove the root")
+	}
+	return p.update(doc, tokens, func(parent JsonValue, tok string) (JsonValue, error) {
+		if _, err := p.get(parent, []string{tok}); err != nil {
+			return parent, err
+		}
+		switch v := parent.(type) {
+		case JsonMap:
+			delete(v, tok)
+		case SortedMap:
+			return v.remove(tok), nil
+		case JsonArray:
+			idx, _ := pointerArrayIndex(tok)
+			return append(v[:idx], v[idx+1:]...), nil
+		}
+		return parent, nil
+	})
+}
+
+// replaces an existing value
+func (p *patcher) set(doc JsonValue, tokens []string, value JsonValue) (JsonValue, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return p.update(doc, tokens, func(parent JsonValue, tok string) (JsonValue, error) {
+		switch v := parent.(type) {
+		case JsonMap:
+			v[tok] = value
+		case SortedMap:
+			v.set(tok, value)
+		case JsonArray:
+			idx, _ := pointerArrayIndex(tok)
+			v[idx] = value
+		}
+		return parent, nil
+	})
+}
@@ -0,0 +1,170 @@
+package json_go
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustPatch(t *testing.T, input string) JsonArray {
+	return MustParse(t, input).(JsonArray)
+}
+
+func TestApplyPatch(t *testing.T) {
+	doc := MustParse(t, `{"a": {"b": [1, 2]}, "c~/": "x"}`)
+	for _, c := range []struct {
+		patch  string
+		expect string
+	}{
+		{`[{"op": "add", "path": "/a/b/1", "value": 9}]`, `{"a": {"b": [1, 9, 2]}, "c~/": "x"}`},
+		{`[{"op": "add", "path": "/a/b/-", "value": 9}]`, `{"a": {"b": [1, 2, 9]}, "c~/": "x"}`},
+		{`[{"op": "add", "path": "/a/d", "value": {}}]`, `{"a": {"b": [1, 2], "d": {}}, "c~/": "x"}`},
+		{`[{"op": "add", "path": "", "value": 1}]`, `1`},
+		{`[{"op": "remove", "path": "/a/b/0"}]`, `{"a": {"b": [2]}, "c~/": "x"}`},
+		{`[{"op": "remove", "path": "/c~0~1"}]`, `{"a": {"b": [1, 2]}}`},
+		{`[{"op": "replace", "path": "/a/b", "value": null}]`, `{"a": {"b": null}, "c~/": "x"}`},
+		{`[{"op": "move", "from": "/a/b", "path": "/b"}]`, `{"a": {}, "b": [1, 2], "c~/": "x"}`},
+		{`[{"op": "copy", "from": "/a/b/1", "path": "/a/b/0"}]`, `{"a": {"b": [2, 1, 2]}, "c~/": "x"}`},
+		{`[{"op": "test", "path": "/a/b/1", "value": 2.0}, {"op": "remove", "path": "/a"}]`, `{"c~/": "x"}`},
+	} {
+		result, err := ApplyPatch(doc, mustPatch(t, c.patch))
+		assert.NoError(t, err, c.patch)
+		assert.Equal(t, MustParse(t, c.expect), result, c.patch)
+	}
+	assert.Equal(t, MustParse(t, `{"a": {"b": [1, 2]}, "c~/": "x"}`), doc)
+
+	for _, c := range []struct {
+		patch string
+		op    int
+	}{
+		{`[{"op": "test", "path": "/a/b/0", "value": 2}]`, 0},
+		{`[{"op": "add", "path": "/a/b/3", "value": 1}]`, 0},
+		{`[{"op": "add", "path": "/x/y", "value": 1}]`, 0},
+		{`[{"op": "add", "path": "/a/b/01", "value": 1}]`, 0},
+		{`[{"op": "remove", "path": "/a/x"}, {"op": "remove", "path": "/a"}]`, 0},
+		{`[{"op": "remove", "path": "/a"}, {"op": "remove", "path": "/a"}]`, 1},
+		{`[{"op": "remove", "path": ""}]`, 0},
+		{`[{"op": "replace", "path": "/x", "value": 1}]`, 0},
+		{`[{"op": "move", "from": "/a", "path": "/a/b/c"}]`, 0},
+		{`[{"op": "copy", "from": "/x", "path": "/y"}]`, 0},
+		{`[{"op": "add", "path": "/x"}]`, 0},
+		{`[{"op": "add", "path": "x", "value": 1}]`, 0},
+		{`[{"op": "add", "path": "/c~1/x", "value": 1}]`, 0},
+		{`[{"op": "nope", "path": ""}]`, 0},
+		{`[{}]`, 0},
+		{`[1]`, 0},
+	} {
+		result, err := ApplyPatch(doc, mustPatch(t, c.patch))
+		var perr *PatchError
+		if assert.True(t, errors.As(err, &perr), c.patch) {
+			assert.Equal(t, c.op, perr.Op(), c.patch)
+		}
+		assert.Equal(t, doc, result, c.patch)
+	}
+	assert.Equal(t, MustParse(t, `{"a": {"b": [1, 2]}, "c~/": "x"}`), doc)
+
+	// the result shares nothing with the patch
+	patch := mustPatch(t, `[{"op": "add", "path": "/v", "value": {"k": [1]}}]`)
+	result, err := ApplyPatch(JsonMap{}, patch)
+	assert.NoError(t, err)
+	result.(JsonMap)["v"].(JsonMap)["k"].(JsonArray)[0] = int64(2)
+	assert.Equal(t, mustPatch(t, `[{"op": "add", "path": "/v", "value": {"k": [1]}}]`), patch)
+}
+
+func TestApplyPatchSharing(t *testing.T) {
+	doc := MustParse(t, `{"a": {"b": [1, {"c": 2}], "d": {"e": 3}}, "f": [4]}`)
+	result, err := ApplyPatch(doc, mustPatch(t, `[
+		{"op": "replace", "path": "/a/b/1/c", "value": 5},
+		{"op": "add", "path": "/a/b/-", "value": 6}
+	]`))
+	assert.NoError(t, err)
+	assert.Equal(t, MustParse(t, `{"a": {"b": [1, {"c": 5}, 6], "d": {"e": 3}}, "f": [4]}`), result)
+
+	// the untouched subtrees are shared
+	at := func(root JsonValue, path string) JsonValue {
+		value, _ := Get(root, path)
+		return value
+	}
+	same := func(x, y JsonValue) bool { return reflect.ValueOf(x).Pointer() == reflect.ValueOf(y).Pointer() }
+	assert.True(t, same(doc.(JsonMap)["f"], result.(JsonMap)["f"]))
+	assert.True(t, same(at(doc, "a.d"), at(result, "a.d")))
+
+	// the changed path is not
+	result.(JsonMap)["x"] = nil
+	result.(JsonMap)["a"].(JsonMap)["y"] = nil
+	result.(JsonMap)["a"].(JsonMap)["b"].(JsonArray)[0] = nil
+	result.(JsonMap)["a"].(JsonMap)["b"].(JsonArray)[1].(JsonMap)["c"] = nil
+	assert.Equal(t, MustParse(t, `{"a": {"b": [1, {"c": 2}], "d": {"e": 3}}, "f": [4]}`), doc)
+
+	// removing from an array doesn't shift the elements of the original
+	arr := MustParse(t, `[1, 2, 3]`)
+	result, err = ApplyPatch(arr, mustPatch(t, `[{"op": "remove", "path": "/0"}]`))
+	assert.NoError(t, err)
+	assert.Equal(t, MustParse(t, `[2, 3]`), result)
+	assert.Equal(t, MustParse(t, `[1, 2, 3]`), arr)
+
+	// copies are only shared with doc until one of them is changed
+	result, err = ApplyPatch(doc, mustPatch(t, `[
+		{"op": "copy", "from": "/a/d", "path": "/g"},
+		{"op": "add", "path": "/g/h", "value": 7}
+	]`))
+	assert.NoError(t, err)
+	assert.Equal(t, MustParse(t, `{"e": 3, "h": 7}`), at(result, "g"))
+	assert.Equal(t, MustParse(t, `{"e": 3}`), at(result, "a.d"))
+}
+
+func TestSetPointer(t *testing.T) {
+	doc := MustParse(t, `{"a": [1, 2], "b": {}}`)
+	result, err := SetPointer(doc, "/a/0", "x")
+	assert.NoError(t, err)
+	assert.Equal(t, MustParse(t, `{"a": ["x", 2], "b": {}}`), result)
+	result, err = SetPointer(result, "/a/-", JsonMap{"k": int64(3)})
+	assert.NoError(t, err)
+	result, err = SetPointer(result, "/b/c", true)
+	assert.NoError(t, err)
+	assert.Equal(t, MustParse(t, `{"a": ["x", 2, {"k": 3}], "b": {"c": true}}`), result)
+	assert.Equal(t, MustParse(t, `{"a": [1, 2], "b": {}}`), doc)
+
+	result, err = SetPointer(doc, "", int64(1))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), result)
+
+	result, err = SetPointer(doc, "/a/5", nil)
+	assert.Equal(t, &PatchError{0, "/a/5", `bad array index "5"`}, err)
+	assert.Equal(t, doc, result)
+	_, err = SetPointer(doc, "/x/y", nil)
+	assert.Equal(t, &PatchError{0, "/x/y", `no member "x"`}, err)
+}
+
+func TestApplyPatches(t *testing.T) {
+	doc := MustParse(t, `{"n": 0}`)
+	patches := []JsonArray{
+		mustPatch(t, `[{"op": "replace", "path": "/n", "value": 1}]`),
+		mustPatch(t, `[{"op": "add", "path": "/m", "value": 2}]`),
+		mustPatch(t, `[{"op": "add", "path": "/k", "value": 3}, {"op": "test", "path": "/n", "value": 0}]`),
+		mustPatch(t, `[{"op": "remove", "path": "/n"}]`),
+	}
+
+	result, err := ApplyPatches(doc, patches[:2])
+	assert.NoError(t, err)
+	assert.Equal(t, MustParse(t, `{"n": 1, "m": 2}`), result)
+
+	result, err = ApplyPatches(doc, patches)
+	var perr *PatchesError
+	if assert.True(t, errors.As(err, &perr)) {
+		assert.Equal(t, 2, perr.Patch())
+		assert.Equal(t, 1, errors.Unwrap(err).(*PatchError).Op())
+	}
+	assert.Equal(t, MustParse(t, `{"n": 1, "m": 2}`), result)
+
+	result, err = PatchOptions{Atomic: true}.ApplyPatches(doc, patches)
+	assert.Error(t, err)
+	assert.Equal(t, MustParse(t, `{"n": 0}`), result)
+	assert.Equal(t, MustParse(t, `{"n": 0}`), doc)
+
+	result, err = ApplyPatches(doc, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, doc, result)
+}
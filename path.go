@@ -0,0 +1,170 @@
+package json_go
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// a dot path like "items.0.id" addresses object members by key and array elements by index.
+// the empty path is the root.
+func splitDotPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+func childAt(value JsonValue, segment string) (child JsonValue, ok bool) {
+	switch v := value.(type) {
+	case JsonMap:
+		child, ok = v[segment]
+	case SortedMap:
+		child, ok = v.Get(segment)
+	case JsonArray:
+		idx, err := strconv.Atoi(segment)
+		if err == nil && idx >= 0 && idx < len(v) {
+			child, ok = v[idx], true
+		}
+	}
+	return
+}
+
+func Get(root JsonValue, path string) (value JsonValue, ok bool) {
+	value, ok = root, true
+	for _, segment := range splitDotPath(path) {
+		value, ok = childAt(value, segment)
+		if !ok {
+			return
+		}
+	}
+	return
+}
+
+// GetInt is Get for an integer. Numbers are taken by value, whatever their type:
+// an int64 as is, a float64 or Decimal only if it is whole and within the int64 range,
+// so 2.0 and 2e0 give 2, while 2.5, 2^63 and NaN fail. Any other value fails too.
+func GetInt(root JsonValue, path string) (i int64, ok bool) {
+	value, ok := Get(root, path)
+	if !ok {
+		return
+	}
+	return toInt(value)
+}
+
+func toInt(value JsonValue) (i int64, ok bool) {
+	switch n := value.(type) {
+	case int64:
+		return n, true
+	case float64:
+		if floatIntEqual(n, int64(n)) {
+			return int64(n), true
+		}
+	case Decimal:
+		return n.Int64()
+	}
+	return 0, false
+}
+
+// GetFloat is Get for any number as a float64. An int64 or Decimal is converted to the nearest float64,
+// which loses precision past 2^53. A Decimal beyond the float64 range fails.
+func GetFloat(root JsonValue, path string) (f float64, ok bool) {
+	value, ok := Get(root, path)
+	if !ok {
+		return
+	}
+	return toFloat(value)
+}
+
+// GetStringSlice is Get for an array of strings. A missing path, a value that is not an array,
+// or an element that is not a string is an UnmarshalError at the JSON Pointer of the offending value.
+func GetStringSlice(root JsonValue, path string) ([]string, error) {
+	return getSlice(root, path, "string", func(v JsonValue) (s string, ok bool) {
+		s, ok = v.(string)
+		return
+	})
+}
+
+// GetIntSlice is GetStringSlice for integers, converted like GetInt.
+func GetIntSlice(root JsonValue, path string) ([]int64, error) {
+	return getSlice(root, path, "integer", toInt)
+}
+
+// GetFloatSlice is GetStringSlice for numbers, converted like GetFloat.
+func GetFloatSlice(root JsonValue, path string) ([]float64, error) {
+	return getSlice(root, path, "number", toFloat)
+}
+
+func getSlice[E any](root JsonValue, path string, expect string, convert func(JsonValue) (E, bool)) (result []E, err error) {
+	pointer := ""
+	for _, segment := range splitDotPath(path) {
+		pointer = pointerJoin(pointer, segment)
+	}
+	value, ok := Get(root, path)
+	if !ok {
+		return nil, &UnmarshalError{path: pointer, msg: "no such path"}
+	}
+	arr, ok := value.(JsonArray)
+	if !ok {
+		return nil, &UnmarshalError{path: pointer, msg: "expect array, got " + TypeName(value)}
+	}
+
+	result = make([]E, len(arr))
+	for i, item := range arr {
+		result[i], ok = convert(item)
+		if !ok {
+			msg := fmt.Sprintf("element %d: expect %s, got %s", i, expect, TypeName(item))
+			return nil, &UnmarshalError{path: pointerIndex(pointer, i), msg: msg}
+		}
+	}
+	return
+}
+
+// GetAll is Get with `*` segments matching every element of an array
+// or every member of an object (in sorted key order).
+// An unmatched path yields an empty result.
+func GetAll(root JsonValue, path string) []JsonValue {
+	values := []JsonValue{root}
+	for _, segment := range splitDotPath(path) {
+		var next []JsonValue
+		for _, value := range values {
+			if segment == "*" {
+				next = append(next, children(value)...)
+			} else if child, ok := childAt(value, segment); ok {
+				next = append(next, child)
+			}
+		}
+		values = next
+	}
+
+	if values == nil {
+		values = []JsonValue{}
+	}
+	return values
+}
+
+func sortedKeys(obj JsonMap) []string {
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func children(value JsonValue) (result []JsonValue) {
+	switch v := value.(type) {
+	case JsonArray:
+		result = append(result, v...)
+	case JsonMap:
+		for _, key := range sortedKeys(v) {
+			result = append(result, v[key])
+		}
+	case SortedMap:
+		for _, kv := range v {
+			result = append(result, kv.value)
+		}
+	}
+	return
+}
@@ -0,0 +1,131 @@
+package json_go
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	root := MustParse(t, `{"a": {"b": [10, {"c": null}]}, "": 1}`)
+	good := func(path string, expect JsonValue) {
+		got, ok := Get(root, path)
+		if assert.True(t, ok, path) {
+			assert.Equal(t, expect, got)
+		}
+	}
+	bad := func(path string) {
+		_, ok := Get(root, path)
+		assert.False(t, ok, path)
+	}
+
+	good("", root)
+	good("a.b.0", int64(10))
+	good("a.b.1.c", nil)
+	bad("a.b.2")
+	bad("a.b.-1")
+	bad("a.b.x")
+	bad("a.b.0.c")
+	bad("x")
+}
+
+func TestGetNumber(t *testing.T) {
+	root := JsonMap{
+		"i": int64(3), "f": 2.0, "half": 2.5, "s": "2", "nan": math.NaN(),
+		"2^53":     float64(1 << 53),
+		"2^53+1":   int64(1<<53 + 1),
+		"2^63":     float64(1 << 63),
+		"-2^63":    float64(-1 << 63),
+		"maxint":   int64(math.MaxInt64),
+		"dec":      Decimal{"4.00"},
+		"bigdec":   Decimal{"9223372036854775808"},
+		"hugedec":  Decimal{"1e400"},
+		"fracdec":  Decimal{"0.5"},
+		"nextdown": math.Nextafter(1<<63, 0),
+	}
+	getInt := func(path string) JsonValue {
+		if i, ok := GetInt(root, path); ok {
+			return i
+		}
+		return nil
+	}
+	getFloat := func(path string) JsonValue {
+		if f, ok := GetFloat(root, path); ok {
+			return f
+		}
+		return nil
+	}
+
+	assert.Equal(t, int64(3), getInt("i"))
+	assert.Equal(t, int64(2), getInt("f"))
+	assert.Equal(t, nil, getInt("half"))
+	assert.Equal(t, nil, getInt("s"))
+	assert.Equal(t, nil, getInt("nan"))
+	assert.Equal(t, nil, getInt("x"))
+	assert.Equal(t, int64(1<<53), getInt("2^53"))
+	assert.Equal(t, int64(1<<53+1), getInt("2^53+1"))
+	assert.Equal(t, nil, getInt("2^63"))
+	assert.Equal(t, int64(-1<<63), getInt("-2^63"))
+	assert.Equal(t, int64(math.MaxInt64), getInt("maxint"))
+	assert.Equal(t, int64(1<<63-1024), getInt("nextdown"))
+	assert.Equal(t, int64(4), getInt("dec"))
+	assert.Equal(t, nil, getInt("bigdec"))
+	assert.Equal(t, nil, getInt("fracdec"))
+
+	assert.Equal(t, 3.0, getFloat("i"))
+	assert.Equal(t, 2.5, getFloat("half"))
+	assert.Equal(t, nil, getFloat("s"))
+	assert.Equal(t, float64(1<<53), getFloat("2^53+1")) // rounded
+	assert.Equal(t, float64(1<<63), getFloat("maxint"))
+	assert.Equal(t, 4.0, getFloat("dec"))
+	assert.Equal(t, nil, getFloat("hugedec"))
+}
+
+func TestGetAll(t *testing.T) {
+	root := MustParse(t, `{"items": [{"id": 1}, {"name": "x"}, {"id": 3}], "m": {"b": {"id": 2}, "a": {"id": 1}}}`)
+	got := func(path string, expect ...JsonValue) {
+		if expect == nil {
+			expect = []JsonValue{}
+		}
+		assert.Equal(t, expect, GetAll(root, path), path)
+	}
+
+	got("items.*.id", int64(1), int64(3))
+	got("items.1.name", "x")
+	got("m.*.id", int64(1), int64(2))
+	got("*.*.id", int64(1), int64(3), int64(1), int64(2))
+	got("items.*.id.*")
+	got("nope.*")
+	got("items.5")
+}
+
+func TestGetSlice(t *testing.T) {
+	root := MustParse(t, `{"a": {"s": ["x", "y"], "i": [1, 2.0, -3], "f": [1, 2.5], "mixed": ["x", 1], "e": []}, "n": 1}`)
+	s, err := GetStringSlice(root, "a.s")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"x", "y"}, s)
+	i, err := GetIntSlice(root, "a.i")
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, -3}, i)
+	f, err := GetFloatSlice(root, "a.i")
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 2, -3}, f)
+	f, err = GetFloatSlice(root, "a.f")
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1, 2.5}, f)
+	s, err = GetStringSlice(root, "a.e")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, s)
+
+	_, err = GetIntSlice(root, "a.f")
+	assert.Equal(t, `UnmarshalError at "/a/f/1": element 1: expect integer, got number`, err.Error())
+	_, err = GetStringSlice(root, "a.mixed")
+	assert.Equal(t, `UnmarshalError at "/a/mixed/1": element 1: expect string, got number`, err.Error())
+	_, err = GetFloatSlice(root, "a.mixed")
+	assert.Equal(t, `UnmarshalError at "/a/mixed/0": element 0: expect number, got string`, err.Error())
+	_, err = GetStringSlice(root, "n")
+	assert.Equal(t, `UnmarshalError at "/n": expect array, got number`, err.Error())
+	_, err = GetStringSlice(root, "a.x")
+	assert.Equal(t, `UnmarshalError at "/a/x": no such path`, err.Error())
+}
@@ -0,0 +1,122 @@
+package json_go
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// append a reference token to a JSON Pointer (RFC 6901)
+func pointerJoin(path string, token string) string {
+	return path + "/" + pointerEscaper.Replace(token)
+}
+
+func pointerIndex(path string, idx int) string {
+	return path + "/" + strconv.Itoa(idx)
+}
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// the unescaped reference tokens of a JSON Pointer, nil for the root
+func splitPointer(path string) (tokens []string, ok bool) {
+	if path == "" {
+		return nil, true
+	}
+	if path[0] != '/' {
+		return nil, false
+	}
+	tokens = strings.Split(path[1:], "/")
+	for i, tok := range tokens {
+		tokens[i] = pointerUnescaper.Replace(tok)
+	}
+	return tokens, true
+}
+
+// an array index token: digits without leading zeros
+func pointerArrayIndex(token string) (idx int, ok bool) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return
+	}
+	for _, ch := range token {
+		if !IsDigit(ch) {
+			return
+		}
+	}
+	idx, err := strconv.Atoi(token)
+	return idx, err == nil
+}
+
+// ExplainPath describes the lookup of a JSON Pointer for debugging: a breadcrumb of the type at each
+// step, like `$ (object) → items (array[3]) → [0] (object) → id (number)`, then the value found
+// on the next lines, indented. On failure it is the breadcrumb up to the step that broke, marked
+// with the reason, and the error is an UnmarshalError at the pointer of that step.
+func ExplainPath(root JsonValue, pointer string) (output string, err error) {
+	tokens, ok := splitPointer(pointer)
+	if !ok {
+		return "", &UnmarshalError{path: pointer, msg: "bad JSON Pointer"}
+	}
+
+	var buf strings.Builder
+	buf.WriteString("$ (" + explainType(root) + ")")
+	value, path := root, ""
+	for _, tok := range tokens {
+		var step, msg string
+		switch v := value.(type) {
+		case JsonMap:
+			step = tok
+			path = pointerJoin(path, tok)
+			value, ok = v[tok]
+			if !ok {
+				msg = fmt.Sprintf("no member %q", tok)
+			}
+		case SortedMap:
+			step = tok
+			path = pointerJoin(path, tok)
+			value, ok = v.Get(tok)
+			if !ok {
+				msg = fmt.Sprintf("no member %q", tok)
+			}
+		case JsonArray:
+			step = "[" + tok + "]"
+			path = pointerJoin(path, tok)
+			idx, ok := pointerArrayIndex(tok)
+			switch {
+			case !ok:
+				msg = fmt.Sprintf("bad array index %q", tok)
+			case idx >= len(v):
+				msg = fmt.Sprintf("index %d out of range for array[%d]", idx, len(v))
+			default:
+				value = v[idx]
+			}
+		default:
+			step = tok
+			path = pointerJoin(path, tok)
+			msg = fmt.Sprintf("cannot index %s with %q", TypeName(value), tok)
+		}
+
+		buf.WriteString(" → " + step)
+		if msg != "" {
+			buf.WriteString(" (" + msg + ")")
+			return buf.String(), &UnmarshalError{path: path, msg: msg}
+		}
+		buf.WriteString(" (" + explainType(value) + ")")
+	}
+
+	text, err := MarshalIndent(value, "  ")
+	if err != nil {
+		return buf.String(), err
+	}
+	buf.WriteString("\n" + text)
+	return buf.String(), nil
+}
+
+func explainType(value JsonValue) string {
+	switch v := value.(type) {
+	case JsonArray:
+		return fmt.Sprintf("array[%d]", len(v))
+	default:
+		return TypeName(value)
+	}
+}
@@ -0,0 +1,52 @@
+package json_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplainPath(t *testing.T) {
+	root := MustParse(t, `{"items": [{"id": 7, "tags": ["a"]}, 2, 3], "a/b": null}`)
+	output, err := ExplainPath(root, "/items/0/id")
+	assert.NoError(t, err)
+	assert.Equal(t, "$ (object) → items (array[3]) → [0] (object) → id (number)\n7", output)
+
+	output, err = ExplainPath(root, "/items/0")
+	assert.NoError(t, err)
+	assert.Equal(t, "$ (object) → items (array[3]) → [0] (object)\n{\n  \"id\": 7,\n  \"tags\": [\n    \"a\"\n  ]\n}", output)
+	output, err = ExplainPath(root, "/a~1b")
+	assert.NoError(t, err)
+	assert.Equal(t, "$ (object) → a/b (null)\nnull", output)
+	output, err = ExplainPath(root, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "$ (object)\n", output[:11])
+
+	for _, c := range []struct {
+		pointer string
+		output  string
+		err     error
+	}{
+		{"/items/5/id", `$ (object) → items (array[3]) → [5] (index 5 out of range for array[3])`,
+			&UnmarshalError{path: "/items/5", msg: "index 5 out of range for array[3]"}},
+		{"/items/x", `$ (object) → items (array[3]) → [x] (bad array index "x")`,
+			&UnmarshalError{path: "/items/x", msg: `bad array index "x"`}},
+		{"/items/a~1b", `$ (object) → items (array[3]) → [a/b] (bad array index "a/b")`,
+			&UnmarshalError{path: "/items/a~1b", msg: `bad array index "a/b"`}},
+		{"/items/1/id", `$ (object) → items (array[3]) → [1] (number) → id (cannot index number with "id")`,
+			&UnmarshalError{path: "/items/1/id", msg: `cannot index number with "id"`}},
+		{"/itemz", `$ (object) → itemz (no member "itemz")`,
+			&UnmarshalError{path: "/itemz", msg: `no member "itemz"`}},
+		{"items", "", &UnmarshalError{path: "items", msg: "bad JSON Pointer"}},
+	} {
+		output, err := ExplainPath(root, c.pointer)
+		assert.Equal(t, c.output, output, c.pointer)
+		assert.Equal(t, c.err, err, c.pointer)
+	}
+
+	sorted, err := ParseWith(`{"a": {"b": true}}`, WithSortedMaps())
+	assert.NoError(t, err)
+	output, err = ExplainPath(sorted, "/a/b")
+	assert.NoError(t, err)
+	assert.Equal(t, "$ (object) → a (object) → b (boolean)\ntrue", output)
+}
@@ -0,0 +1,30 @@
+package json_go
+
+import "sort"
+
+// PositionMap converts rune offsets, like the positions of errors, to line and column numbers.
+type PositionMap struct {
+	newlines []int // offsets of '\n'
+}
+
+func NewPositionMap(input []rune) *PositionMap {
+	m := &PositionMap{}
+	for i, ch := range input {
+		if ch == '\n' {
+			m.newlines = append(m.newlines, i)
+		}
+	}
+	return m
+}
+
+// LineCol returns the 1-based line and column of the rune at offset.
+// A newline belongs to the line it ends.
+func (m *PositionMap) LineCol(offset int) (line, col int) {
+	// newlines before offset
+	n := sort.SearchInts(m.newlines, offset)
+	lineStart := 0
+	if n > 0 {
+		lineStart = m.newlines[n-1] + 1
+	}
+	return n + 1, offset - lineStart + 1
+}
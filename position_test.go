@@ -0,0 +1,38 @@
+package json_go
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionMap(t *testing.T) {
+	m := NewPositionMap([]rune("ab\n啊\r\n\nx"))
+	lineCol := func(offset int, line int, col int) {
+		l, c := m.LineCol(offset)
+		assert.Equal(t, [2]int{line, col}, [2]int{l, c}, "offset %d", offset)
+	}
+	lineCol(0, 1, 1)
+	lineCol(1, 1, 2)
+	lineCol(2, 1, 3) // the newline
+	lineCol(3, 2, 1)
+	lineCol(4, 2, 2)
+	lineCol(5, 2, 3)
+	lineCol(6, 3, 1)
+	lineCol(7, 4, 1)
+	lineCol(8, 4, 2) // end of input
+
+	l, c := NewPositionMap(nil).LineCol(0)
+	assert.Equal(t, [2]int{1, 1}, [2]int{l, c})
+}
+
+func TestPositionMapError(t *testing.T) {
+	input := "{\n  \"a\": [1,\n    2 3]\n}"
+	_, err := Parse(input)
+	var perr *ParseError
+	if assert.True(t, errors.As(err, &perr)) {
+		line, col := NewPositionMap([]rune(input)).LineCol(perr.Pos())
+		assert.Equal(t, [2]int{3, 7}, [2]int{line, col})
+	}
+}
@@ -0,0 +1,198 @@
+package json_go
+
+import "unicode/utf8"
+
+const prettyIndent = "  "
+
+// MarshalPretty keeps an array or object on a single line if it fits in width
+// columns, including the indentation, the key and the trailing comma.
+// Otherwise it is expanded like MarshalIndent with the same rule applied to its children.
+// The members of a []JsonKeyValue are kept in their order, like in Marshal.
+func MarshalPretty(value JsonValue, width int) (output string, err error) {
+	p := prettyPrinter{width: width}
+	err = p.print(value, "", 0, 0, 0)
+	output = string(p.buf)
+	return
+}
+
+type prettyPrinter struct {
+	buf     []byte
+	width   int
+	scratch []byte // for flatWidth
+}
+
+// used is the columns already taken on the current line, trailing is the columns that will follow
+func (p *prettyPrinter) print(value JsonValue, path string, depth int, used int, trailing int) (err error) {
+	// scalars and empty containers are never expanded
+	var expand bool
+	switch v := value.(type) {
+	case JsonArray:
+		expand = len(v) > 0
+	case JsonMap:
+		expand = len(v) > 0
+	case SortedMap:
+		expand = len(v) > 0
+	case []JsonKeyValue:
+		expand = len(v) > 0
+	}
+	if expand {
+		if _, fits := p.flatWidth(value, p.width-used-trailing); !fits {
+			return p.expand(value, path, depth)
+		}
+	}
+
+	m := marshaler{spaced: true, buf: p.buf}
+	err = m.marshal(value, path, depth)
+	p.buf = m.buf
+	return
+}
+
+// the columns of value on a single line, stopping with ok false once it is over budget,
+// so checking every level of a deep document doesn't go through all of it each time.
+// a value that can't be marshaled doesn't fit, its error is reported when its leaf is printed.
+func (p *prettyPrinter) flatWidth(value JsonValue, budget int) (width int, ok bool) {
+	switch v := value.(type) {
+	case JsonArray:
+		width = bracketsWidth(len(v))
+		for _, item := range v {
+			var w int
+			if w, ok = p.flatWidth(item, budget-width); !ok {
+				return
+			}
+			width += w
+		}
+	case JsonMap:
+		// the order doesn't change the width
+		width = bracketsWidth(len(v))
+		for key, item := range v {
+			if width, ok = p.addMember(width, key, item, budget); !ok {
+				return
+			}
+		}
+	case SortedMap:
+		return p.membersWidth(v, budget)
+	case []JsonKeyValue:
+		return p.membersWidth(v, budget)
+	case string:
+		if width, ok = runesWidth(v, budget-2); !ok {
+			return
+		}
+		p.scratch = appendQuote(p.scratch[:0], v)
+		width = utf8.RuneCount(p.scratch)
+	case RawValue:
+		return runesWidth(string(v), budget)
+	default:
+		m := marshaler{spaced: true, buf: p.scratch[:0]}
+		if m.marshal(value, "", 0) != nil {
+			return
+		}
+		p.scratch = m.buf
+		width = utf8.RuneCount(m.buf)
+	}
+	return width, width <= budget
+}
+
+func (p *prettyPrinter) membersWidth(members []JsonKeyValue, budget int) (width int, ok bool) {
+	width, ok = bracketsWidth(len(members)), true
+	for _, kv := range members {
+		if width, ok = p.addMember(width, kv.key, kv.value, budget); !ok {
+			return
+		}
+	}
+	return width, width <= budget
+}
+
+// width plus the member `"key": value`
+func (p *prettyPrinter) addMember(width int, key string, value JsonValue, budget int) (int, bool) {
+	p.scratch = appendQuoteKey(p.scratch[:0], key)
+	width += utf8.RuneCount(p.scratch) + 2
+	if width > budget {
+		return width, false
+	}
+	w, ok := p.flatWidth(value, budget-width)
+	return width + w, ok
+}
+
+// the brackets and the ", " between n items
+func bracketsWidth(n int) int {
+	if n == 0 {
+		return 2
+	}
+	return 2 + 2*(n-1)
+}
+
+// the number of runes of s, counting no further than budget
+func runesWidth(s string, budget int) (width int, ok bool) {
+	for range s {
+		width++
+		if width > budget {
+			return
+		}
+	}
+	return width, true
+}
+
+func (p *prettyPrinter) expand(value JsonValue, path string, depth int) (err error) {
+	used := len(prettyIndent) * (depth + 1)
+	switch v := value.(type) {
+	case JsonArray:
+		p.buf = append(p.buf, '[')
+		for i, item := range v {
+			p.newline(depth + 1)
+			trailing := 0
+			if i+1 < len(v) {
+				trailing = 1
+			}
+			err = p.print(item, pointerIndex(path, i), depth+1, used, trailing)
+			if err != nil {
+				return
+			}
+			if trailing > 0 {
+				p.buf = append(p.buf, ',')
+			}
+		}
+		p.newline(depth)
+		p.buf = append(p.buf, ']')
+	case JsonMap:
+		return p.expandMembers(v.SortedPairs(), path, depth)
+	case SortedMap:
+		return p.expandMembers(v, path, depth)
+	case []JsonKeyValue:
+		return p.expandMembers(v, path, depth)
+	}
+	return
+}
+
+func (p *prettyPrinter) expandMembers(members []JsonKeyValue, path string, depth int) (err error) {
+	used := len(prettyIndent) * (depth + 1)
+	p.buf = append(p.buf, '{')
+	for i, kv := range members {
+		p.newline(depth + 1)
+		start := len(p.buf)
+		p.buf = appendQuoteKey(p.buf, kv.key)
+		p.buf = append(p.buf, ':', ' ')
+		keyLen := utf8.RuneCount(p.buf[start:])
+
+		trailing := 0
+		if i+1 < len(members) {
+			trailing = 1
+		}
+		err = p.print(kv.value, pointerJoin(path, kv.key), depth+1, used+keyLen, trailing)
+		if err != nil {
+			return
+		}
+		if trailing > 0 {
+			p.buf = append(p.buf, ',')
+		}
+	}
+	p.newline(depth)
+	p.buf = append(p.buf, '}')
+	return
+}
+
+func (p *prettyPrinter) newline(depth int) {
+	p.buf = append(p.buf, '\n')
+	for i := 0; i < depth; i++ {
+		p.buf = append(p.buf, prettyIndent...)
+	}
+}
@@ -0,0 +1,79 @@
+package json_go
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalPretty(t *testing.T) {
+	good := func(input string, width int, expect string) {
+		got, err := MarshalPretty(MustParse(t, input), width)
+		if assert.NoError(t, err) {
+			assert.Equal(t, expect, got)
+		}
+	}
+
+	good(`[]`, 0, `[]`)
+	good(`{}`, 0, `{}`)
+	good(`1`, 0, `1`)
+	good(`[1, 2]`, 6, `[1, 2]`)
+	good(`[1, 2]`, 5, "[\n  1,\n  2\n]")
+	good(`{"a": 1}`, 8, `{"a": 1}`)
+	good(`{"a": 1}`, 7, "{\n  \"a\": 1\n}")
+
+	// the "a" line is 14 columns including the comma, the "b" line is 17 columns
+	input := `{"a": [1, 2], "b": {"啊": [3]}}`
+	good(input, 17, "{\n  \"a\": [1, 2],\n  \"b\": {\"啊\": [3]}\n}")
+	good(input, 16, "{\n  \"a\": [1, 2],\n  \"b\": {\n    \"啊\": [3]\n  }\n}")
+	good(input, 13, "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": {\n    \"啊\": [3]\n  }\n}")
+	good(input, 100, `{"a": [1, 2], "b": {"啊": [3]}}`)
+
+	_, err := MarshalPretty(JsonArray{1}, 100)
+	assert.Error(t, err)
+	_, err = MarshalPretty(JsonMap{"a": JsonArray{int64(1), math.NaN()}}, 0)
+	var merr *MarshalError
+	if assert.True(t, errors.As(err, &merr)) {
+		assert.Equal(t, "/a/1", merr.path)
+	}
+
+	pairs := []JsonKeyValue{NewKeyValue("b", JsonArray{int64(1), int64(2)}), NewKeyValue("a", "x")}
+	output, err := MarshalPretty(pairs, 14)
+	assert.NoError(t, err)
+	assert.Equal(t, "{\n  \"b\": [1, 2],\n  \"a\": \"x\"\n}", output)
+	output, err = MarshalPretty(pairs, 23)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"b": [1, 2], "a": "x"}`, output)
+}
+
+func TestPrettyFlatWidth(t *testing.T) {
+	for _, input := range []string{
+		`[]`, `{}`, `[1]`, `"a\n啊"`, `[[], {}, [null, true]]`, `{"a": {"b\"": [1.5, "x"]}, "c": []}`,
+	} {
+		value := MustParse(t, input)
+		m := marshaler{spaced: true}
+		assert.NoError(t, m.marshal(value, "", 0))
+		expect := utf8.RuneCount(m.buf)
+		p := prettyPrinter{}
+		width, ok := p.flatWidth(value, expect)
+		assert.True(t, ok, input)
+		assert.Equal(t, expect, width, input)
+		_, ok = p.flatWidth(value, expect-1)
+		assert.False(t, ok, input)
+	}
+}
+
+func TestMarshalPrettyDeep(t *testing.T) {
+	// every level is expanded, each would take the whole document to measure
+	value := JsonValue(strings.Repeat("x", 100))
+	for i := 0; i < 1000; i++ {
+		value = JsonArray{value, int64(i)}
+	}
+	output, err := MarshalPretty(value, 80)
+	assert.NoError(t, err)
+	assert.Equal(t, 3*1000+1, strings.Count(output, "\n")+1)
+}
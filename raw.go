@@ -0,0 +1,57 @@
+package json_go
+
+import "strings"
+
+// RawValue is the text of a value exactly as it is in the input, for RawPaths.
+// Marshal writes it back unchanged, so a signed or hashed payload can be forwarded byte for byte.
+type RawValue string
+
+// whether the value about to be parsed is at one of RawPaths
+func (p *parser[T]) isRawPath(stack []parseFrame) bool {
+	path, key := "", ""
+	if len(stack) > 0 {
+		top := &stack[len(stack)-1]
+		path = top.childPath()
+		if !top.isArray() {
+			key = top.key
+		}
+	}
+	for _, raw := range p.opts.RawPaths {
+		switch {
+		case raw == "":
+			if len(stack) == 0 {
+				return true
+			}
+		case strings.HasPrefix(raw, "/"):
+			if pointerMatch(raw, path) {
+				return true
+			}
+		default:
+			if len(stack) > 0 && raw == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// check the value at cur like any other and capture its text
+func (p *parser[T]) parseRaw(input []T, cur int) (value JsonValue, next int, err error) {
+	start := skipSpace(input, cur)
+	sub := parser[T]{opts: p.opts, ctx: p.ctx, values: p.values, tokens: p.tokens}
+	sub.opts.RawPaths = nil
+	_, next, err = sub.parseAny(input, start)
+	p.values, p.tokens = sub.values, sub.tokens
+	if err != nil {
+		return
+	}
+	value = RawValue(charsString(input[start:next]))
+	return
+}
+
+func charsString[T char](input []T) string {
+	if b, ok := any(input).([]byte); ok {
+		return string(b)
+	}
+	return string(any(input).([]rune))
+}
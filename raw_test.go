@@ -0,0 +1,70 @@
+package json_go
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawPaths(t *testing.T) {
+	payload := `{ "amount":1.50, "ids" : [3,1,2],"note":"café" }`
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(payload))
+	sig := hex.EncodeToString(mac.Sum(nil))
+	input := `{"event": "paid", "sig": "` + sig + `", "payload": ` + payload + `}`
+
+	value, err := ParseWith(input, WithRawPaths("/payload"))
+	assert.NoError(t, err)
+	envelope := value.(JsonMap)
+	assert.Equal(t, "paid", envelope["event"])
+	assert.Equal(t, RawValue(payload), envelope["payload"])
+
+	// forwarded with a changed envelope, the payload still verifies
+	envelope["event"] = "forwarded"
+	output, err := Marshal(envelope)
+	assert.NoError(t, err)
+	value, err = ParseWith(output, WithRawPaths("payload"))
+	assert.NoError(t, err)
+	raw := value.(JsonMap)["payload"].(RawValue)
+	mac.Reset()
+	mac.Write([]byte(raw))
+	assert.Equal(t, sig, hex.EncodeToString(mac.Sum(nil)))
+	assert.Equal(t, "forwarded", value.(JsonMap)["event"])
+
+	// the same with bytes
+	value, err = Options{RawPaths: []string{"/payload"}}.ParseBytes([]byte(input))
+	assert.NoError(t, err)
+	assert.Equal(t, RawValue(payload), value.(JsonMap)["payload"])
+}
+
+func TestRawPathsMatching(t *testing.T) {
+	input := `{"a": {"data": [1, 2]}, "b": [{"data": true}, {"data": "x", "c": 1}], "data": null}`
+	value, err := ParseWith(input, WithRawPaths("data"))
+	assert.NoError(t, err)
+	assert.Equal(t, JsonMap{
+		"a":    JsonMap{"data": RawValue("[1, 2]")},
+		"b":    JsonArray{JsonMap{"data": RawValue("true")}, JsonMap{"data": RawValue(`"x"`), "c": int64(1)}},
+		"data": RawValue("null"),
+	}, value)
+
+	value, err = ParseWith(input, WithRawPaths("/b/*", "/a/data/1"))
+	assert.NoError(t, err)
+	assert.Equal(t, JsonMap{
+		"a":    JsonMap{"data": JsonArray{int64(1), RawValue("2")}},
+		"b":    JsonArray{RawValue(`{"data": true}`), RawValue(`{"data": "x", "c": 1}`)},
+		"data": nil,
+	}, value)
+
+	value, err = ParseWith(" [1,2] ", WithRawPaths(""))
+	assert.NoError(t, err)
+	assert.Equal(t, RawValue("[1,2]"), value)
+
+	// raw values are still checked
+	_, err = ParseWith(`{"data": [1, }`, WithRawPaths("data"))
+	assert.Equal(t, &ParseError{13, "bad char: '}' (0x7d)"}, err)
+	_, err = ParseWith(`{"data": [1, 2, 3]}`, WithRawPaths("data"), WithMaxTokens(8))
+	assert.Equal(t, &ParseError{13, "more than 8 tokens"}, err)
+}
@@ -0,0 +1,161 @@
+package json_go
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Scanner is a push-model tokenizer. Bytes are fed in arbitrary chunks, which may split
+// a token or a multi-byte character, and complete tokens are taken out as they become available.
+// Unlike Decoder it does not check the structure: strings are reported as Scalar
+// whether they are keys or values, and commas and colons are reported as tokens.
+type Scanner struct {
+	buf    []byte // bytes not consumed yet
+	pos    int    // runes consumed
+	offset int    // bytes consumed
+	done   bool
+	err    error
+}
+
+func (s *Scanner) Feed(chunk []byte) {
+	s.buf = append(s.buf, chunk...)
+}
+
+// NextToken returns false if more input is needed or an error occurred, see Err.
+func (s *Scanner) NextToken() (tok Token, ok bool) {
+	if s.err != nil {
+		return
+	}
+
+	var size int
+	tok, size, ok, s.err = s.scan(s.buf, s.pos, s.offset)
+	if ok {
+		s.pos += utf8.RuneCount(s.buf[:size])
+		s.offset += size
+		s.buf = s.buf[size:]
+	}
+	return
+}
+
+// Done signals the end of input. Tokens still buffered can be taken with NextToken,
+// a dangling partial token is an error.
+func (s *Scanner) Done() error {
+	s.done = true
+	buf, pos, offset := s.buf, s.pos, s.offset
+	for s.err == nil {
+		_, size, ok, err := s.scan(buf, pos, offset)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			break
+		}
+		pos += utf8.RuneCount(buf[:size])
+		offset += size
+		buf = buf[size:]
+	}
+	return s.err
+}
+
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// scan the token at the start of buf, size includes the leading whitespace.
+// ok is false on error or if the token may continue in the next chunk.
+func (s *Scanner) scan(buf []byte, pos int, offset int) (tok Token, size int, ok bool, err error) {
+	for size < len(buf) && (buf[size] == ' ' || buf[size] == '\t' || buf[size] == '\n' || buf[size] == '\r') {
+		size++
+		pos++
+	}
+	if size == len(buf) {
+		return
+	}
+	offset += size
+
+	start := size
+	switch ch := buf[start]; {
+	case ch == '[':
+		tok.Kind = BeginArray
+	case ch == ']':
+		tok.Kind = EndArray
+	case ch == '{':
+		tok.Kind = BeginObject
+	case ch == '}':
+		tok.Kind = EndObject
+	case ch == ',':
+		tok.Kind = Comma
+	case ch == ':':
+		tok.Kind = Colon
+	case ch == '"':
+		end := start + 1
+		for ; end < len(buf) && buf[end] != '"'; end++ {
+			if buf[end] == '\\' {
+				end++
+			}
+		}
+		if end >= len(buf) && !s.done {
+			return
+		}
+		if end < len(buf) {
+			end++ // the closing quote
+		} else {
+			end = len(buf)
+		}
+		tok.Kind = Scalar
+		tok.Value, err = scanScalar(buf[start:end], pos, offset, func(input []rune, cur int) (JsonValue, int, error) {
+			return ParseString(input, cur)
+		})
+		return tok, end, err == nil, err
+	case ch == '-' || ('0' <= ch && ch <= '9'):
+		return s.scanRun(buf, start, pos, offset, isNumberChar, ParseNum)
+	case 'a' <= ch && ch <= 'z':
+		return s.scanRun(buf, start, pos, offset, isLetter, ParseBoolNull)
+	default:
+		code, _, derr := ReadCode(buf, start)
+		if derr != nil {
+			if len(buf)-start < utf8.UTFMax && !s.done {
+				return // maybe a split character
+			}
+			err = shiftDecodingError(derr, offset-start)
+			err.(*DecodingError).runes = pos
+			return
+		}
+		err = &ParseError{pos, fmt.Sprintf("bad char: '%c' (%#x)", code, code)}
+		return
+	}
+	return tok, start + 1, true, nil
+}
+
+// a number or literal ends at the first byte not accepted, which may be in the next chunk
+func (s *Scanner) scanRun(buf []byte, start int, pos int, offset int, accept func(rune) bool, parse ParseFunc) (tok Token, size int, ok bool, err error) {
+	end := start
+	for end < len(buf) && accept(rune(buf[end])) {
+		end++
+	}
+	if end == len(buf) && !s.done {
+		return
+	}
+
+	tok.Kind = Scalar
+	tok.Value, err = scanScalar(buf[start:end], pos, offset, func(input []rune, cur int) (value JsonValue, next int, err error) {
+		value, next, err = parse(input, cur)
+		if err == nil && next != len(input) {
+			err = &ParseError{next, "not terminated"}
+		}
+		return
+	})
+	return tok, end, err == nil, err
+}
+
+func scanScalar(raw []byte, pos int, offset int, parse ParseFunc) (value JsonValue, err error) {
+	var input []rune
+	input, err = Decode(raw)
+	if err != nil {
+		err = shiftDecodingError(err, offset)
+		err.(*DecodingError).runes += pos
+		return
+	}
+	value, _, err = parse(input, 0)
+	return value, shiftError(err, pos)
+}
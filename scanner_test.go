@@ -0,0 +1,106 @@
+package json_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func scanAll(t *testing.T, chunks []string) (tokens []Token, err error) {
+	s := Scanner{}
+	for _, chunk := range chunks {
+		s.Feed([]byte(chunk))
+		for {
+			tok, ok := s.NextToken()
+			if !ok {
+				break
+			}
+			tokens = append(tokens, tok)
+		}
+		if s.Err() != nil {
+			return tokens, s.Err()
+		}
+	}
+
+	err = s.Done()
+	for {
+		tok, ok := s.NextToken()
+		if !ok {
+			break
+		}
+		tokens = append(tokens, tok)
+	}
+	return
+}
+
+func TestScanner(t *testing.T) {
+	input := ` {"a啊": [12, -1.5e3, "x\"\\y", true, null]} 7`
+	expect := []Token{
+		{BeginObject, nil}, {Scalar, "a啊"}, {Colon, nil},
+		{BeginArray, nil}, {Scalar, int64(12)}, {Comma, nil}, {Scalar, -1.5e3}, {Comma, nil},
+		{Scalar, "x\"\\y"}, {Comma, nil}, {Scalar, true}, {Comma, nil}, {Scalar, nil},
+		{EndArray, nil}, {EndObject, nil}, {Scalar, int64(7)},
+	}
+
+	tokens, err := scanAll(t, []string{input})
+	if assert.NoError(t, err) {
+		assert.Equal(t, expect, tokens)
+	}
+
+	// split at every byte, including inside multi-byte characters and escapes
+	chunks := []string{}
+	for i := 0; i < len(input); i++ {
+		chunks = append(chunks, input[i:i+1])
+	}
+	tokens, err = scanAll(t, chunks)
+	if assert.NoError(t, err) {
+		assert.Equal(t, expect, tokens)
+	}
+}
+
+func TestScannerWaits(t *testing.T) {
+	s := Scanner{}
+	s.Feed([]byte(`12`))
+	_, ok := s.NextToken()
+	assert.False(t, ok)
+	assert.NoError(t, s.Err())
+
+	s.Feed([]byte(`3,"ab`))
+	tok, ok := s.NextToken()
+	assert.True(t, ok)
+	assert.Equal(t, Token{Scalar, int64(123)}, tok)
+	tok, _ = s.NextToken()
+	assert.Equal(t, Token{Kind: Comma}, tok)
+	_, ok = s.NextToken()
+	assert.False(t, ok)
+
+	s.Feed([]byte(`"`))
+	tok, ok = s.NextToken()
+	assert.True(t, ok)
+	assert.Equal(t, Token{Scalar, "ab"}, tok)
+}
+
+func TestScannerBad(t *testing.T) {
+	bad := func(chunks ...string) {
+		_, err := scanAll(t, chunks)
+		assert.Error(t, err, chunks)
+		t.Log(chunks, "\t", err)
+	}
+
+	bad(`"abc`)
+	bad(`"abc\`)
+	bad(`[tru`)
+	bad(`[1.`)
+	bad(`-`)
+	bad(`@`)
+	bad(`"\x"`)
+	bad(`01`)
+	bad("\xe5\x95")
+	bad("[\xff]")
+	bad(`[`, `"a`, "\xe5", "\x95", "\x8a", `\q"]`)
+
+	_, err := scanAll(t, []string{`[1, `, `"\q"]`})
+	assert.Equal(t, &ParseError{6, "bad escape char: 'q' (0x71)"}, err)
+	_, err = scanAll(t, []string{`["啊`, "\xff\"]"})
+	assert.Equal(t, &DecodingError{5, 0xff, "bad leading char", 5, 6, 3, false}, err)
+}
@@ -0,0 +1,252 @@
+package json_go
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"unicode/utf8"
+)
+
+type SchemaError struct {
+	path string // JSON Pointer of the offending value
+	msg  string
+}
+
+func (err *SchemaError) Error() string {
+	return fmt.Sprintf("SchemaError at %q: %s", err.path, err.msg)
+}
+
+func (err *SchemaError) Path() string {
+	return err.path
+}
+
+// ValidateSchema checks value against a subset of JSON Schema:
+// type, required, properties, items, enum, minimum, maximum,
+// minLength, maxLength and pattern. Unknown keywords are ignored.
+// All violations are collected instead of stopping at the first one.
+func ValidateSchema(value JsonValue, schema JsonValue) (errs []error) {
+	v := schemaValidator{}
+	v.validate(value, schema, "")
+	return v.errs
+}
+
+type schemaValidator struct {
+	errs []error
+}
+
+func (v *schemaValidator) fail(path string, format string, args ...interface{}) {
+	v.errs = append(v.errs, &SchemaError{path, fmt.Sprintf(format, args...)})
+}
+
+func (v *schemaValidator) validate(value JsonValue, schema JsonValue, path string) {
+	switch s := schema.(type) {
+	case bool: // true accepts anything, false accepts nothing
+		if !s {
+			v.fail(path, "rejected by false schema")
+		}
+		return
+	case JsonMap, SortedMap:
+		m, _ := asMap(s)
+		v.validateMap(value, m, path)
+	default:
+		v.fail(path, "bad schema: expect object or bool, got %s", TypeName(schema))
+	}
+}
+
+func (v *schemaValidator) validateMap(value JsonValue, schema JsonMap, path string) {
+	if t, ok := schema["type"]; ok {
+		v.checkType(value, t, path)
+	}
+	if enum, ok := schema["enum"]; ok {
+		v.checkEnum(value, enum, path)
+	}
+
+	switch val := value.(type) {
+	case JsonMap, SortedMap:
+		obj, _ := asMap(val)
+		v.checkObject(obj, schema, path)
+	case JsonArray:
+		if items, ok := schema["items"]; ok {
+			for i, item := range val {
+				v.validate(item, items, pointerIndex(path, i))
+			}
+		}
+	case string:
+		v.checkString(val, schema, path)
+	case int64, float64:
+		v.checkNumber(value, schema, path)
+	}
+}
+
+func TypeName(value JsonValue) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case int64, float64, Decimal:
+		return "number"
+	case JsonArray:
+		return "array"
+	case JsonMap, SortedMap:
+		return "object"
+	default:
+		return fmt.Sprintf("unknown(%T)", value)
+	}
+}
+
+func isSchemaType(value JsonValue, name string) bool {
+	switch name {
+	case "integer":
+		switch n := value.(type) {
+		case int64:
+			return true
+		case float64:
+			return floatIntEqual(n, int64(n))
+		}
+		return false
+	default:
+		return TypeName(value) == name
+	}
+}
+
+func (v *schemaValidator) checkType(value JsonValue, t JsonValue, path string) {
+	var names []string
+	switch tv := t.(type) {
+	case string:
+		names = []string{tv}
+	case JsonArray:
+		for _, item := range tv {
+			name, ok := item.(string)
+			if !ok {
+				v.fail(path, "bad schema: type must be string or array of string")
+				return
+			}
+			names = append(names, name)
+		}
+	default:
+		v.fail(path, "bad schema: type must be string or array of string")
+		return
+	}
+
+	for _, name := range names {
+		if isSchemaType(value, name) {
+			return
+		}
+	}
+	v.fail(path, "expect type %v, got %s", names, TypeName(value))
+}
+
+func (v *schemaValidator) checkEnum(value JsonValue, enum JsonValue, path string) {
+	arr, ok := enum.(JsonArray)
+	if !ok {
+		v.fail(path, "bad schema: enum must be array")
+		return
+	}
+	for _, item := range arr {
+		if Equal(value, item) {
+			return
+		}
+	}
+	v.fail(path, "value not in enum")
+}
+
+func (v *schemaValidator) checkObject(obj JsonMap, schema JsonMap, path string) {
+	if required, ok := schema["required"]; ok {
+		arr, ok := required.(JsonArray)
+		if !ok {
+			v.fail(path, "bad schema: required must be array")
+		}
+		for _, item := range arr {
+			key, ok := item.(string)
+			if !ok {
+				v.fail(path, "bad schema: required must be array of string")
+				continue
+			}
+			if _, ok := obj[key]; !ok {
+				v.fail(path, "missing required property %q", key)
+			}
+		}
+	}
+
+	if properties, ok := schema["properties"]; ok {
+		props, ok := asMap(properties)
+		if !ok {
+			v.fail(path, "bad schema: properties must be object")
+			return
+		}
+
+		keys := make([]string, 0, len(props))
+		for key := range props {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if sub, ok := obj[key]; ok {
+				v.validate(sub, props[key], pointerJoin(path, key))
+			}
+		}
+	}
+}
+
+func (v *schemaValidator) checkString(str string, schema JsonMap, path string) {
+	length := utf8.RuneCountInString(str)
+	if limit, ok := v.schemaInt(schema, "minLength", path); ok && int64(length) < limit {
+		v.fail(path, "string length %d < minLength %d", length, limit)
+	}
+	if limit, ok := v.schemaInt(schema, "maxLength", path); ok && int64(length) > limit {
+		v.fail(path, "string length %d > maxLength %d", length, limit)
+	}
+
+	if pattern, ok := schema["pattern"]; ok {
+		expr, ok := pattern.(string)
+		if !ok {
+			v.fail(path, "bad schema: pattern must be string")
+			return
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			v.fail(path, "bad schema: bad pattern %q: %v", expr, err)
+			return
+		}
+		if !re.MatchString(str) {
+			v.fail(path, "string does not match pattern %q", expr)
+		}
+	}
+}
+
+func (v *schemaValidator) checkNumber(num JsonValue, schema JsonMap, path string) {
+	f, _ := toFloat(num)
+	if limit, ok := v.schemaNumber(schema, "minimum", path); ok && f < limit {
+		v.fail(path, "%v < minimum %v", num, limit)
+	}
+	if limit, ok := v.schemaNumber(schema, "maximum", path); ok && f > limit {
+		v.fail(path, "%v > maximum %v", num, limit)
+	}
+}
+
+func (v *schemaValidator) schemaNumber(schema JsonMap, keyword string, path string) (limit float64, ok bool) {
+	raw, exists := schema[keyword]
+	if !exists {
+		return
+	}
+	limit, ok = toFloat(raw)
+	if !ok {
+		v.fail(path, "bad schema: %s must be number", keyword)
+	}
+	return
+}
+
+func (v *schemaValidator) schemaInt(schema JsonMap, keyword string, path string) (limit int64, ok bool) {
+	raw, exists := schema[keyword]
+	if !exists {
+		return
+	}
+	limit, ok = raw.(int64)
+	if !ok {
+		v.fail(path, "bad schema: %s must be integer", keyword)
+	}
+	return
+}
@@ -0,0 +1,72 @@
+package json_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func MustParse(t *testing.T, input string) JsonValue {
+	value, err := Parse(input)
+	if err != nil {
+		t.Fatal(input, err)
+	}
+	return value
+}
+
+func TestValidateSchema(t *testing.T) {
+	schema := MustParse(t, `{
+		"type": "object",
+		"required": ["name", "age"],
+		"properties": {
+			"name": {"type": "string", "minLength": 1, "maxLength": 4},
+			"age": {"type": "integer", "minimum": 0, "maximum": 150},
+			"role": {"enum": ["admin", "user"]},
+			"tags": {"type": "array", "items": {"type": "string", "pattern": "^[a-z]+$"}},
+			"a/b": {"type": ["number", "null"]}
+		}
+	}`)
+
+	good := func(input string) {
+		errs := ValidateSchema(MustParse(t, input), schema)
+		assert.Empty(t, errs, input)
+	}
+	bad := func(input string, paths ...string) {
+		errs := ValidateSchema(MustParse(t, input), schema)
+		got := []string{}
+		for _, err := range errs {
+			got = append(got, err.(*SchemaError).Path())
+			t.Log(input, "\t", err)
+		}
+		assert.Equal(t, paths, got, input)
+	}
+
+	good(`{"name": "bob", "age": 20}`)
+	good(`{"name": "啊啊啊啊", "age": 20.0, "role": "user", "tags": [], "a/b": null}`)
+	good(`{"name": "bob", "age": 0, "tags": ["x", "yz"], "a/b": 1.5}`)
+
+	bad(`[]`, "")
+	bad(`{}`, "", "")
+	bad(`{"name": "", "age": -1}`, "/age", "/name")
+	bad(`{"name": "bobby", "age": 1.5}`, "/age", "/name")
+	bad(`{"name": "bob", "age": 151, "role": "root"}`, "/age", "/role")
+	bad(`{"name": "bob", "age": 1, "tags": ["ok", "NO", 1]}`, "/tags/1", "/tags/2")
+	bad(`{"name": "bob", "age": 1, "a/b": "x"}`, "/a~1b")
+}
+
+func TestValidateSchemaBadSchema(t *testing.T) {
+	bad := func(value string, schema string) {
+		errs := ValidateSchema(MustParse(t, value), MustParse(t, schema))
+		assert.NotEmpty(t, errs)
+		t.Log(schema, "\t", errs)
+	}
+
+	bad(`1`, `1`)
+	bad(`1`, `false`)
+	bad(`1`, `{"type": 1}`)
+	bad(`1`, `{"minimum": "0"}`)
+	bad(`"a"`, `{"pattern": "("}`)
+	bad(`{}`, `{"required": "a"}`)
+
+	assert.Empty(t, ValidateSchema(MustParse(t, `1`), MustParse(t, `true`)))
+}
@@ -0,0 +1,26 @@
+package json_go
+
+// LooksLikeJSON is a cheap heuristic for routing input by content: it reports whether the first byte
+// after a UTF-8 BOM and whitespace can start a JSON value. It does not allocate or validate, see Valid.
+func LooksLikeJSON(input []byte) bool {
+	i := 0
+	if len(input) >= 3 && input[0] == 0xef && input[1] == 0xbb && input[2] == 0xbf {
+		i = 3
+	}
+	for ; i < len(input); i++ {
+		switch ch := input[i]; ch {
+		case ' ', '\t', '\n', '\r':
+		case '{', '[', '"', 't', 'f', 'n', '-':
+			return true
+		default:
+			return '0' <= ch && ch <= '9'
+		}
+	}
+	return false
+}
+
+// Valid reports whether input is a complete JSON document.
+func Valid(input []byte) bool {
+	_, err := ParseBytes(input)
+	return err == nil
+}
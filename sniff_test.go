@@ -0,0 +1,28 @@
+package json_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLooksLikeJSON(t *testing.T) {
+	for _, input := range []string{`{}`, ` [`, "\r\n\t\"", `true`, `f`, `nope`, `-`, `0`, `9x`, "\xef\xbb\xbf {"} {
+		assert.True(t, LooksLikeJSON([]byte(input)), input)
+	}
+	for _, input := range []string{``, `   `, `<html>`, `a=1`, `+1`, `.5`, `'x'`, "\xef\xbb", "\xef\xbb\xbf"} {
+		assert.False(t, LooksLikeJSON([]byte(input)), input)
+	}
+
+	input := []byte(" \n[1, 2]")
+	assert.Equal(t, 0.0, testing.AllocsPerRun(100, func() { LooksLikeJSON(input) }))
+}
+
+func TestValid(t *testing.T) {
+	assert.True(t, Valid([]byte(` {"a": [1, null]} `)))
+	assert.True(t, Valid([]byte(`0`)))
+	assert.False(t, Valid([]byte(`nope`)))
+	assert.False(t, Valid([]byte(`{"a": 1,}`)))
+	assert.False(t, Valid([]byte("\"\xff\"")))
+	assert.False(t, Valid(nil))
+}
@@ -0,0 +1,104 @@
+package json_go
+
+import "sort"
+
+// SortedMap is an object as its members sorted by key, without repeated keys, from Options.SortedMaps.
+// It takes much less memory than a JsonMap for small objects, and Get is a binary search.
+// The rest of the package takes it as the JsonMap of the same members: Equal(sm, sm.ToMap()) is true,
+// and Walk, Get, Query, ApplyPatch and Unmarshal go through it in the same way.
+type SortedMap []JsonKeyValue
+
+func (m SortedMap) Get(key string) (value JsonValue, ok bool) {
+	i := m.search(key)
+	if i < len(m) && m[i].key == key {
+		return m[i].value, true
+	}
+	return
+}
+
+// the index of key, or where it would be inserted
+func (m SortedMap) search(key string) int {
+	return sort.Search(len(m), func(i int) bool { return m[i].key >= key })
+}
+
+// set the member in place, or insert it, which may reuse the capacity of m
+func (m SortedMap) set(key string, value JsonValue) SortedMap {
+	i := m.search(key)
+	if i < len(m) && m[i].key == key {
+		m[i].value = value
+		return m
+	}
+	m = append(m, JsonKeyValue{})
+	copy(m[i+1:], m[i:])
+	m[i] = JsonKeyValue{key, value}
+	return m
+}
+
+// remove the member in place if present
+func (m SortedMap) remove(key string) SortedMap {
+	i := m.search(key)
+	if i < len(m) && m[i].key == key {
+		return append(m[:i], m[i+1:]...)
+	}
+	return m
+}
+
+func (m SortedMap) Keys() []string {
+	keys := make([]string, len(m))
+	for i, kv := range m {
+		keys[i] = kv.key
+	}
+	return keys
+}
+
+// ToMap is a shallow copy as a JsonMap, nested SortedMaps are kept.
+func (m SortedMap) ToMap() JsonMap {
+	obj := make(JsonMap, len(m))
+	for _, kv := range m {
+		obj[kv.key] = kv.value
+	}
+	return obj
+}
+
+// the members of a JsonMap or SortedMap, a SortedMap is copied
+func asMap(value JsonValue) (obj JsonMap, ok bool) {
+	switch v := value.(type) {
+	case JsonMap:
+		return v, true
+	case SortedMap:
+		return v.ToMap(), true
+	}
+	return
+}
+
+func isObject(value JsonValue) bool {
+	switch value.(type) {
+	case JsonMap, SortedMap:
+		return true
+	}
+	return false
+}
+
+// SortedPairs is the members of obj sorted by key, to go through a JsonMap in a stable order.
+// It is a SortedMap of the same members, see Key and Value for each.
+func (obj JsonMap) SortedPairs() []JsonKeyValue {
+	pairs := make(SortedMap, 0, len(obj))
+	for key, value := range obj {
+		pairs = append(pairs, JsonKeyValue{key, value})
+	}
+	sortPairs(pairs)
+	return pairs
+}
+
+func sortPairs(m SortedMap) {
+	if len(m) > 12 {
+		sort.Slice(m, func(i, j int) bool { return m[i].key < m[j].key })
+		return
+	}
+	// insertion sort, sort.Slice allocates
+	for i := 1; i < len(m); i++ {
+		for j := i; j > 0 && m[j].key < m[j-1].key; j-- {
+			m[j], m[j-1] = m[j-1], m[j]
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package json_go
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortedMaps(t *testing.T) {
+	opts := NewOptions(WithSortedMaps())
+	value, err := opts.Parse(`{"b": 1, "a": {"y": [{}], "x": null}, "c": "s"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, SortedMap{
+		{"a", SortedMap{{"x", nil}, {"y", JsonArray{SortedMap{}}}}},
+		{"b", int64(1)},
+		{"c", "s"},
+	}, value)
+
+	obj := value.(SortedMap)
+	got, ok := obj.Get("b")
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), got)
+	_, ok = obj.Get("bb")
+	assert.False(t, ok)
+	_, ok = SortedMap{}.Get("")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"a", "b", "c"}, obj.Keys())
+	assert.Equal(t, JsonMap{"a": obj[0].value, "b": int64(1), "c": "s"}, obj.ToMap())
+
+	output, err := Marshal(value)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":{"x":null,"y":[{}]},"b":1,"c":"s"}`, output)
+	last := func(keys []string) []string {
+		if len(keys) == 0 {
+			return nil
+		}
+		return keys[len(keys)-1:]
+	}
+	output, err = MarshalOptions{KeyOrder: last}.Marshal(value)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"c":"s","a":{"y":[{}],"x":null},"b":1}`, output)
+	assert.Equal(t, "object", TypeName(value))
+	assert.NoError(t, ValidateForMarshal(value))
+}
+
+func TestSortedMapsDuplicateKeys(t *testing.T) {
+	// small objects and large ones with an index
+	for _, n := range []int{0, sortedMapIndexMin * 2} {
+		var members []string
+		for i := 0; i < n; i++ {
+			members = append(members, fmt.Sprintf(`"k%02d": %d`, i, i))
+		}
+		filler := strings.Join(append(members, ""), ", ")
+		input := `{` + filler + `"b": 1, "a": 2, "b": 3}`
+		expect := func(mode DuplicateKeyMode, b JsonValue) {
+			value, err := NewOptions(WithSortedMaps(), WithDuplicateKeys(mode)).Parse(input)
+			if !assert.NoError(t, err) {
+				return
+			}
+			obj := value.(SortedMap)
+			assert.Equal(t, n+2, len(obj))
+			got, _ := obj.Get("b")
+			assert.Equal(t, b, got, mode)
+			got, _ = obj.Get("a")
+			assert.Equal(t, int64(2), got)
+			expect, _ := NewOptions(WithDuplicateKeys(mode)).Parse(input)
+			assert.Equal(t, expect, value.(SortedMap).ToMap())
+		}
+		expect(LastWins, int64(3))
+		expect(FirstWins, int64(1))
+		expect(Collect, JsonArray{int64(1), int64(3)})
+
+		_, err := NewOptions(WithSortedMaps(), WithDuplicateKeys(Error)).Parse(input)
+		assert.Equal(t, &ParseError{len(input) - 7, `duplicate key "b"`}, err)
+	}
+}
+
+func TestSortedMapsPartial(t *testing.T) {
+	p := parser[rune]{opts: Options{SortedMaps: true}, partial: true}
+	value, err := p.parseDocument([]rune(`[{"b": 1, "a": [2`))
+	assert.NoError(t, err)
+	assert.True(t, p.incomplete)
+	assert.Equal(t, JsonArray{SortedMap{{"a", JsonArray{int64(2)}}, {"b", int64(1)}}}, value)
+}
+
+func BenchmarkSortedMaps(b *testing.B) {
+	items := make([]string, 10000)
+	for i := range items {
+		items[i] = fmt.Sprintf(`{"id": %d, "name": "n%d", "ok": true}`, i, i)
+	}
+	input := []rune("[" + strings.Join(items, ",") + "]")
+
+	for _, opts := range []Options{{}, {SortedMaps: true}} {
+		b.Run(fmt.Sprintf("SortedMaps=%v", opts.SortedMaps), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _ = opts.ParseRunes(input)
+			}
+		})
+	}
+}
+
+func TestJsonMapSortedPairs(t *testing.T) {
+	obj := MustParse(t, `{"b": 2, "a": [1], "c": {"d": null}, "": true}`).(JsonMap)
+	var keys []string
+	var values []JsonValue
+	for _, kv := range obj.SortedPairs() {
+		keys = append(keys, kv.Key())
+		values = append(values, kv.Value())
+	}
+	assert.Equal(t, []string{"", "a", "b", "c"}, keys)
+	assert.Equal(t, []JsonValue{true, JsonArray{int64(1)}, int64(2), JsonMap{"d": nil}}, values)
+	assert.Equal(t, []JsonKeyValue{}, JsonMap{}.SortedPairs())
+
+	// and marshals the same
+	many := JsonMap{}
+	for i := 0; i < 20; i++ {
+		many[fmt.Sprint(i)] = int64(i)
+	}
+	pairs, err := Marshal(many.SortedPairs())
+	assert.NoError(t, err)
+	expect, _ := Marshal(many)
+	assert.Equal(t, expect, pairs)
+}
+
+// the tree of input with SortedMaps
+func mustParseSorted(t *testing.T, input string) JsonValue {
+	value, err := ParseWith(input, WithSortedMaps())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+const sortedMapsDoc = `{"b": {"c": [1, {"d": "x"}], "e": true}, "a": null, " k ": "v  w"}`
+
+func TestSortedMapsEqual(t *testing.T) {
+	sm, m := mustParseSorted(t, sortedMapsDoc), MustParse(t, sortedMapsDoc)
+	assert.True(t, Equal(sm, sm))
+	assert.True(t, Equal(sm, m))
+	assert.True(t, Equal(m, sm))
+	assert.False(t, Equal(sm, mustParseSorted(t, `{"b": {"c": [1, {"d": "y"}], "e": true}, "a": null, " k ": "v  w"}`)))
+	assert.False(t, Equal(sm, mustParseSorted(t, `{"b": {}, "a": null}`)))
+	assert.False(t, Equal(SortedMap{{"a", nil}}, SortedMap{{"b", nil}}))
+
+	_, _, _, equal := FirstDiff(sm, m)
+	assert.True(t, equal)
+	path, x, y, equal := FirstDiff(sm, mustParseSorted(t, `{"b": {"c": [1, {"d": 2}], "e": true}, "a": null, " k ": "v  w"}`))
+	assert.False(t, equal)
+	assert.Equal(t, "/b/c/1/d", path)
+	assert.Equal(t, "x", x)
+	assert.Equal(t, int64(2), y)
+
+	assert.True(t, EqualUnordered(mustParseSorted(t, `{"a": [1, 2]}`), mustParseSorted(t, `{"a": [2, 1]}`), []string{"/a"}))
+	assert.True(t, EqualUnordered(mustParseSorted(t, `{"a": {"b": [1, 2]}}`), MustParse(t, `{"a": {"b": [2, 1]}}`), []string{"/a/b"}))
+	assert.False(t, EqualUnordered(mustParseSorted(t, `{"a": {"b": [1, 2]}}`), MustParse(t, `{"a": {"b": [2, 1]}}`), []string{"/a"}))
+	assert.True(t, EqualIgnoring(sm, mustParseSorted(t, `{"b": {"c": [1, {"d": "x"}], "e": false}, "a": null, " k ": "v  w"}`), []string{"/b/e"}))
+	assert.True(t, Matches(sm, mustParseSorted(t, `{"b": {"c": "<array>", "e": "*"}, "a": "<null>", " k ": "<string>"}`)))
+	assert.False(t, Matches(sm, JsonMap{"b": "<object>"}))
+}
+
+func TestSortedMapsDiff(t *testing.T) {
+	sm := mustParseSorted(t, sortedMapsDoc)
+	assert.Equal(t, JsonArray{}, Diff(sm, sm))
+	assert.Equal(t, JsonArray{}, Diff(sm, MustParse(t, sortedMapsDoc)))
+	other := mustParseSorted(t, `{"b": {"c": [1, {"d": "x"}]}, "a": 1, " k ": "v  w"}`)
+	patch := Diff(sm, other)
+	assert.Equal(t, MustParse(t, `[{"op": "replace", "path": "/a", "value": 1}, {"op": "remove", "path": "/b/e"}]`), patch)
+	patched, err := ApplyPatch(sm, patch)
+	assert.NoError(t, err)
+	assert.True(t, Equal(other, patched))
+
+	result, changes := MergeWithLog(sm, mustParseSorted(t, `{"a": 1, "b": {"e": null}}`))
+	assert.True(t, Equal(other, result))
+	assert.Equal(t, []Change{{"/a", Replaced, nil, int64(1)}, {"/b/e", Removed, true, nil}}, changes)
+	assert.True(t, Equal(other, Merge(sm, mustParseSorted(t, `{"a": 1, "b": {"e": null}}`))))
+}
+
+func TestSortedMapsPaths(t *testing.T) {
+	sm := mustParseSorted(t, sortedMapsDoc)
+	got, ok := Get(sm, "b.c.1.d")
+	assert.True(t, ok)
+	assert.Equal(t, "x", got)
+	_, ok = Get(sm, "b.x")
+	assert.False(t, ok)
+
+	result, err := Query(sm, "$.b.c[1].d")
+	assert.NoError(t, err)
+	assert.Equal(t, []JsonValue{"x"}, result)
+	result, err = Query(sm, "$..d")
+	assert.NoError(t, err)
+	assert.Equal(t, []JsonValue{"x"}, result)
+	result, err = Query(sm, "$.*")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(result))
+
+	assert.Equal(t, []string{"/b/c/1/d"}, FindKey(sm, "d"))
+	assert.Equal(t, Paths(MustParse(t, sortedMapsDoc)), Paths(sm))
+	assert.Equal(t, []string{"", "/b", "/b/c", "/b/c/1"}, PathsOf(sm, ContainerPaths))
+	assert.Equal(t, Leaves(MustParse(t, sortedMapsDoc)), Leaves(sm))
+}
+
+func TestSortedMapsTransform(t *testing.T) {
+	sm := mustParseSorted(t, sortedMapsDoc)
+	clone := Clone(sm)
+	assert.Equal(t, sm, clone)
+	clone.(SortedMap)[2].value.(SortedMap)[1].value = false
+	assert.Equal(t, true, sm.(SortedMap)[2].value.(SortedMap)[1].value)
+
+	upper := Map(sm, func(path string, v JsonValue) JsonValue {
+		if s, ok := v.(string); ok {
+			return strings.ToUpper(s)
+		}
+		return v
+	})
+	assert.Equal(t, SortedMap{{" k ", "V  W"}, {"a", nil}, {"b", SortedMap{{"c", JsonArray{int64(1), SortedMap{{"d", "X"}}}}, {"e", true}}}}, upper)
+
+	collapsed := WhitespaceOptions{Keys: true}.CollapseWhitespace(sm)
+	assert.Equal(t, SortedMap{{"a", nil}, {"b", SortedMap{{"c", JsonArray{int64(1), SortedMap{{"d", "x"}}}}, {"e", true}}}, {"k", "v w"}}, collapsed)
+}
+
+func TestSortedMapsPatch(t *testing.T) {
+	sm := mustParseSorted(t, sortedMapsDoc)
+	patched, err := ApplyPatch(sm, MustParse(t, `[
+		{"op": "add", "path": "/b/aa", "value": 1},
+		{"op": "replace", "path": "/b/c/1/d", "value": "y"},
+		{"op": "remove", "path": "/a"},
+		{"op": "move", "from": "/b/e", "path": "/z"},
+		{"op": "test", "path": "/b/aa", "value": 1}
+	]`).(JsonArray))
+	assert.NoError(t, err)
+	assert.Equal(t, SortedMap{
+		{" k ", "v  w"},
+		{"b", SortedMap{{"aa", int64(1)}, {"c", JsonArray{int64(1), SortedMap{{"d", "y"}}}}}},
+		{"z", true},
+	}, patched)
+	assert.Equal(t, mustParseSorted(t, sortedMapsDoc), sm)
+
+	_, err = ApplyPatch(sm, MustParse(t, `[{"op": "remove", "path": "/b/x"}]`).(JsonArray))
+	assert.Equal(t, &PatchError{0, "/b/x", `no member "x"`}, err)
+	replaced, err := SetPointer(sm, "/b/e", false)
+	assert.NoError(t, err)
+	got, _ := Get(replaced, "b.e")
+	assert.Equal(t, false, got)
+}
+
+func TestSortedMapsUnmarshal(t *testing.T) {
+	type inner struct {
+		C []interface{} `json:"c"`
+		E bool          `json:"e"`
+	}
+	var got struct {
+		A *int              `json:"a"`
+		B inner             `json:"b"`
+		K map[string]string `json:" k "`
+	}
+	err := UnmarshalValue(mustParseSorted(t, `{"b": {"c": [1, {"d": "x"}], "e": true}, "a": null, " k ": {"v": "w"}}`), &got)
+	assert.NoError(t, err)
+	assert.Nil(t, got.A)
+	assert.Equal(t, inner{[]interface{}{int64(1), map[string]interface{}{"d": "x"}}, true}, got.B)
+	assert.Equal(t, map[string]string{"v": "w"}, got.K)
+
+	var byID map[int]string
+	assert.NoError(t, UnmarshalValue(mustParseSorted(t, `{"2": "b", "1": "a"}`), &byID))
+	assert.Equal(t, map[int]string{1: "a", 2: "b"}, byID)
+}
+
+func TestSortedMapsMarshalPretty(t *testing.T) {
+	sm := mustParseSorted(t, sortedMapsDoc)
+	expect, err := MarshalPretty(MustParse(t, sortedMapsDoc), 30)
+	assert.NoError(t, err)
+	output, err := MarshalPretty(sm, 30)
+	assert.NoError(t, err)
+	assert.Equal(t, expect, output)
+	assert.Equal(t, "{\n  \" k \": \"v  w\",\n  \"a\": null,\n  \"b\": {\n    \"c\": [1, {\"d\": \"x\"}],\n    \"e\": true\n  }\n}", output)
+	output, err = MarshalPretty(SortedMap{}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "{}", output)
+}
+
+func TestSortedMapsOther(t *testing.T) {
+	errs := ValidateSchema(
+		mustParseSorted(t, `{"b": {"c": [1, {"d": "x"}], "e": true}}`),
+		mustParseSorted(t, `{"type": "object", "required": ["a"], "properties": {"b": {"properties": {"e": {"type": "string"}}}}}`))
+	if assert.Equal(t, 2, len(errs)) {
+		assert.Equal(t, "", errs[0].(*SchemaError).Path())
+		assert.Equal(t, "/b/e", errs[1].(*SchemaError).Path())
+	}
+
+	output, err := ToCSV(mustParseSorted(t, `[{"b": 1, "a": "x"}, {"a": "y"}]`).(JsonArray))
+	assert.NoError(t, err)
+	assert.Equal(t, "a,b\nx,1\ny,\n", output)
+
+	merged, err := MergeArraysByKey(
+		mustParseSorted(t, `[{"name": "a", "v": 1}]`).(JsonArray),
+		mustParseSorted(t, `[{"name": "a", "v": 2}, {"name": "b"}]`).(JsonArray), "name")
+	assert.NoError(t, err)
+	assert.True(t, Equal(MustParse(t, `[{"name": "a", "v": 2}, {"name": "b"}]`), merged))
+}
@@ -0,0 +1,141 @@
+package json_go
+
+import "strings"
+
+// Map returns a new tree with every leaf (a value that is not an array or object)
+// replaced by fn(path, leaf), where path is the JSON Pointer of the leaf.
+// Arrays and objects are copied, the original tree is left untouched.
+func Map(root JsonValue, fn func(path string, v JsonValue) JsonValue) JsonValue {
+	return mapValue(root, "", fn)
+}
+
+func mapValue(value JsonValue, path string, fn func(path string, v JsonValue) JsonValue) JsonValue {
+	switch v := value.(type) {
+	case JsonArray:
+		arr := make(JsonArray, len(v))
+		for i, item := range v {
+			arr[i] = mapValue(item, pointerIndex(path, i), fn)
+		}
+		return arr
+	case JsonMap:
+		obj := make(JsonMap, len(v))
+		for key, item := range v {
+			obj[key] = mapValue(item, pointerJoin(path, key), fn)
+		}
+		return obj
+	case SortedMap:
+		obj := make(SortedMap, len(v))
+		for i, kv := range v {
+			obj[i] = JsonKeyValue{kv.key, mapValue(kv.value, pointerJoin(path, kv.key), fn)}
+		}
+		return obj
+	default:
+		return fn(path, value)
+	}
+}
+
+// ReplaceValues returns a new tree with the leaves matched by match swapped for replacement.
+func ReplaceValues(root JsonValue, match func(path string, v JsonValue) bool, replacement JsonValue) JsonValue {
+	return Map(root, func(path string, v JsonValue) JsonValue {
+		if match(path, v) {
+			return replacement
+		}
+		return v
+	})
+}
+
+// Coerce returns a new tree with the strings that are valid JSON numbers or booleans,
+// like "42", "-1.5e3" or "true", replaced by the typed value. Keys and other strings are kept.
+func Coerce(root JsonValue) JsonValue {
+	return Map(root, func(path string, v JsonValue) JsonValue {
+		s, ok := v.(string)
+		if !ok {
+			return v
+		}
+		switch s {
+		case "true":
+			return true
+		case "false":
+			return false
+		}
+		if s == "" || !(s[0] == '-' || IsDigit(rune(s[0]))) {
+			return v
+		}
+		input := []rune(s)
+		num, next, err := ParseNum(input, 0)
+		if err != nil || next != len(input) {
+			return v
+		}
+		return num
+	})
+}
+
+var newlineReplacer = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// NormalizeNewlines returns a new tree with "\r\n" and lone "\r" replaced by "\n" in every string value.
+// Object keys are kept as is, see NormalizeNewlinesAndKeys.
+func NormalizeNewlines(value JsonValue) JsonValue {
+	return mapStrings(value, false, newlineReplacer.Replace)
+}
+
+// NormalizeNewlinesAndKeys is NormalizeNewlines for object keys too.
+// Keys that become the same keep the member of the last original key in sorted order.
+func NormalizeNewlinesAndKeys(value JsonValue) JsonValue {
+	return mapStrings(value, true, newlineReplacer.Replace)
+}
+
+// CollapseWhitespace returns a new tree where every string value has its runs of whitespace replaced
+// by a single space, and no whitespace at either end, like "  a \n\t b " to "a b".
+// Whitespace is as in unicode.IsSpace. This changes the content of the strings, so it is for
+// normalizing free text like user input, not for values where spacing matters.
+// Object keys are kept as is, see WhitespaceOptions.
+func CollapseWhitespace(value JsonValue) JsonValue {
+	return WhitespaceOptions{}.CollapseWhitespace(value)
+}
+
+type WhitespaceOptions struct {
+	// collapse object keys too. Keys that become the same keep the member of the last original key in sorted order.
+	Keys bool
+}
+
+func (opts WhitespaceOptions) CollapseWhitespace(value JsonValue) JsonValue {
+	return mapStrings(value, opts.Keys, collapseWhitespace)
+}
+
+func collapseWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// a new tree with fn applied to every string value, and to keys with keys set
+func mapStrings(value JsonValue, keys bool, fn func(string) string) JsonValue {
+	if !keys {
+		return Map(value, func(path string, v JsonValue) JsonValue {
+			if s, ok := v.(string); ok {
+				return fn(s)
+			}
+			return v
+		})
+	}
+
+	switch v := value.(type) {
+	case JsonArray:
+		arr := make(JsonArray, len(v))
+		for i, item := range v {
+			arr[i] = mapStrings(item, keys, fn)
+		}
+		return arr
+	case JsonMap:
+		obj := make(JsonMap, len(v))
+		for _, key := range sortedKeys(v) {
+			obj[fn(key)] = mapStrings(v[key], keys, fn)
+		}
+		return obj
+	case SortedMap:
+		// keys may change order or become the same
+		return SortedMap(mapStrings(v.ToMap(), keys, fn).(JsonMap).SortedPairs())
+	case string:
+		return fn(v)
+	default:
+		return value
+	}
+}
@@ -0,0 +1,71 @@
+package json_go
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap(t *testing.T) {
+	root := MustParse(t, `{"a": [1, "x", {"b/c": null}], "d": {}}`)
+	paths := []string{}
+	got := Map(root, func(path string, v JsonValue) JsonValue {
+		paths = append(paths, path)
+		if s, ok := v.(string); ok {
+			return strings.ToUpper(s)
+		}
+		return v
+	})
+
+	assert.Equal(t, MustParse(t, `{"a": [1, "X", {"b/c": null}], "d": {}}`), got)
+	assert.ElementsMatch(t, []string{"/a/0", "/a/1", "/a/2/b~1c"}, paths)
+	assert.Equal(t, MustParse(t, `{"a": [1, "x", {"b/c": null}], "d": {}}`), root)
+
+	assert.Equal(t, int64(2), Map(int64(1), func(string, JsonValue) JsonValue { return int64(2) }))
+}
+
+func TestReplaceValues(t *testing.T) {
+	root := MustParse(t, `{"env": "dev", "services": [{"env": "dev"}, {"env": "test", "name": "dev"}]}`)
+	got := ReplaceValues(root, func(path string, v JsonValue) bool {
+		return strings.HasSuffix(path, "/env") && v == "dev"
+	}, "prod")
+
+	assert.Equal(t, MustParse(t, `{"env": "prod", "services": [{"env": "prod"}, {"env": "test", "name": "dev"}]}`), got)
+	assert.Equal(t, "dev", root.(JsonMap)["env"])
+	assert.Equal(t, "dev", root.(JsonMap)["services"].(JsonArray)[0].(JsonMap)["env"])
+}
+
+func TestCoerce(t *testing.T) {
+	root := MustParse(t, `{"count": "42", "active": "true", "off": "false", "ratio": "-1.5e3",
+		"list": ["0", "007", " 1", "1 ", "1.", "null", "True", "", "x", 3], "42": "name"}`)
+	assert.Equal(t, JsonMap{
+		"count": int64(42), "active": true, "off": false, "ratio": -1.5e3,
+		"list": JsonArray{int64(0), "007", " 1", "1 ", "1.", "null", "True", "", "x", int64(3)},
+		"42":   "name",
+	}, Coerce(root))
+	assert.Equal(t, "42", root.(JsonMap)["count"])
+	assert.Equal(t, int64(7), Coerce("7"))
+}
+
+func TestNormalizeNewlines(t *testing.T) {
+	root := MustParse(t, `{"a\r\nb": ["x\r\ny\rz\n", 1, {"c\r": "\r\r\n"}], "d": "plain"}`)
+	assert.Equal(t, MustParse(t, `{"a\r\nb": ["x\ny\nz\n", 1, {"c\r": "\n\n"}], "d": "plain"}`), NormalizeNewlines(root))
+	assert.Equal(t, MustParse(t, `{"a\nb": ["x\ny\nz\n", 1, {"c\n": "\n\n"}], "d": "plain"}`), NormalizeNewlinesAndKeys(root))
+	// the input is untouched
+	assert.Equal(t, "x\r\ny\rz\n", root.(JsonMap)["a\r\nb"].(JsonArray)[0])
+
+	assert.Equal(t, JsonMap{"a\n": int64(2)}, NormalizeNewlinesAndKeys(MustParse(t, `{"a\r": 1, "a\r\n": 2}`)))
+	assert.Equal(t, "\n", NormalizeNewlinesAndKeys("\r"))
+}
+
+func TestCollapseWhitespace(t *testing.T) {
+	root := MustParse(t, `{"  a  b ": ["  x \n\t y  ", "", " ", 1, {"c": "\u00a0z\u3000"}], "d": "plain"}`)
+	assert.Equal(t, MustParse(t, `{"  a  b ": ["x y", "", "", 1, {"c": "z"}], "d": "plain"}`), CollapseWhitespace(root))
+	assert.Equal(t, MustParse(t, `{"a b": ["x y", "", "", 1, {"c": "z"}], "d": "plain"}`), WhitespaceOptions{Keys: true}.CollapseWhitespace(root))
+	assert.Equal(t, "a b", CollapseWhitespace(" a  b"))
+
+	// keys that collapse to the same
+	root = MustParse(t, `{"a b": 1, "a  b": 2, " a b": 3}`)
+	assert.Equal(t, JsonMap{"a b": int64(1)}, WhitespaceOptions{Keys: true}.CollapseWhitespace(root))
+}
@@ -0,0 +1,208 @@
+package json_go
+
+import (
+	"sort"
+	"strings"
+)
+
+// Trivia is a side table of the blank lines between the elements and members of a parsed tree,
+// like between the sections of a config file, keyed by JSON Pointer. With MarshalTrivia,
+// an edited tree is written back with the blank lines kept where they were.
+//
+// As the paths are positions, inserting or removing array elements moves the blank lines of the
+// elements after it to other elements. Whitespace other than blank lines is not kept.
+type Trivia struct {
+	before map[string]int // blank lines before the element or member at the path
+	end    map[string]int // blank lines before the closing bracket of the container at the path
+	// line comments before the element or member at the path, or the document at "", without the "//"
+	comments map[string][]string
+	order    *ObjectOrder
+}
+
+func ParseTrivia(input string) (value JsonValue, trivia *Trivia, err error) {
+	return Options{}.ParseTrivia(input)
+}
+
+// ParseTrivia is ParseOrdered that also records the blank lines inside arrays and objects.
+func (opts Options) ParseTrivia(input string) (value JsonValue, trivia *Trivia, err error) {
+	if opts.SortedMaps {
+		return nil, nil, ErrSortedMapsOrder
+	}
+	var decoded []rune
+	decoded, err = DecodeString(input)
+	if err != nil {
+		return
+	}
+	trivia = &Trivia{
+		before: map[string]int{}, end: map[string]int{}, comments: map[string][]string{},
+		order: &ObjectOrder{keys: map[uintptr][]string{}},
+	}
+	p := parser[rune]{opts: opts, order: trivia.order, trivia: trivia}
+	value, err = p.parseDocument(decoded)
+	return
+}
+
+// the number of empty lines in a run of whitespace
+func blankLines[T char](space []T) (n int) {
+	for _, ch := range space {
+		if ch == '\n' {
+			n++
+		}
+	}
+	if n > 0 {
+		n--
+	}
+	return
+}
+
+type lineComment struct {
+	pos     int // of the "//"
+	ownLine bool
+	text    string
+}
+
+// the input with the line comments replaced by spaces, so that positions don't change.
+// input is copied if it has any.
+func stripLineComments[T char](input []T) (output []T, comments []lineComment) {
+	output = input
+	inString, escaped, ownLine := false, false, true
+	for i := 0; i < len(input); i++ {
+		ch := input[i]
+		switch {
+		case inString:
+			if escaped {
+				escaped = false
+			} else if ch == '\\' {
+				escaped = true
+			} else if ch == '"' {
+				inString = false
+			}
+		case ch == '"':
+			inString = true
+		case ch == '/' && i+1 < len(input) && input[i+1] == '/':
+			if len(comments) == 0 {
+				output = append([]T(nil), input...)
+			}
+			end := i + 2
+			for end < len(input) && input[end] != '\n' {
+				end++
+			}
+			comments = append(comments, lineComment{i, ownLine, charsString(input[i+2 : end])})
+			for j := i; j < end; j++ {
+				output[j] = ' '
+			}
+			i = end - 1
+			continue
+		}
+		if ch == '\n' {
+			ownLine = true
+		} else if ch != ' ' && ch != '\t' && ch != '\r' {
+			ownLine = false
+		}
+	}
+	return
+}
+
+// the blank lines and the comments on their own lines in input[cur:start], before the value at path
+func (p *parser[T]) recordBefore(path string, input []T, cur int, start int) {
+	space := input[cur:start]
+	var texts []string
+	first := sort.Search(len(p.comments), func(i int) bool { return p.comments[i].pos >= cur })
+	for _, c := range p.comments[first:] {
+		if c.pos >= start {
+			break
+		}
+		if c.ownLine {
+			if texts == nil {
+				space = input[cur:c.pos]
+			}
+			texts = append(texts, c.text)
+		}
+	}
+	if texts != nil {
+		p.trivia.comments[path] = texts
+	}
+	if path != "" {
+		p.trivia.recordBefore(path, blankLines(space))
+	}
+}
+
+func (trivia *Trivia) recordBefore(path string, n int) {
+	if n > 0 {
+		trivia.before[path] = n
+	}
+}
+
+func (trivia *Trivia) recordEnd(path string, n int) {
+	if n > 0 {
+		trivia.end[path] = n
+	}
+}
+
+// BlankLinesBefore is the number of blank lines before the element or member at path.
+func (trivia *Trivia) BlankLinesBefore(path string) int {
+	if trivia == nil {
+		return 0
+	}
+	return trivia.before[path]
+}
+
+// BlankLinesAtEnd is the number of blank lines after the last element or member of the container at path.
+func (trivia *Trivia) BlankLinesAtEnd(path string) int {
+	if trivia == nil {
+		return 0
+	}
+	return trivia.end[path]
+}
+
+// SetBlankLinesBefore changes the blank lines before the element or member at path, like for a new section.
+func (trivia *Trivia) SetBlankLinesBefore(path string, n int) {
+	if n > 0 {
+		trivia.before[path] = n
+	} else {
+		delete(trivia.before, path)
+	}
+}
+
+// CommentsBefore is the text of the line comments before the element or member at path,
+// or the document at "", without the "//". See PreserveComments.
+func (trivia *Trivia) CommentsBefore(path string) []string {
+	if trivia == nil {
+		return nil
+	}
+	return trivia.comments[path]
+}
+
+// SetCommentsBefore changes the line comments before the element or member at path,
+// each written after a "//" on its own line. A text with line breaks is split into one comment
+// per line. nil removes them.
+func (trivia *Trivia) SetCommentsBefore(path string, comments []string) {
+	var lines []string
+	for _, text := range comments {
+		text = strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+		lines = append(lines, strings.Split(text, "\n")...)
+	}
+	if len(lines) > 0 {
+		trivia.comments[path] = lines
+	} else {
+		delete(trivia.comments, path)
+	}
+}
+
+// Order is the member order recorded with the trivia.
+func (trivia *Trivia) Order() *ObjectOrder {
+	return trivia.order
+}
+
+// MarshalTrivia is MarshalIndent with the blank lines and comments of trivia, and the keys in its recorded order.
+func MarshalTrivia(value JsonValue, indent string, trivia *Trivia) (output string, err error) {
+	m := marshaler{indent: indent, pretty: true, trivia: trivia, order: trivia.order}
+	for _, text := range trivia.CommentsBefore("") {
+		m.buf = append(m.buf, "//"...)
+		m.buf = append(m.buf, text...)
+		m.buf = append(m.buf, '\n')
+	}
+	err = m.marshal(value, "", 0)
+	output = string(m.buf)
+	return
+}
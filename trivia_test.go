@@ -0,0 +1,160 @@
+package json_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTrivia(t *testing.T) {
+	input := `{
+  "name": "app",
+  "version": 2,
+
+  "server": {
+    "host": "localhost",
+
+
+    "port": 80
+  },
+
+  "list": [
+    1,
+
+    2
+
+  ]
+}`
+	value, trivia, err := ParseTrivia(input)
+	assert.NoError(t, err)
+	assert.Equal(t, MustParse(t, input), value)
+	assert.Equal(t, 0, trivia.BlankLinesBefore("/name"))
+	assert.Equal(t, 1, trivia.BlankLinesBefore("/server"))
+	assert.Equal(t, 2, trivia.BlankLinesBefore("/server/port"))
+	assert.Equal(t, 1, trivia.BlankLinesBefore("/list/1"))
+	assert.Equal(t, 1, trivia.BlankLinesAtEnd("/list"))
+	assert.Equal(t, 0, trivia.BlankLinesAtEnd(""))
+
+	// unchanged layout round trips
+	output, err := MarshalTrivia(value, "  ", trivia)
+	assert.NoError(t, err)
+	assert.Equal(t, input, output)
+
+	// only the edited member changes
+	value.(JsonMap)["server"].(JsonMap)["port"] = int64(8080)
+	value.(JsonMap)["debug"] = true
+	trivia.SetBlankLinesBefore("/debug", 1)
+	trivia.SetBlankLinesBefore("/list/1", 0)
+	output, err = MarshalTrivia(value, "  ", trivia)
+	assert.NoError(t, err)
+	assert.Equal(t, `{
+  "name": "app",
+  "version": 2,
+
+  "server": {
+    "host": "localhost",
+
+
+    "port": 8080
+  },
+
+  "list": [
+    1,
+    2
+
+  ],
+
+  "debug": true
+}`, output)
+
+	// compact output has no blank lines
+	output, err = MarshalOrdered(value, trivia.Order())
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"app","version":2,"server":{"host":"localhost","port":8080},"list":[1,2],"debug":true}`, output)
+}
+
+func TestParseTriviaCompact(t *testing.T) {
+	value, trivia, err := ParseTrivia("[[\n\n1], {}, [\n\n]]")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, trivia.BlankLinesBefore("/0/0"))
+	assert.Equal(t, 0, trivia.BlankLinesBefore("/1"))
+	output, err := MarshalTrivia(value, "\t", trivia)
+	assert.NoError(t, err)
+	assert.Equal(t, "[\n\t[\n\n\t\t1\n\t],\n\t{},\n\t[]\n]", output)
+
+	_, _, err = ParseTrivia(`[1,`)
+	assert.Error(t, err)
+}
+
+func TestParseTriviaComments(t *testing.T) {
+	input := `// app config
+{
+  // the name
+  "name": "app // not a comment",
+  "version": 2, // dropped
+
+  // server
+  // settings
+  "server": {
+    "port": 80
+  },
+  "list": [
+    // first
+    1,
+    2 // dropped
+  ]
+}`
+	value, trivia, err := NewOptions(WithPreserveComments()).ParseTrivia(input)
+	assert.NoError(t, err)
+	assert.Equal(t, JsonMap{
+		"name": "app // not a comment", "version": int64(2), "server": JsonMap{"port": int64(80)}, "list": JsonArray{int64(1), int64(2)},
+	}, value)
+	assert.Equal(t, []string{" app config"}, trivia.CommentsBefore(""))
+	assert.Equal(t, []string{" the name"}, trivia.CommentsBefore("/name"))
+	assert.Equal(t, []string(nil), trivia.CommentsBefore("/version"))
+	assert.Equal(t, []string{" server", " settings"}, trivia.CommentsBefore("/server"))
+	assert.Equal(t, 1, trivia.BlankLinesBefore("/server"))
+	assert.Equal(t, []string{" first"}, trivia.CommentsBefore("/list/0"))
+
+	// comments on their own lines round trip
+	trivia.SetCommentsBefore("/version", []string{" bumped"})
+	trivia.SetCommentsBefore("/list/0", nil)
+	output, err := MarshalTrivia(value, "  ", trivia)
+	assert.NoError(t, err)
+	assert.Equal(t, `// app config
+{
+  // the name
+  "name": "app // not a comment",
+  // bumped
+  "version": 2,
+
+  // server
+  // settings
+  "server": {
+    "port": 80
+  },
+  "list": [
+    1,
+    2
+  ]
+}`, output)
+
+	// line breaks in a comment make more comments
+	trivia.SetCommentsBefore("/name", []string{" a\n b\r\n c\r d"})
+	assert.Equal(t, []string{" a", " b", " c", " d"}, trivia.CommentsBefore("/name"))
+	output, err = MarshalTrivia(JsonMap{"name": "x"}, "  ", trivia)
+	assert.NoError(t, err)
+	assert.Equal(t, "// app config\n{\n  // a\n  // b\n  // c\n  // d\n  \"name\": \"x\"\n}", output)
+	value, _, err = NewOptions(WithPreserveComments()).ParseTrivia(output)
+	assert.NoError(t, err)
+	assert.Equal(t, JsonMap{"name": "x"}, value)
+
+	// without the option, comments are errors and positions are kept with it
+	_, _, err = ParseTrivia(input)
+	assert.Equal(t, &ParseError{0, "bad char: '/' (0x2f)"}, err)
+	_, err = ParseWith("[1, // x\n 2,]", WithPreserveComments())
+	assert.Equal(t, &ParseError{12, "bad char: ']' (0x5d)"}, err)
+	value, err = NewOptions(WithPreserveComments()).ParseBytes([]byte("// é\n[\"//\\\"//\"]//"))
+	assert.NoError(t, err)
+	assert.Equal(t, JsonArray{`//"//`}, value)
+}
@@ -0,0 +1,417 @@
+package json_go
+
+import (
+	"encoding"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// JsonUnmarshaler is implemented by types that decode themselves from an already parsed JsonValue.
+type JsonUnmarshaler interface {
+	UnmarshalJSONValue(JsonValue) error
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*JsonUnmarshaler)(nil)).Elem()
+	jsonValueType       = reflect.TypeOf((*JsonValue)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+type UnmarshalError struct {
+	path  string // JSON Pointer of the offending value
+	msg   string
+	cause error
+}
+
+func (err *UnmarshalError) Error() string {
+	if err.cause != nil {
+		return fmt.Sprintf("UnmarshalError at %q: %s: %v", err.path, err.msg, err.cause)
+	}
+	return fmt.Sprintf("UnmarshalError at %q: %s", err.path, err.msg)
+}
+
+func (err *UnmarshalError) Unwrap() error {
+	return err.cause
+}
+
+type UnmarshalOptions struct {
+	// error on an object key without a matching struct field instead of ignoring it
+	DisallowUnknownFields bool
+}
+
+// Unmarshal parses input and stores the result in the struct pointed to by out.
+func Unmarshal(input string, out interface{}) (err error) {
+	return UnmarshalOptions{}.Unmarshal(input, out)
+}
+
+// UnmarshalValue stores a parsed value in the struct pointed to by out using reflection.
+// JSON keys are matched to fields by their `json` tag or name, falling back to a case-insensitive match.
+// Keys without a matching field are ignored.
+func UnmarshalValue(value JsonValue, out interface{}) (err error) {
+	return UnmarshalOptions{}.UnmarshalValue(value, out)
+}
+
+func (opts UnmarshalOptions) Unmarshal(input string, out interface{}) (err error) {
+	var value JsonValue
+	value, err = Parse(input)
+	if err != nil {
+		return
+	}
+	return opts.UnmarshalValue(value, out)
+}
+
+func (opts UnmarshalOptions) UnmarshalValue(value JsonValue, out interface{}) (err error) {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return &UnmarshalError{msg: fmt.Sprintf("expect non-nil pointer, got %T", out)}
+	}
+	u := unmarshaler{opts: opts}
+	return u.unmarshal(value, rv.Elem(), "")
+}
+
+type unmarshaler struct {
+	opts UnmarshalOptions
+}
+
+func mismatch(value JsonValue, rv reflect.Value, path string) error {
+	return &UnmarshalError{path: path, msg: fmt.Sprintf("cannot store %s in %s", TypeName(value), rv.Type())}
+}
+
+func (u *unmarshaler) unmarshal(value JsonValue, rv reflect.Value, path string) (err error) {
+	if rv.CanAddr() && rv.Addr().Type().Implements(jsonUnmarshalerType) {
+		err = rv.Addr().Interface().(JsonUnmarshaler).UnmarshalJSONValue(value)
+		if err != nil {
+			err = &UnmarshalError{path: path, msg: fmt.Sprintf("UnmarshalJSONValue of %s", rv.Type()), cause: err}
+		}
+		return
+	}
+
+	switch rv.Type() {
+	case timeType, durationType:
+		return unmarshalTime(value, rv, path)
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return mismatch(value, rv, path)
+		}
+		rv.SetBool(b)
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return mismatch(value, rv, path)
+		}
+		rv.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := wholeNumber(value)
+		if !ok {
+			return mismatch(value, rv, path)
+		}
+		if rv.OverflowInt(i) {
+			return &UnmarshalError{path: path, msg: fmt.Sprintf("%d overflows %s", i, rv.Type())}
+		}
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, ok := wholeNumber(value)
+		if !ok {
+			return mismatch(value, rv, path)
+		}
+		if i < 0 || rv.OverflowUint(uint64(i)) {
+			return &UnmarshalError{path: path, msg: fmt.Sprintf("%d overflows %s", i, rv.Type())}
+		}
+		rv.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		f, ok := toFloat(value)
+		if !ok {
+			return mismatch(value, rv, path)
+		}
+		if rv.OverflowFloat(f) {
+			return &UnmarshalError{path: path, msg: fmt.Sprintf("%v overflows %s", f, rv.Type())}
+		}
+		rv.SetFloat(f)
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return unmarshalBytes(value, rv, path)
+		}
+		return u.unmarshalSlice(value, rv, path)
+	case reflect.Array:
+		arr, ok := value.(JsonArray)
+		if !ok {
+			return mismatch(value, rv, path)
+		}
+		return u.unmarshalElements(arr, rv, path)
+	case reflect.Map:
+		return u.unmarshalMap(value, rv, path)
+	case reflect.Interface:
+		return unmarshalInterface(value, rv, path)
+	case reflect.Ptr:
+		// null sets the pointer to nil, anything else is decoded into a new T
+		if value == nil {
+			rv.SetZero()
+			return
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return u.unmarshal(value, rv.Elem(), path)
+	case reflect.Struct:
+		obj, ok := asMap(value)
+		if !ok {
+			return mismatch(value, rv, path)
+		}
+		return u.unmarshalStruct(obj, rv, path)
+	default:
+		return &UnmarshalError{path: path, msg: fmt.Sprintf("unsupported destination type: %s", rv.Type())}
+	}
+	return
+}
+
+// time.Time from an RFC 3339 string, time.Duration from a string like "1h30m"
+func unmarshalTime(value JsonValue, rv reflect.Value, path string) (err error) {
+	s, ok := value.(string)
+	if !ok {
+		return mismatch(value, rv, path)
+	}
+
+	if rv.Type() == timeType {
+		var t time.Time
+		t, err = time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return &UnmarshalError{path: path, msg: "bad time", cause: err}
+		}
+		rv.Set(reflect.ValueOf(t))
+	} else {
+		var d time.Duration
+		d, err = time.ParseDuration(s)
+		if err != nil {
+			return &UnmarshalError{path: path, msg: "bad duration", cause: err}
+		}
+		rv.SetInt(int64(d))
+	}
+	return
+}
+
+// []byte from a base64 string, null for a nil slice
+func unmarshalBytes(value JsonValue, rv reflect.Value, path string) (err error) {
+	if value == nil {
+		rv.SetZero()
+		return
+	}
+	s, ok := value.(string)
+	if !ok {
+		return mismatch(value, rv, path)
+	}
+
+	var b []byte
+	b, err = base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return &UnmarshalError{path: path, msg: "bad base64", cause: err}
+	}
+	rv.SetBytes(b)
+	return
+}
+
+// an int64, or a float64 without fractional part that fits in int64
+func wholeNumber(value JsonValue) (i int64, ok bool) {
+	switch n := value.(type) {
+	case int64:
+		return n, true
+	case float64:
+		if floatIntEqual(n, int64(n)) {
+			return int64(n), true
+		}
+	}
+	return
+}
+
+// a JsonValue destination gets the tree as is. interface{} gets the plain Go representation:
+// map[string]interface{}, []interface{}, string, bool or nil for null.
+// Numbers are kept as the parser produced them, int64 for integers and float64 otherwise,
+// instead of turning everything into float64 like encoding/json.
+func unmarshalInterface(value JsonValue, rv reflect.Value, path string) (err error) {
+	if value == nil {
+		rv.SetZero()
+		return
+	}
+	if rv.NumMethod() != 0 {
+		return &UnmarshalError{path: path, msg: fmt.Sprintf("unsupported destination type: %s", rv.Type())}
+	}
+
+	if rv.Type() == jsonValueType {
+		rv.Set(reflect.ValueOf(value))
+	} else {
+		rv.Set(reflect.ValueOf(toGoNative(value)))
+	}
+	return
+}
+
+func toGoNative(value JsonValue) interface{} {
+	switch v := value.(type) {
+	case JsonMap:
+		obj := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			obj[key] = toGoNative(item)
+		}
+		return obj
+	case SortedMap:
+		obj := make(map[string]interface{}, len(v))
+		for _, kv := range v {
+			obj[kv.key] = toGoNative(kv.value)
+		}
+		return obj
+	case JsonArray:
+		arr := make([]interface{}, len(v))
+		for i, item := range v {
+			arr[i] = toGoNative(item)
+		}
+		return arr
+	default:
+		return value
+	}
+}
+
+// a null clears the slice
+func (u *unmarshaler) unmarshalSlice(value JsonValue, rv reflect.Value, path string) (err error) {
+	if value == nil {
+		rv.SetZero()
+		return
+	}
+	arr, ok := value.(JsonArray)
+	if !ok {
+		return mismatch(value, rv, path)
+	}
+
+	rv.Set(reflect.MakeSlice(rv.Type(), len(arr), len(arr)))
+	return u.unmarshalElements(arr, rv, path)
+}
+
+// for arrays, extra elements are dropped and missing ones are zeroed, like encoding/json
+func (u *unmarshaler) unmarshalElements(arr JsonArray, rv reflect.Value, path string) (err error) {
+	for i := 0; i < rv.Len(); i++ {
+		if i >= len(arr) {
+			rv.Index(i).SetZero()
+			continue
+		}
+		err = u.unmarshal(arr[i], rv.Index(i), pointerIndex(path, i))
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// keys are strings, integers parsed from strings, or types implementing encoding.TextUnmarshaler. a null clears the map,
+// otherwise members are added to the existing map.
+func (u *unmarshaler) unmarshalMap(value JsonValue, rv reflect.Value, path string) (err error) {
+	if value == nil {
+		rv.SetZero()
+		return
+	}
+	obj, ok := asMap(value)
+	if !ok {
+		return mismatch(value, rv, path)
+	}
+
+	keyType := rv.Type().Key()
+	if !isMapKeyType(keyType) {
+		return &UnmarshalError{path: path, msg: fmt.Sprintf("unsupported map key type: %s", keyType)}
+	}
+
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMapWithSize(rv.Type(), len(obj)))
+	}
+	for _, key := range sortedKeys(obj) {
+		var kv reflect.Value
+		kv, err = mapKey(keyType, key, path)
+		if err != nil {
+			return
+		}
+		elem := reflect.New(rv.Type().Elem()).Elem()
+		err = u.unmarshal(obj[key], elem, pointerJoin(path, key))
+		if err != nil {
+			return
+		}
+		rv.SetMapIndex(kv, elem)
+	}
+	return
+}
+
+// a TextUnmarshaler takes precedence over the kind, like in encoding/json
+func isMapKeyType(keyType reflect.Type) bool {
+	if reflect.PointerTo(keyType).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch keyType.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
+func mapKey(keyType reflect.Type, key string, path string) (kv reflect.Value, err error) {
+	ptr := reflect.New(keyType)
+	kv = ptr.Elem()
+	if tu, ok := ptr.Interface().(encoding.TextUnmarshaler); ok {
+		err = tu.UnmarshalText([]byte(key))
+		if err != nil {
+			err = &UnmarshalError{path: pointerJoin(path, key), msg: fmt.Sprintf("bad map key %q", key), cause: err}
+		}
+		return
+	}
+	switch keyType.Kind() {
+	case reflect.String:
+		kv.SetString(key)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		i, err = strconv.ParseInt(key, 10, keyType.Bits())
+		kv.SetInt(i)
+	default:
+		var i uint64
+		i, err = strconv.ParseUint(key, 10, keyType.Bits())
+		kv.SetUint(i)
+	}
+	if err != nil {
+		err = &UnmarshalError{path: pointerJoin(path, key), msg: fmt.Sprintf("bad map key %q", key), cause: err}
+	}
+	return
+}
+
+func (u *unmarshaler) unmarshalStruct(obj JsonMap, rv reflect.Value, path string) (err error) {
+	fields := typeFields(rv.Type())
+	for _, key := range sortedKeys(obj) {
+		field, ok := lookupField(fields, key)
+		if !ok {
+			if u.opts.DisallowUnknownFields {
+				return &UnmarshalError{path: pointerJoin(path, key), msg: fmt.Sprintf("unknown field %q", key)}
+			}
+			continue
+		}
+		err = u.unmarshal(obj[key], fieldByIndexAlloc(rv, field.index), pointerJoin(path, key))
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func lookupField(fields []fieldInfo, key string) (field fieldInfo, ok bool) {
+	for _, field = range fields {
+		if field.name == key {
+			return field, true
+		}
+	}
+	for _, field = range fields {
+		if strings.EqualFold(field.name, key) {
+			return field, true
+		}
+	}
+	return
+}
@@ -0,0 +1,371 @@
+package json_go
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// accepts either a number or a numeric string
+type flexInt int
+
+func (n *flexInt) UnmarshalJSONValue(value JsonValue) error {
+	switch v := value.(type) {
+	case int64:
+		*n = flexInt(v)
+	case string:
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return err
+		}
+		*n = flexInt(i)
+	default:
+		return fmt.Errorf("expect number or string, got %s", TypeName(value))
+	}
+	return nil
+}
+
+func TestUnmarshal(t *testing.T) {
+	type inner struct {
+		B bool `json:"b"`
+	}
+	type doc struct {
+		Name  string  `json:"name"`
+		Age   uint8   `json:"age"`
+		Score float32 `json:"score"`
+		Int   int     `json:"int"`
+		Skip  string  `json:"-"`
+		Inner inner   `json:"inner"`
+		Upper string
+	}
+
+	var got doc
+	err := Unmarshal(`{"name": "bob", "age": 30, "score": 1.5, "int": 2.0, "Skip": "x",
+		"inner": {"b": true}, "upper": "u", "unknown": [1]}`, &got)
+	if assert.NoError(t, err) {
+		assert.Equal(t, doc{Name: "bob", Age: 30, Score: 1.5, Int: 2, Inner: inner{B: true}, Upper: "u"}, got)
+	}
+
+	bad := func(input string, path string) {
+		var got doc
+		err := Unmarshal(input, &got)
+		var uerr *UnmarshalError
+		if assert.True(t, errors.As(err, &uerr), input) {
+			assert.Equal(t, path, uerr.path)
+		}
+		t.Log(input, "\t", err)
+	}
+	bad(`[]`, "")
+	bad(`{"name": 1}`, "/name")
+	bad(`{"age": 256}`, "/age")
+	bad(`{"age": -1}`, "/age")
+	bad(`{"int": 1.5}`, "/int")
+	bad(`{"score": 1e300}`, "/score")
+	bad(`{"inner": {"b": null}}`, "/inner/b")
+
+	assert.Error(t, Unmarshal(`{}`, got))
+	assert.Error(t, Unmarshal(`{}`, (*doc)(nil)))
+	assert.Error(t, Unmarshal(`{`, &got))
+}
+
+func TestUnmarshalJsonUnmarshaler(t *testing.T) {
+	type doc struct {
+		A flexInt `json:"a"`
+		B flexInt `json:"b"`
+	}
+
+	var got doc
+	if assert.NoError(t, Unmarshal(`{"a": 1, "b": "2"}`, &got)) {
+		assert.Equal(t, doc{A: 1, B: 2}, got)
+	}
+
+	err := Unmarshal(`{"b": true}`, &got)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `"/b"`)
+		assert.Contains(t, err.Error(), "expect number or string, got boolean")
+	}
+
+	err = Unmarshal(`{"a": "x"}`, &got)
+	assert.True(t, errors.Is(err, strconv.ErrSyntax))
+}
+
+func TestGoTime(t *testing.T) {
+	type event struct {
+		At      time.Time     `json:"at"`
+		Timeout time.Duration `json:"timeout"`
+	}
+
+	at := time.Date(2020, 1, 2, 3, 4, 5, 600, time.FixedZone("", 8*3600))
+	output, err := MarshalGo(event{At: at, Timeout: 90 * time.Minute})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"at":"2020-01-02T03:04:05.0000006+08:00","timeout":"1h30m0s"}`, output)
+	}
+
+	var got event
+	if assert.NoError(t, Unmarshal(output, &got)) {
+		assert.True(t, at.Equal(got.At))
+		assert.Equal(t, 90*time.Minute, got.Timeout)
+	}
+	if assert.NoError(t, Unmarshal(`{"at": "2020-01-02T03:04:05Z", "timeout": "1.5s"}`, &got)) {
+		assert.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), got.At)
+		assert.Equal(t, 1500*time.Millisecond, got.Timeout)
+	}
+
+	bad := func(input string, path string) {
+		err := Unmarshal(input, &got)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), path)
+		}
+		t.Log(err)
+	}
+	bad(`{"at": "2020-01-02"}`, `"/at"`)
+	bad(`{"at": 1}`, `"/at"`)
+	bad(`{"timeout": "1 hour"}`, `"/timeout"`)
+	bad(`{"timeout": 1}`, `"/timeout"`)
+}
+
+func TestGoBytes(t *testing.T) {
+	type blob struct {
+		Data  []byte `json:"data"`
+		Empty []byte `json:"empty"`
+		Nil   []byte `json:"nil"`
+	}
+
+	data := make([]byte, 256)
+	for i := range data {
+		data[i] = byte(255 - i)
+	}
+	output, err := MarshalGo(blob{Data: data, Empty: []byte{}})
+	if assert.NoError(t, err) {
+		assert.Contains(t, output, `"empty":"","nil":null`)
+	}
+
+	var got blob
+	if assert.NoError(t, Unmarshal(output, &got)) {
+		assert.Equal(t, blob{Data: data, Empty: []byte{}}, got)
+	}
+
+	err = Unmarshal(`{"data": "not base64!"}`, &got)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `"/data"`)
+	}
+	assert.Error(t, Unmarshal(`{"data": [1, 2]}`, &got))
+}
+
+func TestDisallowUnknownFields(t *testing.T) {
+	type inner struct {
+		A int `json:"a"`
+	}
+	type doc struct {
+		Name  string `json:"name"`
+		Inner inner  `json:"inner"`
+	}
+	strict := UnmarshalOptions{DisallowUnknownFields: true}
+
+	var got doc
+	assert.NoError(t, strict.Unmarshal(`{"name": "x", "inner": {"a": 1}}`, &got))
+	assert.NoError(t, strict.Unmarshal(`{"NAME": "x"}`, &got))
+
+	err := strict.Unmarshal(`{"name": "x", "inner": {"a": 1, "b~/": 2}}`, &got)
+	var uerr *UnmarshalError
+	if assert.True(t, errors.As(err, &uerr)) {
+		assert.Equal(t, "/inner/b~0~1", uerr.path)
+		assert.Contains(t, err.Error(), `unknown field "b~/"`)
+	}
+
+	// lenient by default
+	assert.NoError(t, Unmarshal(`{"name": "x", "typo": 1}`, &got))
+}
+
+func TestUnmarshalMapSlice(t *testing.T) {
+	type item struct {
+		ID int `json:"id"`
+	}
+	type doc struct {
+		Items  []item            `json:"items"`
+		Matrix [][]float64       `json:"matrix"`
+		Pair   [2]string         `json:"pair"`
+		ByName map[string]item   `json:"by_name"`
+		ByID   map[int64][]uint8 `json:"by_id"`
+		Tags   map[uint16]string `json:"tags"`
+	}
+
+	var got doc
+	err := Unmarshal(`{
+		"items": [{"id": 1}, {"id": 2}],
+		"matrix": [[1, 2.5], []],
+		"pair": ["a", "b", "c"],
+		"by_name": {"x": {"id": 3}},
+		"by_id": {"-1": "AQI=", "2": null},
+		"tags": {"65535": "max"}
+	}`, &got)
+	if assert.NoError(t, err) {
+		assert.Equal(t, doc{
+			Items:  []item{{1}, {2}},
+			Matrix: [][]float64{{1, 2.5}, {}},
+			Pair:   [2]string{"a", "b"},
+			ByName: map[string]item{"x": {3}},
+			ByID:   map[int64][]uint8{-1: {1, 2}, 2: nil},
+			Tags:   map[uint16]string{65535: "max"},
+		}, got)
+	}
+
+	var top []map[string]int
+	if assert.NoError(t, Unmarshal(`[{"a": 1}, {}]`, &top)) {
+		assert.Equal(t, []map[string]int{{"a": 1}, {}}, top)
+	}
+	if assert.NoError(t, Unmarshal(`null`, &top)) {
+		assert.Nil(t, top)
+	}
+
+	bad := func(input string, path string) {
+		var got doc
+		err := Unmarshal(input, &got)
+		var uerr *UnmarshalError
+		if assert.True(t, errors.As(err, &uerr), input) {
+			assert.Equal(t, path, uerr.path)
+		}
+		t.Log(input, "\t", err)
+	}
+	bad(`{"items": {}}`, "/items")
+	bad(`{"items": [{"id": 1}, {"id": "2"}]}`, "/items/1/id")
+	bad(`{"matrix": [[1], [true]]}`, "/matrix/1/0")
+	bad(`{"pair": "ab"}`, "/pair")
+	bad(`{"by_name": []}`, "/by_name")
+	bad(`{"by_name": {"x": {"id": null}}}`, "/by_name/x/id")
+	bad(`{"by_id": {"x": ""}}`, "/by_id/x")
+	bad(`{"tags": {"65536": ""}}`, "/tags/65536")
+
+	var badKey map[bool]int
+	assert.Error(t, Unmarshal(`{"true": 1}`, &badKey))
+}
+
+// a map key like "x:3,y:4"
+type gridKey struct{ X, Y int }
+
+func (k *gridKey) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "x:%d,y:%d", &k.X, &k.Y)
+	return err
+}
+
+func TestUnmarshalMapKeys(t *testing.T) {
+	type doc struct {
+		Names  map[int]string     `json:"names"`
+		Counts map[uint64]int     `json:"counts"`
+		Cells  map[gridKey]string `json:"cells"`
+	}
+	input := `{
+		"names": {"1": "a", "-2": "b"},
+		"counts": {"18446744073709551615": 1},
+		"cells": {"x:3,y:4": "ship", "x:0,y:0": ""}
+	}`
+	expect := doc{
+		Names:  map[int]string{1: "a", -2: "b"},
+		Counts: map[uint64]int{18446744073709551615: 1},
+		Cells:  map[gridKey]string{{3, 4}: "ship", {0, 0}: ""},
+	}
+	for _, decode := range []func(string, interface{}) error{Unmarshal, DecodeInto} {
+		var got doc
+		if assert.NoError(t, decode(input, &got)) {
+			assert.Equal(t, expect, got)
+		}
+
+		var uerr *UnmarshalError
+		err := decode(`{"names": {"1x": ""}}`, &got)
+		if assert.True(t, errors.As(err, &uerr)) {
+			assert.Equal(t, "/names/1x", uerr.path)
+			assert.Contains(t, err.Error(), `bad map key "1x"`)
+		}
+		err = decode(`{"counts": {"-1": 0}}`, &got)
+		if assert.True(t, errors.As(err, &uerr)) {
+			assert.Equal(t, "/counts/-1", uerr.path)
+		}
+		err = decode(`{"cells": {"3,4": ""}}`, &got)
+		if assert.True(t, errors.As(err, &uerr)) {
+			assert.Equal(t, "/cells/3,4", uerr.path)
+			assert.Contains(t, err.Error(), `bad map key "3,4"`)
+		}
+	}
+}
+
+func TestUnmarshalInterface(t *testing.T) {
+	type doc struct {
+		Name  string      `json:"name"`
+		Extra interface{} `json:"extra"`
+		Any   any         `json:"any"`
+		Tree  JsonValue   `json:"tree"`
+		Err   error       `json:"err"`
+	}
+
+	var got doc
+	err := Unmarshal(`{"name": "n", "extra": {"a": [1, 2.5, "s", true, null], "b": {}},
+		"any": 3, "tree": {"c": [1]}, "err": null}`, &got)
+	if assert.NoError(t, err) {
+		assert.Equal(t, doc{
+			Name: "n",
+			Extra: map[string]interface{}{
+				"a": []interface{}{int64(1), 2.5, "s", true, nil},
+				"b": map[string]interface{}{},
+			},
+			Any:  int64(3),
+			Tree: JsonMap{"c": JsonArray{int64(1)}},
+		}, got)
+	}
+
+	got.Any = "old"
+	if assert.NoError(t, Unmarshal(`{"any": null}`, &got)) {
+		assert.Nil(t, got.Any)
+	}
+
+	var top interface{}
+	if assert.NoError(t, Unmarshal(`[{}]`, &top)) {
+		assert.Equal(t, []interface{}{map[string]interface{}{}}, top)
+	}
+
+	assert.Error(t, Unmarshal(`{"err": "x"}`, &got))
+}
+
+func TestUnmarshalPointer(t *testing.T) {
+	type patch struct {
+		Name  *string  `json:"name"`
+		Note  *string  `json:"note,omitempty"`
+		Count **int    `json:"count"`
+		Flex  *flexInt `json:"flex"`
+	}
+
+	// absent, null and present
+	var got patch
+	if assert.NoError(t, Unmarshal(`{}`, &got)) {
+		assert.Nil(t, got.Name)
+	}
+	name := "old"
+	got.Name = &name
+	if assert.NoError(t, Unmarshal(`{"name": null}`, &got)) {
+		assert.Nil(t, got.Name)
+	}
+	if assert.NoError(t, Unmarshal(`{"name": "new", "count": 3, "flex": "4"}`, &got)) {
+		if assert.NotNil(t, got.Name) {
+			assert.Equal(t, "new", *got.Name)
+		}
+		assert.Equal(t, 3, **got.Count)
+		assert.Equal(t, flexInt(4), *got.Flex)
+	}
+	err := Unmarshal(`{"name": 1}`, &got)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `"/name"`)
+	}
+
+	output, err := MarshalGo(patch{})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"count":null,"flex":null,"name":null}`, output)
+	}
+	empty := ""
+	output, err = MarshalGo(patch{Name: &empty, Note: &empty})
+	if assert.NoError(t, err) {
+		assert.Equal(t, `{"count":null,"flex":null,"name":"","note":""}`, output)
+	}
+}
@@ -0,0 +1,83 @@
+package json_go
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// ToURLValues turns a flat object into query parameters: a string is kept as is, an int64 is written
+// with all its digits and a float64 by FormatNumber, a bool as "true" or "false" and null as the empty
+// string. An array becomes one parameter per element, in order, and an empty array leaves the key out.
+// Nested structures are not flattened: an object member, or an array or object inside an array, is a
+// MarshalError at its path.
+func ToURLValues(m JsonMap) (values url.Values, err error) {
+	values = url.Values{}
+	for _, key := range sortedKeys(m) {
+		path := pointerJoin("", key)
+		if arr, ok := m[key].(JsonArray); ok {
+			for i, item := range arr {
+				var str string
+				str, err = urlValue(item, pointerIndex(path, i))
+				if err != nil {
+					return nil, err
+				}
+				values.Add(key, str)
+			}
+			continue
+		}
+		var str string
+		str, err = urlValue(m[key], path)
+		if err != nil {
+			return nil, err
+		}
+		values.Set(key, str)
+	}
+	return
+}
+
+func urlValue(value JsonValue, path string) (str string, err error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		str, err = FormatNumber(v)
+		if merr, ok := err.(*MarshalError); ok {
+			merr.path = path
+		}
+		return
+	case Decimal:
+		return v.String(), nil
+	case JsonArray, JsonMap, SortedMap:
+		return "", &MarshalError{path: path, msg: fmt.Sprintf("nested %s in URL values", TypeName(value))}
+	default:
+		return "", &MarshalError{path: path, msg: fmt.Sprintf("unsupported type: %T", value)}
+	}
+}
+
+// FromURLValues is the inverse of ToURLValues, with every value a string: a key with one value
+// maps to that string and a key with several to a JsonArray of them. Keys without values are left out.
+// Nothing is parsed from the strings, "1" stays a string and "a[b]" is a plain key.
+func FromURLValues(values url.Values) JsonMap {
+	m := JsonMap{}
+	for key, list := range values {
+		switch len(list) {
+		case 0:
+		case 1:
+			m[key] = list[0]
+		default:
+			arr := make(JsonArray, len(list))
+			for i, str := range list {
+				arr[i] = str
+			}
+			m[key] = arr
+		}
+	}
+	return m
+}
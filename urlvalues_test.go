@@ -0,0 +1,49 @@
+package json_go
+
+import (
+	"math"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToURLValues(t *testing.T) {
+	values, err := ToURLValues(MustParse(t, `{"q": "a b", "n": 10, "f": 0.5, "on": true, "none": null, "tag": ["x", 2, false], "empty": []}`).(JsonMap))
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"q": {"a b"}, "n": {"10"}, "f": {"0.5"}, "on": {"true"}, "none": {""}, "tag": {"x", "2", "false"},
+	}, values)
+	assert.Equal(t, "f=0.5&n=10&none=&on=true&q=a+b&tag=x&tag=2&tag=false", values.Encode())
+
+	values, err = ToURLValues(JsonMap{"d": Decimal{"1.50"}})
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"d": {"1.50"}}, values)
+
+	// integers above 2^53 keep all their digits
+	values, err = ToURLValues(JsonMap{"id": int64(9007199254740993), "min": int64(math.MinInt64)})
+	assert.NoError(t, err)
+	assert.Equal(t, url.Values{"id": {"9007199254740993"}, "min": {"-9223372036854775808"}}, values)
+
+	_, err = ToURLValues(MustParse(t, `{"a": {"b": 1}}`).(JsonMap))
+	assert.Equal(t, &MarshalError{path: "/a", msg: "nested object in URL values"}, err)
+	_, err = ToURLValues(MustParse(t, `{"a": [1, [2]]}`).(JsonMap))
+	assert.Equal(t, &MarshalError{path: "/a/1", msg: "nested array in URL values"}, err)
+	_, err = ToURLValues(JsonMap{"a": JsonArray{math.NaN()}})
+	assert.Equal(t, &MarshalError{path: "/a/0", msg: "unsupported float: NaN"}, err)
+	_, err = ToURLValues(JsonMap{"a": 1})
+	assert.Equal(t, &MarshalError{path: "/a", msg: "unsupported type: int"}, err)
+}
+
+func TestFromURLValues(t *testing.T) {
+	values, err := url.ParseQuery("q=a+b&n=10&tag=x&tag=2&a[b]=c")
+	assert.NoError(t, err)
+	assert.Equal(t, JsonMap{"q": "a b", "n": "10", "tag": JsonArray{"x", "2"}, "a[b]": "c"}, FromURLValues(values))
+	assert.Equal(t, JsonMap{}, FromURLValues(url.Values{"none": {}}))
+
+	// strings round trip
+	m := JsonMap{"a": "1", "b": JsonArray{"x", "y"}}
+	values, err = ToURLValues(m)
+	assert.NoError(t, err)
+	assert.Equal(t, m, FromURLValues(values))
+}
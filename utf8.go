@@ -1,11 +1,19 @@
 package json_go
 
-import "fmt"
+import (
+	"fmt"
+	"unicode/utf8"
+)
 
 type DecodingError struct {
-	pos  int
-	char byte
-	msg  string
+	pos   int
+	char  byte
+	msg   string
+	start int // the bad sequence is [start, end)
+	end   int
+	runes int // decoded before it, by Decode, ParseBytes, the Decoder and the Scanner
+	// from ParseBytes, where a ParseError is at a byte offset
+	inBytes bool
 }
 
 func (err *DecodingError) Error() string {
@@ -13,9 +21,53 @@ func (err *DecodingError) Error() string {
 		err.pos, err.char, err.msg)
 }
 
+// Pos is the byte offset of the bad sequence.
+func (err *DecodingError) Pos() int {
+	return err.pos
+}
+
+// Byte is the offending byte, 0 if the input ended in the middle of a sequence.
+func (err *DecodingError) Byte() byte {
+	return err.char
+}
+
+// Range is the byte offsets of the bad sequence: from its leading byte to the first byte that is not part of it.
+// It is a single byte for a bad leading byte, and for a bad following byte it is the leading byte and
+// the following bytes before Pos, as the byte at Pos may start the next character.
+func (err *DecodingError) Range() (start, end int) {
+	return err.start, err.end
+}
+
+// Runes is the number of valid code points before the bad sequence, for Decode, ParseBytes, the Decoder and the Scanner.
+// It is the position of the error for a parser that works on the decoded runes.
+func (err *DecodingError) Runes() int {
+	return err.runes
+}
+
+// As lets errors.As take a DecodingError as a ParseError in the unit of the other errors of the same call:
+// the byte offset of Pos from ParseBytes, and the rune offset of Runes from Parse and the rest.
+// So callers can handle bad UTF-8 and bad JSON with one errors.As,
+// and still errors.As to the DecodingError for the details.
+func (err *DecodingError) As(target interface{}) bool {
+	if perr, ok := target.(**ParseError); ok {
+		pos := err.runes
+		if err.inBytes {
+			pos = err.pos
+		}
+		*perr = &ParseError{pos, "invalid UTF-8: " + err.msg}
+		return true
+	}
+	return false
+}
+
+// ReadCode decodes the UTF-8 sequence at cur. Overlong encodings and surrogate code points are rejected,
+// noncharacters like U+FFFF are valid.
 func ReadCode(buf []byte, cur int) (code rune, next int, err error) {
+	bad := func(pos int, char byte, msg string, end int) error {
+		return &DecodingError{pos, char, msg, cur, end, 0, false}
+	}
 	if len(buf)-cur <= 0 {
-		err = &DecodingError{cur, 0, "no enough data"}
+		err = bad(cur, 0, "no enough data", cur)
 		return
 	}
 
@@ -26,7 +78,7 @@ func ReadCode(buf []byte, cur int) (code rune, next int, err error) {
 		next = cur + 1
 		mask = 0xff
 	case leading < 0xc0: // prefix 10
-		err = &DecodingError{cur, leading, "unexpected leading char"}
+		err = bad(cur, leading, "unexpected leading char", cur+1)
 	case leading < 0xe0: // prefix 110
 		next = cur + 2
 		mask = 0x1f
@@ -37,7 +89,7 @@ func ReadCode(buf []byte, cur int) (code rune, next int, err error) {
 		next = cur + 4
 		mask = 0x07
 	default:
-		err = &DecodingError{cur, leading, "bad leading char"}
+		err = bad(cur, leading, "bad leading char", cur+1)
 	}
 
 	if err != nil {
@@ -46,27 +98,45 @@ func ReadCode(buf []byte, cur int) (code rune, next int, err error) {
 
 	numFollowing := next - cur - 1
 	if numFollowing+1 > len(buf)-cur {
-		err = &DecodingError{
-			cur, leading,
+		err = bad(cur, leading,
 			fmt.Sprintf("buf not enough. req: %d, remain: %d",
-				numFollowing+1, len(buf)-cur)}
+				numFollowing+1, len(buf)-cur), len(buf))
 		return
 	}
 
 	code = rune(leading & mask)
 	for i := 0; i < numFollowing; i++ {
+		following := buf[cur+1+i]
+		if following&0xc0 != 0x80 {
+			err = bad(cur+1+i, following, "bad following char", cur+1+i)
+			code = 0
+			return
+		}
 		code <<= 6
-		code |= rune(buf[cur+1+i] & 0x3f)
+		code |= rune(following & 0x3f)
+	}
+	switch {
+	case code < [...]rune{0, 0x80, 0x800, 0x10000}[numFollowing]:
+		err = bad(cur, leading, "overlong encoding", next)
+	case 0xd800 <= code && code < 0xe000:
+		err = bad(cur, leading, "surrogate code point", next)
+	case code > utf8.MaxRune:
+		err = bad(cur, leading, "code point out of range", next)
+	}
+	if err != nil {
+		code = 0
 	}
 
 	return
 }
 
+// Decode decodes UTF-8 to runes. On error, output has the runes before the bad sequence.
 func Decode(input []byte) (output []rune, err error) {
 	for cur := 0; cur < len(input); {
 		var code rune
 		code, cur, err = ReadCode(input, cur)
 		if err != nil {
+			err.(*DecodingError).runes = len(output)
 			return
 		}
 		output = append(output, code)
@@ -77,3 +147,24 @@ func Decode(input []byte) (output []rune, err error) {
 func DecodeString(input string) (output []rune, err error) {
 	return Decode([]byte(input))
 }
+
+// RuneLen is the length of s in code points. A combining mark counts on its own,
+// so "e\u0301" has length 2. Each byte of invalid UTF-8 counts as one.
+func RuneLen(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// TruncateRunes keeps the first max code points of s, never splitting a multi-byte character.
+func TruncateRunes(s string, max int) string {
+	if max <= 0 {
+		return ""
+	}
+	n := 0
+	for i := range s {
+		if n == max {
+			return s[:i]
+		}
+		n++
+	}
+	return s
+}
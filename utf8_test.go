@@ -1,7 +1,9 @@
 package json_go
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -26,10 +28,23 @@ func TestReadCode(t *testing.T) {
 	bad("啊"[1:])
 	bad("啊"[:1])
 	bad("啊"[:2])
+	bad("\xe0@0")           // not a following byte
+	bad("\xc0\xb0")         // overlong '0'
+	bad("\xe0\x80\xaf")     // overlong '/'
+	bad("\xf0\x8f\xbf\xbf") // overlong U+FFFF
+	bad("\xed\xbf\xbf")     // surrogate
+	bad("\xf4\x90\x80\x80") // beyond U+10FFFF
+
+	_, _, err := ReadCode([]byte("\xe0@0"), 0)
+	assert.Equal(t, &DecodingError{1, '@', "bad following char", 0, 1, 0, false}, err)
 
 	good("a", 'a', 1)
 	good("啊", 0x554a, 3)
 	good("\xf4\x8f\xbf\xbf", 0x10ffff, 4)
+	good("\xc2\x80", 0x80, 2)
+	good("\xe0\xa0\x80", 0x800, 3)
+	good("\xf0\x90\x80\x80", 0x10000, 4)
+	good("\xef\xbf\xbf", 0xffff, 3)
 }
 
 func TestDecodeString(t *testing.T) {
@@ -50,3 +65,101 @@ func TestDecodeString(t *testing.T) {
 	good("asdf啊124")
 	bad("asdf啊\xfe124")
 }
+
+func TestDecodingErrorAs(t *testing.T) {
+	for _, input := range []string{"[\"a\", \xff]", "[1,  2"} {
+		_, err := Parse(input)
+		var perr *ParseError
+		if assert.True(t, errors.As(err, &perr), input) {
+			assert.Equal(t, 6, perr.Pos())
+		}
+	}
+
+	_, err := Parse("[\"\xe5\x95\x8a\", \xe5\x95]")
+	var perr *ParseError
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, &ParseError{6, "invalid UTF-8: bad following char"}, perr)
+	var derr *DecodingError
+	if assert.True(t, errors.As(err, &derr)) {
+		assert.Equal(t, 10, derr.Pos())
+		assert.Equal(t, 6, derr.Runes())
+		assert.Equal(t, byte(']'), derr.Byte())
+	}
+
+	// byte offsets from bytes, like the other errors of ParseBytes
+	_, err = ParseBytes([]byte("[\"\xe5\x95\x8a\", \xe5\x95]"))
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, &ParseError{10, "invalid UTF-8: bad following char"}, perr)
+	if assert.True(t, errors.As(err, &derr)) {
+		assert.Equal(t, 10, derr.Pos())
+		assert.Equal(t, 6, derr.Runes())
+	}
+	_, err = ParseBytes([]byte("[\"\xe5\x95\x8a\", \xff]"))
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, &ParseError{8, "invalid UTF-8: bad leading char"}, perr)
+	_, err = ParseBytes([]byte("[\"\xe5\x95\x8a\", x]"))
+	assert.Equal(t, &ParseError{8, "bad char: 'x' (0x78)"}, err)
+	// and rune offsets from runes
+	_, err = Parse("[\"\xe5\x95\x8a\", \xff]")
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, &ParseError{6, "invalid UTF-8: bad leading char"}, perr)
+	_, err = Parse("[\"\xe5\x95\x8a\", x]")
+	assert.Equal(t, &ParseError{6, "bad char: 'x' (0x78)"}, err)
+
+	_, err = ParseBytes([]byte("[\xc0\x80]"))
+	assert.True(t, errors.As(err, &perr))
+	assert.Equal(t, &ParseError{1, "invalid UTF-8: overlong encoding"}, perr)
+	assert.False(t, errors.As(&ParseError{0, "x"}, &derr))
+}
+
+func TestRuneLen(t *testing.T) {
+	assert.Equal(t, 0, RuneLen(""))
+	assert.Equal(t, 3, RuneLen("abc"))
+	assert.Equal(t, 2, RuneLen("啊啊"))
+	assert.Equal(t, 2, RuneLen("e\u0301"))
+	assert.Equal(t, 3, RuneLen("a\U0001F600b"))
+	assert.Equal(t, 2, RuneLen("\xe5\x95"))
+}
+
+func TestTruncateRunes(t *testing.T) {
+	assert.Equal(t, "ab", TruncateRunes("abc", 2))
+	assert.Equal(t, "abc", TruncateRunes("abc", 3))
+	assert.Equal(t, "abc", TruncateRunes("abc", 10))
+	assert.Equal(t, "", TruncateRunes("abc", 0))
+	assert.Equal(t, "", TruncateRunes("abc", -1))
+	assert.Equal(t, "啊", TruncateRunes("啊啊", 1))
+	assert.Equal(t, "a\U0001F600", TruncateRunes("a\U0001F600\U0001F600", 2))
+	// a combining mark is a code point of its own
+	assert.Equal(t, "cafe", TruncateRunes("cafe\u0301!", 4))
+	assert.Equal(t, "cafe\u0301", TruncateRunes("cafe\u0301!", 5))
+}
+
+func TestDecodeErrorDetails(t *testing.T) {
+	check := func(input string, runes int, start int, end int, pos int) {
+		output, err := DecodeString(input)
+		derr, ok := err.(*DecodingError)
+		if !assert.True(t, ok, input) {
+			return
+		}
+		assert.Equal(t, runes, derr.Runes(), input)
+		assert.Equal(t, runes, len(output), input)
+		s, e := derr.Range()
+		assert.Equal(t, []int{start, end}, []int{s, e}, input)
+		assert.Equal(t, pos, derr.Pos(), input)
+	}
+	check("héllo \xff world", 6, 7, 8, 7)
+	check("héllo \xe5\x95@ world", 6, 7, 9, 9)     // cut short by '@'
+	check("héllo \xed\xa0\x80 world", 6, 7, 10, 7) // surrogate
+	check("héllo \xc0\xaf", 6, 7, 9, 7)            // overlong
+	check("héllo \xe5\x95", 6, 7, 9, 7)            // end of input
+
+	d := NewDecoder(strings.NewReader("[\"啊\", \"\xff\"]"))
+	var err error
+	for err == nil {
+		_, err = d.Token()
+	}
+	derr := err.(*DecodingError)
+	assert.Equal(t, 7, derr.Runes())
+	s, e := derr.Range()
+	assert.Equal(t, []int{9, 10}, []int{s, e})
+}
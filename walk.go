@@ -0,0 +1,147 @@
+package json_go
+
+import "strings"
+
+// Walk calls fn for every value of the tree in document order, containers before their children,
+// with the JSON Pointer of the value. Object members are visited in sorted key order.
+// An error returned by fn stops the walk and is returned.
+func Walk(root JsonValue, fn func(path string, v JsonValue) error) error {
+	return walk(root, "", func(path string, v JsonValue) (bool, error) {
+		return true, fn(path, v)
+	})
+}
+
+// WalkPrune is Walk, except that the children of an array or object are skipped
+// when fn returns false for it.
+func WalkPrune(root JsonValue, fn func(path string, v JsonValue) (descend bool, err error)) error {
+	return walk(root, "", fn)
+}
+
+func walk(value JsonValue, path string, fn func(path string, v JsonValue) (bool, error)) (err error) {
+	descend, err := fn(path, value)
+	if err != nil || !descend {
+		return
+	}
+
+	switch v := value.(type) {
+	case JsonArray:
+		for i, item := range v {
+			err = walk(item, pointerIndex(path, i), fn)
+			if err != nil {
+				return
+			}
+		}
+	case JsonMap:
+		for _, key := range sortedKeys(v) {
+			err = walk(v[key], pointerJoin(path, key), fn)
+			if err != nil {
+				return
+			}
+		}
+	case SortedMap:
+		for _, kv := range v {
+			err = walk(kv.value, pointerJoin(path, kv.key), fn)
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// pointerMatch reports whether path is pattern or below it.
+// A `*` token in the pattern matches any single token.
+func pointerMatch(pattern string, path string) bool {
+	if pattern == "" {
+		return true
+	}
+	patTokens := strings.Split(pattern, "/")[1:]
+	pathTokens := strings.Split(path, "/")[1:]
+	if path == "" || len(pathTokens) < len(patTokens) {
+		return false
+	}
+	for i, tok := range patTokens {
+		if tok != "*" && tok != pathTokens[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// FindKey returns the JSON Pointer of every object member named key, at any depth, in Walk order.
+func FindKey(root JsonValue, key string) []string {
+	return findKey(root, func(k string) bool { return k == key })
+}
+
+// FindKeyFold is FindKey with case-insensitive key matching.
+func FindKeyFold(root JsonValue, key string) []string {
+	return findKey(root, func(k string) bool { return strings.EqualFold(k, key) })
+}
+
+func findKey(root JsonValue, match func(key string) bool) []string {
+	paths := []string{}
+	_ = Walk(root, func(path string, v JsonValue) error {
+		var keys []string
+		switch obj := v.(type) {
+		case JsonMap:
+			keys = sortedKeys(obj)
+		case SortedMap:
+			keys = obj.Keys()
+		}
+		for _, key := range keys {
+			if match(key) {
+				paths = append(paths, pointerJoin(path, key))
+			}
+		}
+		return nil
+	})
+	return paths
+}
+
+type LeafEntry struct {
+	Path  string // JSON Pointer
+	Value JsonValue
+}
+
+// Leaves lists the scalar values of the tree with their paths, in Walk order.
+// Empty arrays and objects have no leaves and don't appear.
+func Leaves(root JsonValue) []LeafEntry {
+	entries := []LeafEntry{}
+	_ = Walk(root, func(path string, v JsonValue) error {
+		switch v.(type) {
+		case JsonArray, JsonMap, SortedMap:
+		default:
+			entries = append(entries, LeafEntry{path, v})
+		}
+		return nil
+	})
+	return entries
+}
+
+// which nodes PathsOf lists
+type PathKind int
+
+const (
+	AllPaths       PathKind = iota
+	LeafPaths               // values that are not arrays or objects
+	ContainerPaths          // arrays and objects, including empty ones
+)
+
+// Paths returns the JSON Pointer of every value of the tree, the root included, in Walk order.
+func Paths(root JsonValue) []string {
+	return PathsOf(root, AllPaths)
+}
+
+// PathsOf is Paths limited to leaves or containers.
+func PathsOf(root JsonValue, kind PathKind) []string {
+	paths := []string{}
+	_ = Walk(root, func(path string, v JsonValue) error {
+		_, isArray := v.(JsonArray)
+		container := isArray || isObject(v)
+		if kind == AllPaths || (kind == ContainerPaths) == container {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths
+}
@@ -0,0 +1,109 @@
+package json_go
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalk(t *testing.T) {
+	root := MustParse(t, `{"b": [1, {"c/d": null}], "a": {}}`)
+	paths := []string{}
+	values := []JsonValue{}
+	err := Walk(root, func(path string, v JsonValue) error {
+		paths = append(paths, path)
+		values = append(values, v)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "/a", "/b", "/b/0", "/b/1", "/b/1/c~1d"}, paths)
+	assert.Equal(t, root, values[0])
+	assert.Equal(t, int64(1), values[3])
+
+	stop := errors.New("stop")
+	count := 0
+	err = Walk(root, func(path string, v JsonValue) error {
+		count++
+		if path == "/b/0" {
+			return stop
+		}
+		return nil
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 4, count)
+}
+
+func TestWalkPrune(t *testing.T) {
+	root := MustParse(t, `{"b": [1, {"c": null}], "a": {"x": 1}, "d": [2]}`)
+	paths := []string{}
+	err := WalkPrune(root, func(path string, v JsonValue) (bool, error) {
+		paths = append(paths, path)
+		return path != "/a" && path != "/b/1", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"", "/a", "/b", "/b/0", "/b/1", "/d", "/d/0"}, paths)
+
+	// pruning the root visits only the root
+	paths = paths[:0]
+	err = WalkPrune(root, func(path string, v JsonValue) (bool, error) {
+		paths = append(paths, path)
+		return false, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{""}, paths)
+
+	stop := errors.New("stop")
+	err = WalkPrune(root, func(path string, v JsonValue) (bool, error) {
+		if path == "/b/0" {
+			return true, stop
+		}
+		return true, nil
+	})
+	assert.Equal(t, stop, err)
+}
+
+func TestPointerMatch(t *testing.T) {
+	assert.True(t, pointerMatch("", ""))
+	assert.True(t, pointerMatch("", "/a"))
+	assert.True(t, pointerMatch("/a", "/a"))
+	assert.True(t, pointerMatch("/a", "/a/b"))
+	assert.True(t, pointerMatch("/a/*/c", "/a/0/c"))
+	assert.True(t, pointerMatch("/a/*", "/a/x/y"))
+	assert.False(t, pointerMatch("/a", ""))
+	assert.False(t, pointerMatch("/a", "/ab"))
+	assert.False(t, pointerMatch("/a/*/c", "/a/0"))
+	assert.False(t, pointerMatch("/a/*/c", "/a/0/d"))
+}
+
+func TestFindKey(t *testing.T) {
+	root := MustParse(t, `{"password": 1, "users": [{"name": "a", "Password": "x"}, {"password": {"password": 2}}]}`)
+	assert.Equal(t, []string{"/password", "/users/1/password", "/users/1/password/password"}, FindKey(root, "password"))
+	assert.Equal(t,
+		[]string{"/password", "/users/0/Password", "/users/1/password", "/users/1/password/password"},
+		FindKeyFold(root, "PASSWORD"))
+	assert.Equal(t, []string{}, FindKey(root, "missing"))
+	assert.Equal(t, []string{}, FindKey("password", "password"))
+}
+
+func TestLeaves(t *testing.T) {
+	root := MustParse(t, `{"b": [1, 2.5, {"c": null}], "a": {"x": true, "y": "s"}, "e": [], "d/": {}}`)
+	assert.Equal(t, []LeafEntry{
+		{"/a/x", true},
+		{"/a/y", "s"},
+		{"/b/0", int64(1)},
+		{"/b/1", 2.5},
+		{"/b/2/c", nil},
+	}, Leaves(root))
+	assert.Equal(t, []LeafEntry{{"", "x"}}, Leaves("x"))
+	assert.Equal(t, []LeafEntry{}, Leaves(JsonArray{}))
+}
+
+func TestPaths(t *testing.T) {
+	root := MustParse(t, `{"b": [1, {"c/d": null}], "a": {}}`)
+	assert.Equal(t, []string{"", "/a", "/b", "/b/0", "/b/1", "/b/1/c~1d"}, Paths(root))
+	assert.Equal(t, []string{"/b/0", "/b/1/c~1d"}, PathsOf(root, LeafPaths))
+	assert.Equal(t, []string{"", "/a", "/b", "/b/1"}, PathsOf(root, ContainerPaths))
+	assert.Equal(t, []string{""}, Paths(int64(1)))
+	assert.Equal(t, []string{}, PathsOf(int64(1), ContainerPaths))
+}